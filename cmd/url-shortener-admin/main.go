@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/joho/godotenv"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"url-shortener/proto"
+)
+
+// callTimeout ограничивает время одного вызова служебного сервиса
+const callTimeout = 10 * time.Second
+
+const usage = `Использование: url-shortener-admin [-addr host:port] [-token TOKEN] <команда>
+
+Команды:
+  keys issue -owner ID [-name NAME]  выпустить ключ API
+  keys list                          список ключей API
+  keys revoke KEY_ID                 отозвать ключ API
+  keyspace                           заполненность пространства кодов ссылок
+`
+
+// errUsage сообщает о неверных аргументах командной строки
+var errUsage = errors.New("invalid arguments")
+
+func main() {
+	fs := flag.NewFlagSet("url-shortener-admin", flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(fs.Output(), usage) }
+	addr := fs.String("addr", "localhost:9090", "адрес порта администрирования сервиса (ADMIN_PORT)")
+	// Токен по умолчанию берётся из ADMIN_TOKEN, в том числе из .env рядом с сервером
+	godotenv.Load() //nolint:errcheck
+	token := fs.String("token", os.Getenv("ADMIN_TOKEN"), "токен администратора (ADMIN_TOKEN)")
+	fs.Parse(os.Args[1:]) //nolint:errcheck
+
+	conn, err := grpc.NewClient(*addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to connect:", err)
+		os.Exit(1)
+	}
+	defer conn.Close() //nolint:errcheck
+
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+*token)
+	err = run(ctx, proto.NewAdminClient(conn), fs.Args(), os.Stdout)
+	if errors.Is(err, errUsage) {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+// run выполняет команду args и пишет результат в out
+func run(ctx context.Context, client proto.AdminClient, args []string, out io.Writer) error {
+	if len(args) == 1 && args[0] == "keyspace" {
+		return showKeyspace(ctx, client, out)
+	}
+	if len(args) < 2 || args[0] != "keys" {
+		return errUsage
+	}
+	switch args[1] {
+	case "issue":
+		return issueKey(ctx, client, args[2:], out)
+	case "list":
+		return listKeys(ctx, client, out)
+	case "revoke":
+		if len(args) != 3 {
+			return errUsage
+		}
+		if _, err := client.RevokeAPIKey(ctx, &proto.RevokeAPIKeyRequest{Id: args[2]}); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Revoked", args[2])
+		return nil
+	}
+	return errUsage
+}
+
+// issueKey выпускает ключ API и выводит его. Ключ показывается только один раз
+func issueKey(ctx context.Context, client proto.AdminClient, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("keys issue", flag.ContinueOnError)
+	owner := fs.String("owner", "", "владелец ссылок, созданных с ключом")
+	name := fs.String("name", "", "описание ключа")
+	if err := fs.Parse(args); err != nil || *owner == "" {
+		return errUsage
+	}
+
+	resp, err := client.IssueAPIKey(ctx, &proto.IssueAPIKeyRequest{OwnerId: *owner, Name: *name})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "ID: ", resp.GetKey().GetId())
+	fmt.Fprintln(out, "Key:", resp.GetSecret())
+	fmt.Fprintln(out, "Store the key now: it cannot be shown again.")
+	return nil
+}
+
+// listKeys выводит таблицу ключей API
+func listKeys(ctx context.Context, client proto.AdminClient, out io.Writer) error {
+	resp, err := client.ListAPIKeys(ctx, &proto.ListAPIKeysRequest{})
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tOWNER\tNAME\tCREATED\tREVOKED")
+	for _, key := range resp.GetKeys() {
+		revoked := "-"
+		if key.GetRevokedAt() != nil {
+			revoked = key.GetRevokedAt().AsTime().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			key.GetId(), key.GetOwnerId(), key.GetName(), key.GetCreatedAt().AsTime().Format(time.RFC3339), revoked)
+	}
+	return w.Flush()
+}
+
+// showKeyspace выводит длину кодов, число ссылок и долю занятых кодов
+func showKeyspace(ctx context.Context, client proto.AdminClient, out io.Writer) error {
+	resp, err := client.GetKeyspace(ctx, &proto.GetKeyspaceRequest{})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Links:      ", resp.GetLinks())
+	if resp.GetCodeLength() == 0 {
+		fmt.Fprintln(out, "Code length: unbounded")
+		return nil
+	}
+	fmt.Fprintln(out, "Code length:", resp.GetCodeLength())
+	fmt.Fprintf(out, "Keyspace:    %.4g\n", resp.GetKeyspace())
+	fmt.Fprintf(out, "Occupancy:   %.6f%%\n", resp.GetOccupancy()*100)
+	return nil
+}
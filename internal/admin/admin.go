@@ -0,0 +1,119 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/logging"
+	"url-shortener/internal/service"
+	"url-shortener/internal/storage"
+	"url-shortener/proto"
+)
+
+// Server реализует служебный gRPC-сервис Admin. Сервис не проверяет права вызывающего:
+// вызовы пропускает TokenInterceptor на порту администрирования
+type Server struct {
+	proto.UnimplementedAdminServer
+	keys *auth.Authenticator
+	urls *service.Service
+}
+
+// NewServer создаёт служебный сервис, управляющий ключами API через keys и сообщающий состояние ссылок urls
+func NewServer(keys *auth.Authenticator, urls *service.Service) *Server {
+	return &Server{keys: keys, urls: urls}
+}
+
+// TokenInterceptor пропускает только вызовы с токеном администратора в метаданных authorization
+// в виде "Bearer <токен>". При пустом token отклоняются все вызовы
+func TokenInterceptor(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		values := metadata.ValueFromIncomingContext(ctx, "authorization")
+		if len(values) == 0 {
+			return nil, status.Error(codes.Unauthenticated, "admin token required")
+		}
+		got, ok := auth.BearerToken(values[0])
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "invalid admin token")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// IssueAPIKey реализует gRPC-метод для выпуска ключа API
+func (s *Server) IssueAPIKey(ctx context.Context, req *proto.IssueAPIKeyRequest) (*proto.IssueAPIKeyResponse, error) {
+	key, secret, err := s.keys.Issue(ctx, req.GetOwnerId(), req.GetName())
+	if err != nil {
+		return nil, toStatusError(ctx, err)
+	}
+	return &proto.IssueAPIKeyResponse{Key: keyToProto(key), Secret: secret}, nil
+}
+
+// ListAPIKeys реализует gRPC-метод для получения списка ключей API
+func (s *Server) ListAPIKeys(ctx context.Context, _ *proto.ListAPIKeysRequest) (*proto.ListAPIKeysResponse, error) {
+	keys, err := s.keys.List(ctx)
+	if err != nil {
+		return nil, toStatusError(ctx, err)
+	}
+	resp := &proto.ListAPIKeysResponse{Keys: make([]*proto.APIKey, 0, len(keys))}
+	for _, key := range keys {
+		resp.Keys = append(resp.Keys, keyToProto(key))
+	}
+	return resp, nil
+}
+
+// RevokeAPIKey реализует gRPC-метод для отзыва ключа API
+func (s *Server) RevokeAPIKey(ctx context.Context, req *proto.RevokeAPIKeyRequest) (*proto.RevokeAPIKeyResponse, error) {
+	if err := s.keys.Revoke(ctx, req.GetId()); err != nil {
+		return nil, toStatusError(ctx, err)
+	}
+	return &proto.RevokeAPIKeyResponse{}, nil
+}
+
+// GetKeyspace реализует gRPC-метод для получения заполненности пространства кодов
+func (s *Server) GetKeyspace(ctx context.Context, _ *proto.GetKeyspaceRequest) (*proto.GetKeyspaceResponse, error) {
+	stats, err := s.urls.Keyspace(ctx)
+	if err != nil {
+		return nil, toStatusError(ctx, err)
+	}
+	return &proto.GetKeyspaceResponse{
+		CodeLength: int32(stats.CodeLength),
+		Keyspace:   stats.Keyspace,
+		Links:      stats.Links,
+		Occupancy:  stats.Occupancy,
+	}, nil
+}
+
+// keyToProto описывает ключ API без его хеша
+func keyToProto(key storage.APIKey) *proto.APIKey {
+	resp := &proto.APIKey{
+		Id:        key.ID,
+		OwnerId:   key.OwnerID,
+		Name:      key.Name,
+		CreatedAt: timestamppb.New(key.CreatedAt),
+	}
+	if key.Revoked() {
+		resp.RevokedAt = timestamppb.New(key.RevokedAt)
+	}
+	return resp
+}
+
+// toStatusError преобразует ошибку управления ключами в gRPC-статус.
+// Непредвиденные ошибки пишутся в лог запроса, клиент получает только общий текст
+func toStatusError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, auth.ErrOwnerRequired):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, storage.ErrKeyNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	}
+	logging.FromContext(ctx).Error("Admin request failed", "error", err)
+	return status.Error(codes.Internal, "internal error")
+}
@@ -0,0 +1,89 @@
+package admin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"url-shortener/internal/auth"
+	"url-shortener/internal/service"
+	"url-shortener/internal/storage/memory"
+	"url-shortener/proto"
+)
+
+func TestServer_APIKeys(t *testing.T) {
+	s := NewServer(auth.New(memory.NewMemory()), nil)
+
+	issued, err := s.IssueAPIKey(context.Background(), &proto.IssueAPIKeyRequest{OwnerId: "alice", Name: "ci"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, issued.Secret)
+	assert.Equal(t, "alice", issued.Key.OwnerId)
+
+	_, err = s.IssueAPIKey(context.Background(), &proto.IssueAPIKeyRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = s.RevokeAPIKey(context.Background(), &proto.RevokeAPIKeyRequest{Id: issued.Key.Id})
+	require.NoError(t, err)
+	_, err = s.RevokeAPIKey(context.Background(), &proto.RevokeAPIKeyRequest{Id: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	list, err := s.ListAPIKeys(context.Background(), &proto.ListAPIKeysRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.Keys, 1)
+	assert.Equal(t, issued.Key.Id, list.Keys[0].Id)
+	assert.Equal(t, "ci", list.Keys[0].Name)
+	assert.NotNil(t, list.Keys[0].RevokedAt)
+}
+
+func TestServer_GetKeyspace(t *testing.T) {
+	store := memory.NewMemory()
+	codes, err := service.NewRandomGenerator(2, "ab")
+	require.NoError(t, err)
+	urls := service.NewService(store, service.WithCodeGenerator(codes))
+	s := NewServer(auth.New(store), urls)
+
+	_, err = urls.CreateURL(context.Background(), &proto.CreateURLRequest{OriginalUrl: "https://example.com"})
+	require.NoError(t, err)
+
+	resp, err := s.GetKeyspace(context.Background(), &proto.GetKeyspaceRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), resp.CodeLength)
+	assert.Equal(t, 4.0, resp.Keyspace)
+	assert.Equal(t, int64(1), resp.Links)
+	assert.Equal(t, 0.25, resp.Occupancy)
+}
+
+func TestTokenInterceptor(t *testing.T) {
+	ok := func(context.Context, any) (any, error) { return "ok", nil }
+
+	tests := []struct {
+		name         string
+		token        string
+		md           metadata.MD
+		expectedCode codes.Code
+	}{
+		{name: "Верный токен", token: "secret", md: metadata.Pairs("authorization", "Bearer secret")},
+		{name: "Без токена", token: "secret", md: metadata.MD{}, expectedCode: codes.Unauthenticated},
+		{name: "Неверный токен", token: "secret", md: metadata.Pairs("authorization", "Bearer other"), expectedCode: codes.Unauthenticated},
+		{name: "Другая схема", token: "secret", md: metadata.Pairs("authorization", "Basic secret"), expectedCode: codes.Unauthenticated},
+		{name: "Токен не задан", token: "", md: metadata.Pairs("authorization", "Bearer "), expectedCode: codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			info := &grpc.UnaryServerInfo{FullMethod: "/proto.Admin/IssueAPIKey"}
+			resp, err := TokenInterceptor(tt.token)(ctx, nil, info, ok)
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+			if tt.expectedCode == codes.OK {
+				assert.Equal(t, "ok", resp)
+			}
+		})
+	}
+}
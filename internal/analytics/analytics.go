@@ -0,0 +1,159 @@
+package analytics
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"sync"
+	"time"
+
+	"url-shortener/internal/storage"
+)
+
+// defaultFlushTimeout ограничивает запись одной пачки переходов, если Config.FlushTimeout не задан
+const defaultFlushTimeout = 5 * time.Second
+
+// Config содержит параметры асинхронной записи переходов
+type Config struct {
+	BufferSize    int           // размер очереди переходов, при переполнении новые переходы отбрасываются
+	BatchSize     int           // максимальный размер пачки, сохраняемой за один запрос
+	FlushInterval time.Duration // максимальное время ожидания перед сохранением неполной пачки
+	FlushTimeout  time.Duration // ограничение записи одной пачки, 0 — defaultFlushTimeout
+	IPSalt        string        // соль для хеширования IP-адресов клиентов
+}
+
+// Client описывает клиента, перешедшего по короткой ссылке
+type Client struct {
+	IP        string
+	Referrer  string
+	UserAgent string
+}
+
+type clientKey struct{}
+
+// ContextWithClient возвращает контекст с информацией о клиенте
+func ContextWithClient(ctx context.Context, client Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// ClientFromContext извлекает информацию о клиенте из контекста
+func ClientFromContext(ctx context.Context) (Client, bool) {
+	client, ok := ctx.Value(clientKey{}).(Client)
+	return client, ok
+}
+
+// Analytics асинхронно записывает переходы по коротким ссылкам пачками и отдаёт статистику
+type Analytics struct {
+	store  storage.ClickStorage
+	config Config
+
+	clicks chan storage.Click
+	done   chan struct{}
+	mu     sync.RWMutex
+	closed bool
+}
+
+// New создаёт Analytics и запускает фоновую запись переходов в хранилище
+func New(store storage.ClickStorage, config Config) *Analytics {
+	a := &Analytics{
+		store:  store,
+		config: config,
+		clicks: make(chan storage.Click, config.BufferSize),
+		done:   make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+// Record ставит переход в очередь на запись, не блокируя вызывающего
+func (a *Analytics) Record(shortURL string, client Client) {
+	click := storage.Click{
+		ShortURL:  shortURL,
+		ClickedAt: time.Now().UTC(),
+		Referrer:  client.Referrer,
+		UserAgent: client.UserAgent,
+		IPHash:    a.hashIP(client.IP),
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		return
+	}
+	select {
+	case a.clicks <- click:
+	default:
+		slog.Warn("Click queue is full, dropping click", "short_code", shortURL)
+	}
+}
+
+// Stats возвращает статистику переходов по короткой ссылке
+func (a *Analytics) Stats(ctx context.Context, query storage.ClickStatsQuery) (storage.ClickStats, error) {
+	return a.store.ClickStats(ctx, query)
+}
+
+// Close прекращает приём переходов и дожидается сохранения очереди
+func (a *Analytics) Close() {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return
+	}
+	a.closed = true
+	close(a.clicks)
+	a.mu.Unlock()
+
+	<-a.done
+}
+
+// run собирает переходы в пачки и сохраняет их по заполнению или по таймеру
+func (a *Analytics) run() {
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]storage.Click, 0, a.config.BatchSize)
+	for {
+		select {
+		case click, ok := <-a.clicks:
+			if !ok {
+				a.flush(batch)
+				return
+			}
+			batch = append(batch, click)
+			if len(batch) >= a.config.BatchSize {
+				a.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			a.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// flush сохраняет пачку переходов в хранилище
+func (a *Analytics) flush(batch []storage.Click) {
+	if len(batch) == 0 {
+		return
+	}
+	// Запись идёт в фоне и не связана с контекстом какого-либо запроса. Таймаут не даёт зависшему
+	// хранилищу остановить запись: иначе очередь переполнится, а Close не дождётся её сохранения
+	timeout := a.config.FlushTimeout
+	if timeout <= 0 {
+		timeout = defaultFlushTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := a.store.SaveClicks(ctx, batch); err != nil {
+		slog.Error("Failed to save clicks", "error", err, "count", len(batch))
+	}
+}
+
+// hashIP хеширует IP-адрес клиента с солью
+func (a *Analytics) hashIP(ip string) string {
+	sum := sha256.Sum256([]byte(a.config.IPSalt + ip))
+	return hex.EncodeToString(sum[:])
+}
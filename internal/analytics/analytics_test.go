@@ -0,0 +1,131 @@
+package analytics
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"url-shortener/internal/storage"
+)
+
+// FakeClickStorage — поддельное хранилище переходов, запоминающее сохранённые пачки
+type FakeClickStorage struct {
+	mu      sync.Mutex
+	batches [][]storage.Click
+}
+
+func (f *FakeClickStorage) SaveClicks(_ context.Context, clicks []storage.Click) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, append([]storage.Click(nil), clicks...))
+	return nil
+}
+
+func (f *FakeClickStorage) ClickStats(context.Context, storage.ClickStatsQuery) (storage.ClickStats, error) {
+	return storage.ClickStats{}, nil
+}
+
+func (f *FakeClickStorage) batchSizes() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sizes := make([]int, 0, len(f.batches))
+	for _, batch := range f.batches {
+		sizes = append(sizes, len(batch))
+	}
+	return sizes
+}
+
+func TestAnalytics_Batching(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        Config
+		clicks        int
+		wait          time.Duration
+		expectedSizes []int // размеры пачек до вызова Close
+	}{
+		{
+			name:          "Сохранение полными пачками",
+			config:        Config{BufferSize: 10, BatchSize: 2, FlushInterval: time.Hour},
+			clicks:        4,
+			wait:          50 * time.Millisecond,
+			expectedSizes: []int{2, 2},
+		},
+		{
+			name:          "Сохранение неполной пачки по таймеру",
+			config:        Config{BufferSize: 10, BatchSize: 100, FlushInterval: 10 * time.Millisecond},
+			clicks:        3,
+			wait:          100 * time.Millisecond,
+			expectedSizes: []int{3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &FakeClickStorage{}
+			a := New(store, tt.config)
+			for i := 0; i < tt.clicks; i++ {
+				a.Record("abc123", Client{IP: "127.0.0.1"})
+			}
+			time.Sleep(tt.wait)
+			assert.Equal(t, tt.expectedSizes, store.batchSizes())
+			a.Close()
+		})
+	}
+}
+
+func TestAnalytics_CloseFlushesQueue(t *testing.T) {
+	store := &FakeClickStorage{}
+	a := New(store, Config{BufferSize: 10, BatchSize: 100, FlushInterval: time.Hour, IPSalt: "salt"})
+	a.Record("abc123", Client{IP: "10.0.0.1", Referrer: "https://ref.example", UserAgent: "curl/8.0"})
+	a.Close()
+
+	// Переходы после закрытия игнорируются
+	a.Record("abc123", Client{IP: "10.0.0.1"})
+
+	assert.Equal(t, []int{1}, store.batchSizes())
+	click := store.batches[0][0]
+	assert.Equal(t, "abc123", click.ShortURL)
+	assert.Equal(t, "https://ref.example", click.Referrer)
+	assert.Equal(t, "curl/8.0", click.UserAgent)
+	assert.Len(t, click.IPHash, 64)
+	assert.NotContains(t, click.IPHash, "10.0.0.1")
+}
+
+// stuckClickStorage ждёт отмены контекста записи, как зависшее хранилище
+type stuckClickStorage struct {
+	FakeClickStorage
+}
+
+func (s *stuckClickStorage) SaveClicks(ctx context.Context, _ []storage.Click) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestAnalytics_FlushTimeout(t *testing.T) {
+	a := New(&stuckClickStorage{}, Config{BufferSize: 10, BatchSize: 100, FlushInterval: time.Hour, FlushTimeout: 10 * time.Millisecond})
+	a.Record("abc123", Client{IP: "127.0.0.1"})
+
+	// Close не зависает вместе с хранилищем
+	closed := make(chan struct{})
+	go func() {
+		a.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close is blocked by a stuck storage")
+	}
+}
+
+func TestClientFromContext(t *testing.T) {
+	_, ok := ClientFromContext(context.Background())
+	assert.False(t, ok)
+
+	ctx := ContextWithClient(context.Background(), Client{IP: "10.0.0.1"})
+	client, ok := ClientFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "10.0.0.1", client.IP)
+}
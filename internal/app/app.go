@@ -0,0 +1,349 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc/filters"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	"url-shortener/internal/admin"
+	"url-shortener/internal/analytics"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/config"
+	"url-shortener/internal/handler"
+	"url-shortener/internal/health"
+	"url-shortener/internal/logging"
+	"url-shortener/internal/metrics"
+	"url-shortener/internal/ratelimit"
+	"url-shortener/internal/service"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/tracing"
+	"url-shortener/internal/urlnorm"
+	"url-shortener/proto"
+)
+
+// App собирает хранилище, сервис и серверы и управляет их запуском и остановкой
+type App struct {
+	cfg     *config.Config
+	storage storage.Storage
+	clicks  *analytics.Analytics
+	health  *health.Checker
+	metrics *metrics.Metrics
+	// sharedLimits — корзины ограничения частоты в хранилище, nil если хранилище их не поддерживает
+	sharedLimits storage.RateLimitStorage
+	// keys — ключи API в хранилище ссылок
+	keys storage.KeyStorage
+	svc  *service.Service
+
+	httpServer   *http.Server
+	httpListener net.Listener
+	grpcServer   *grpc.Server
+	grpcListener net.Listener
+	// adminServer отдаёт /metrics и служебный gRPC-сервис Admin, nil если ADMIN_PORT не задан
+	adminServer   *http.Server
+	adminListener net.Listener
+
+	// closers закрывают хранилище и экспорт трассировок после остановки серверов, в обратном порядке
+	closers []func() error
+}
+
+// New открывает хранилище и порты серверов. Запросы начинают обрабатываться после вызова Run
+func New(cfg *config.Config) (_ *App, err error) {
+	if !service.IsRedirectStatus(cfg.RedirectStatus) {
+		return nil, fmt.Errorf("unsupported redirect status %d", cfg.RedirectStatus)
+	}
+
+	a := &App{cfg: cfg, health: health.New(), metrics: metrics.New()}
+	// при ошибке закрываем уже открытые ресурсы
+	defer func() {
+		if err != nil {
+			a.close()
+		}
+	}()
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:       cfg.TracingExporter,
+		FilePath:       cfg.TracingFile,
+		SampleRatio:    cfg.TracingSampleRatio,
+		StorageBackend: cfg.StorageType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("setup tracing: %w", err)
+	}
+	// трассировки отправляются последними, после закрытия хранилища
+	a.closers = append(a.closers, func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		return shutdownTracing(ctx)
+	})
+
+	var clickStorage storage.ClickStorage
+	a.storage, clickStorage, err = a.openStorage(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Асинхронная запись переходов по ссылкам
+	a.clicks = analytics.New(clickStorage, analytics.Config{
+		BufferSize:    cfg.AnalyticsBufferSize,
+		BatchSize:     cfg.AnalyticsBatchSize,
+		FlushInterval: cfg.AnalyticsFlushInterval,
+		FlushTimeout:  cfg.RequestTimeout,
+		IPSalt:        cfg.AnalyticsIPSalt,
+	})
+
+	codes, err := service.NewCodeGenerator(service.CodeGeneratorConfig{
+		Strategy: cfg.CodeGenerator,
+		Length:   cfg.CodeLength,
+		Alphabet: cfg.CodeAlphabet,
+		NodeID:   cfg.CodeNodeID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create code generator: %w", err)
+	}
+
+	// Сервис реализует как HTTP, так и gRPC интерфейсы
+	opts := []service.Option{
+		service.WithCodeGenerator(codes),
+		service.WithAnalytics(a.clicks),
+		service.WithTimeout(cfg.RequestTimeout),
+		service.WithMetrics(a.metrics),
+		service.WithNormalizer(urlnorm.New(urlnorm.Config{
+			AllowedSchemes: cfg.URLAllowedSchemes,
+			TrackingParams: cfg.URLTrackingParams,
+		})),
+	}
+	if cfg.APIKeyRequired {
+		opts = append(opts, service.WithOwnerRequired())
+	}
+	svc := service.NewService(a.storage, opts...)
+	if err := svc.RestoreCodeLength(context.Background()); err != nil {
+		return nil, fmt.Errorf("restore code length: %w", err)
+	}
+	a.svc = svc
+	authenticator := auth.New(a.keys)
+
+	// Бюджеты клиентов хранятся в памяти процесса или, для нескольких экземпляров, в общем хранилище
+	var limits storage.RateLimitStorage = ratelimit.NewMemory()
+	if cfg.RateLimitShared {
+		if a.sharedLimits == nil {
+			return nil, fmt.Errorf("storage %q cannot share rate limits", cfg.StorageType)
+		}
+		limits = a.sharedLimits
+	}
+	limiter := ratelimit.New(limits, ratelimit.Config{
+		Create:  storage.TokenBucket{Rate: cfg.RateLimitCreateRate, Burst: cfg.RateLimitCreateBurst},
+		Resolve: storage.TokenBucket{Rate: cfg.RateLimitResolveRate, Burst: cfg.RateLimitResolveBurst},
+	})
+
+	// Лог и метрики учитывают gRPC-статус до его переноса в поле error ответа.
+	// Ограничение частоты идёт после аутентификации, чтобы бюджет считался по ключу API
+	var interceptors []grpc.UnaryServerInterceptor
+	if cfg.GRPCLegacyErrors {
+		interceptors = append(interceptors, service.LegacyErrorsInterceptor())
+	}
+	interceptors = append(interceptors,
+		logging.UnaryServerInterceptor(),
+		a.metrics.UnaryServerInterceptor(),
+		authenticator.UnaryServerInterceptor(),
+		limiter.UnaryServerInterceptor(),
+		tracing.UnaryServerInterceptor(),
+	)
+	a.grpcServer = grpc.NewServer(
+		// span на каждый вызов, кроме проверок grpc.health.v1
+		grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithFilter(filters.Not(filters.HealthCheck())))),
+		grpc.ChainUnaryInterceptor(interceptors...),
+		grpc.ChainStreamInterceptor(logging.StreamServerInterceptor(), a.metrics.StreamServerInterceptor(), authenticator.StreamServerInterceptor(), limiter.StreamServerInterceptor()),
+	)
+	proto.RegisterURLShortenerServer(a.grpcServer, svc)
+	healthpb.RegisterHealthServer(a.grpcServer, a.health.GRPCServer())
+	reflection.Register(a.grpcServer)
+	a.grpcListener, err = net.Listen("tcp", ":"+cfg.GRPCPort)
+	if err != nil {
+		return nil, fmt.Errorf("listen gRPC: %w", err)
+	}
+
+	// Пробы регистрируются раньше маршрутов коротких ссылок, чтобы /healthz не считался коротким URL
+	r := mux.NewRouter()
+	r.HandleFunc("/healthz", a.health.Liveness).Methods("GET")
+	r.HandleFunc("/readyz", a.health.Readiness).Methods("GET")
+	r.PathPrefix("/").Handler(a.metrics.InstrumentHTTP(handler.NewHandler(svc, cfg.RedirectStatus, cfg.BaseURL,
+		handler.WithRateLimiter(limiter),
+		handler.WithAuthenticator(authenticator),
+	).SetupRoutes()))
+	a.httpServer = &http.Server{Handler: r}
+	a.httpListener, err = net.Listen("tcp", ":"+cfg.ServerPort)
+	if err != nil {
+		a.grpcListener.Close() //nolint:errcheck
+		return nil, fmt.Errorf("listen HTTP: %w", err)
+	}
+
+	// Метрики и управление ключами API на отдельном порту, недоступном снаружи.
+	// Служебный gRPC-сервис работает поверх HTTP/2 без TLS на том же порту и принимает только вызовы с AdminToken
+	if cfg.AdminPort != "" {
+		adminGRPC := grpc.NewServer(grpc.ChainUnaryInterceptor(
+			logging.UnaryServerInterceptor(),
+			admin.TokenInterceptor(cfg.AdminToken),
+		))
+		proto.RegisterAdminServer(adminGRPC, admin.NewServer(authenticator, svc))
+		adminMux := http.NewServeMux()
+		adminMux.Handle("/metrics", a.metrics.Handler())
+		var protocols http.Protocols
+		protocols.SetHTTP1(true)
+		protocols.SetUnencryptedHTTP2(true)
+		a.adminServer = &http.Server{Handler: adminHandler(adminGRPC, adminMux), Protocols: &protocols}
+		a.adminListener, err = net.Listen("tcp", ":"+cfg.AdminPort)
+		if err != nil {
+			a.grpcListener.Close() //nolint:errcheck
+			a.httpListener.Close() //nolint:errcheck
+			return nil, fmt.Errorf("listen admin: %w", err)
+		}
+	}
+	return a, nil
+}
+
+// HTTPAddr возвращает адрес HTTP-сервера
+func (a *App) HTTPAddr() net.Addr {
+	return a.httpListener.Addr()
+}
+
+// GRPCAddr возвращает адрес gRPC-сервера
+func (a *App) GRPCAddr() net.Addr {
+	return a.grpcListener.Addr()
+}
+
+// AdminAddr возвращает адрес сервера администрирования или nil, если он отключён
+func (a *App) AdminAddr() net.Addr {
+	if a.adminListener == nil {
+		return nil
+	}
+	return a.adminListener.Addr()
+}
+
+// Run обслуживает запросы до отмены ctx или ошибки одного из серверов, затем останавливает приложение:
+// дожидается текущих запросов не дольше ShutdownTimeout, сохраняет очередь переходов и закрывает хранилище
+func (a *App) Run(ctx context.Context) error {
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	workers.Add(3)
+	go func() {
+		defer workers.Done()
+		// Фоновое удаление истёкших ссылок
+		storage.RunReaper(workersCtx, a.storage, a.cfg.ReaperInterval)
+	}()
+	go func() {
+		defer workers.Done()
+		// Статус gRPC health по проверкам готовности
+		a.health.Run(workersCtx, a.cfg.HealthCheckInterval)
+	}()
+	go func() {
+		defer workers.Done()
+		// Метрики заполненности пространства кодов
+		a.svc.RunKeyspaceReporter(workersCtx, a.cfg.KeyspaceReportInterval)
+	}()
+
+	serveErr := make(chan error, 3)
+	go func() {
+		slog.Info("Starting gRPC server", "addr", a.GRPCAddr().String())
+		if err := a.grpcServer.Serve(a.grpcListener); err != nil {
+			serveErr <- fmt.Errorf("serve gRPC: %w", err)
+		}
+	}()
+	go func() {
+		slog.Info("Starting HTTP server", "addr", a.HTTPAddr().String())
+		if err := a.httpServer.Serve(a.httpListener); !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- fmt.Errorf("serve HTTP: %w", err)
+		}
+	}()
+	if a.adminServer != nil {
+		go func() {
+			slog.Info("Starting admin server", "addr", a.AdminAddr().String())
+			if err := a.adminServer.Serve(a.adminListener); !errors.Is(err, http.ErrServerClosed) {
+				serveErr <- fmt.Errorf("serve admin: %w", err)
+			}
+		}()
+	}
+
+	var err error
+	select {
+	case <-ctx.Done():
+		slog.Info("Shutting down")
+	case err = <-serveErr:
+		slog.Error("Shutting down after server failure", "error", err)
+	}
+
+	// Пробы готовности перестают проходить до начала остановки серверов
+	a.health.Shutdown()
+	shutdownErr := a.shutdown()
+	stopWorkers()
+	workers.Wait()
+	a.close()
+	return errors.Join(err, shutdownErr)
+}
+
+// shutdown останавливает приём запросов и ждёт завершения текущих не дольше ShutdownTimeout.
+// Запросы, не успевшие завершиться, прерываются
+func (a *App) shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.ShutdownTimeout)
+	defer cancel()
+
+	grpcStopped := make(chan struct{})
+	go func() {
+		defer close(grpcStopped)
+		a.grpcServer.GracefulStop()
+	}()
+
+	err := a.httpServer.Shutdown(ctx)
+	if err != nil {
+		a.httpServer.Close() //nolint:errcheck
+		err = fmt.Errorf("drain HTTP: %w", err)
+	}
+	select {
+	case <-grpcStopped:
+	case <-ctx.Done():
+		a.grpcServer.Stop()
+		<-grpcStopped
+	}
+	// Сервер метрик останавливается последним, чтобы метрики были доступны во время остановки
+	if a.adminServer != nil {
+		if adminErr := a.adminServer.Shutdown(ctx); adminErr != nil {
+			a.adminServer.Close() //nolint:errcheck
+		}
+	}
+	return err
+}
+
+// adminHandler передаёт вызовы gRPC служебному сервису, остальные запросы — next
+func adminHandler(grpcServer *grpc.Server, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// close сохраняет очередь переходов и закрывает хранилище
+func (a *App) close() {
+	if a.clicks != nil {
+		a.clicks.Close()
+	}
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		if err := a.closers[i](); err != nil {
+			slog.Error("Failed to close", "error", err)
+		}
+	}
+	a.closers = nil
+}
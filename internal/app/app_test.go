@@ -0,0 +1,174 @@
+package app
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"url-shortener/internal/config"
+	"url-shortener/internal/logging"
+	"url-shortener/internal/storage/memory"
+	"url-shortener/proto"
+)
+
+func testConfig(t *testing.T) *config.Config {
+	return &config.Config{
+		StorageType:            "memory",
+		ServerPort:             "0",
+		GRPCPort:               "0",
+		AdminPort:              "0",
+		AdminToken:             "admin-secret",
+		RequestTimeout:         time.Second,
+		ShutdownTimeout:        time.Second,
+		HealthCheckInterval:    time.Second,
+		ReaperInterval:         time.Minute,
+		KeyspaceReportInterval: time.Minute,
+		RedirectStatus:         http.StatusFound,
+		AnalyticsBufferSize:    10,
+		AnalyticsBatchSize:     10,
+		AnalyticsFlushInterval: time.Second,
+		URLAllowedSchemes:      []string{"http", "https"},
+		MemoryPersistDir:       t.TempDir(),
+		MemoryFsync:            string(memory.FsyncNever),
+	}
+}
+
+func TestApp_RunAndStop(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.TracingExporter = "file"
+	cfg.TracingFile = filepath.Join(t.TempDir(), "traces.json")
+	cfg.TracingSampleRatio = 1
+	a, err := New(cfg)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- a.Run(ctx) }()
+
+	conn, err := grpc.NewClient(a.GRPCAddr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close() //nolint:errcheck
+	var header metadata.MD
+	resp, err := proto.NewURLShortenerClient(conn).CreateURL(context.Background(), &proto.CreateURLRequest{
+		OriginalUrl: "https://example.com",
+		CustomAlias: "spring-sale",
+	}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, "spring-sale", resp.ShortUrl)
+	assert.NotEmpty(t, header.Get(logging.RequestIDHeader))
+
+	health, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, health.Status)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	for _, path := range []string{"/healthz", "/readyz"} {
+		httpResp, err := client.Get("http://" + a.HTTPAddr().String() + path)
+		require.NoError(t, err)
+		httpResp.Body.Close() //nolint:errcheck
+		assert.Equal(t, http.StatusOK, httpResp.StatusCode, path)
+	}
+	// Запрос продолжает трассировку клиента из заголовка traceparent
+	req, err := http.NewRequest(http.MethodGet, "http://"+a.HTTPAddr().String()+"/spring-sale", nil)
+	require.NoError(t, err)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	httpResp, err := client.Do(req)
+	require.NoError(t, err)
+	httpResp.Body.Close() //nolint:errcheck
+	assert.Equal(t, http.StatusFound, httpResp.StatusCode)
+	assert.Equal(t, "https://example.com", httpResp.Header.Get("Location"))
+
+	httpResp, err = client.Get("http://" + a.AdminAddr().String() + "/metrics")
+	require.NoError(t, err)
+	metricsBody, err := io.ReadAll(httpResp.Body)
+	httpResp.Body.Close() //nolint:errcheck
+	require.NoError(t, err)
+	assert.Contains(t, string(metricsBody), `url_shortener_http_requests_total{method="GET",route="/{shortURL}",status="302"} 1`)
+	assert.Contains(t, string(metricsBody), `url_shortener_grpc_requests_total{code="OK",method="/proto.URLShortener/CreateURL"} 1`)
+
+	// Ключ API выпускается служебным сервисом на порту администрирования
+	adminConn, err := grpc.NewClient(a.AdminAddr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer adminConn.Close() //nolint:errcheck
+	adminClient := proto.NewAdminClient(adminConn)
+	_, err = adminClient.IssueAPIKey(context.Background(), &proto.IssueAPIKeyRequest{OwnerId: "alice"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	adminCtx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+cfg.AdminToken)
+	issued, err := adminClient.IssueAPIKey(adminCtx, &proto.IssueAPIKeyRequest{OwnerId: "alice"})
+	require.NoError(t, err)
+	shortener := proto.NewURLShortenerClient(conn)
+	authCtx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+issued.Secret)
+	_, err = shortener.DeleteURL(context.Background(), &proto.DeleteURLRequest{ShortUrl: "spring-sale"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	owned, err := shortener.CreateURL(authCtx, &proto.CreateURLRequest{OriginalUrl: "https://example.org"})
+	require.NoError(t, err)
+	// Ссылка создана с ключом alice и не принадлежит владельцу другого ключа
+	other, err := adminClient.IssueAPIKey(adminCtx, &proto.IssueAPIKeyRequest{OwnerId: "bob"})
+	require.NoError(t, err)
+	otherCtx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+other.Secret)
+	_, err = shortener.DeleteURL(otherCtx, &proto.DeleteURLRequest{ShortUrl: owned.ShortUrl})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = shortener.DeleteURL(authCtx, &proto.DeleteURLRequest{ShortUrl: owned.ShortUrl})
+	assert.NoError(t, err)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("App did not stop")
+	}
+
+	// Порты освобождены, а журнал хранилища закрыт и читается при следующем запуске
+	_, err = client.Get("http://" + a.HTTPAddr().String() + "/spring-sale")
+	assert.Error(t, err)
+	mem, err := memory.Open(memory.PersistConfig{Dir: cfg.MemoryPersistDir, Fsync: memory.FsyncNever})
+	require.NoError(t, err)
+	defer mem.Close() //nolint:errcheck
+	url, err := mem.Get(context.Background(), "spring-sale")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com", url.OriginalURL)
+
+	// Span'ы отправлены в файл при остановке
+	traces, err := os.ReadFile(cfg.TracingFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(traces), `"Name":"proto.URLShortener/CreateURL"`)
+	assert.Contains(t, string(traces), `"Name":"GET /{shortURL}"`)
+	assert.Contains(t, string(traces), `"TraceID":"4bf92f3577b34da6a3ce929d0e0e4736"`)
+	assert.Contains(t, string(traces), `"Key":"url_shortener.short_code","Value":{"Type":"STRING","Value":"spring-sale"}`)
+	assert.NotContains(t, string(traces), "grpc.health.v1")
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *config.Config)
+	}{
+		{name: "Неизвестный тип хранилища", modify: func(cfg *config.Config) { cfg.StorageType = "unknown" }},
+		{name: "Неподдерживаемый статус редиректа", modify: func(cfg *config.Config) { cfg.RedirectStatus = http.StatusOK }},
+		{name: "Занятый порт", modify: func(cfg *config.Config) { cfg.ServerPort = "-1" }},
+		{name: "Неизвестный экспортёр трассировок", modify: func(cfg *config.Config) { cfg.TracingExporter = "jaeger" }},
+		{name: "Общие бюджеты в памяти процесса", modify: func(cfg *config.Config) { cfg.RateLimitShared = true }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			tt.modify(cfg)
+			_, err := New(cfg)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	_ "github.com/lib/pq"
+	"github.com/pressly/goose/v3"
+	goredis "github.com/redis/go-redis/v9"
+
+	"url-shortener/internal/config"
+	"url-shortener/internal/health"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/cache"
+	"url-shortener/internal/storage/file"
+	"url-shortener/internal/storage/memory"
+	"url-shortener/internal/storage/postgres"
+	"url-shortener/internal/storage/redis"
+)
+
+// openStorage открывает хранилище, выбранное в STORAGE_TYPE. Открытые ресурсы добавляются
+// в a.closers, чтобы закрыться после остановки серверов
+func (a *App) openStorage(cfg *config.Config) (storage.Storage, storage.ClickStorage, error) {
+	var appStorage storage.Storage
+	var clickStorage storage.ClickStorage
+	switch cfg.StorageType {
+	case "postgres":
+		slog.Info("Connecting to database", "host", cfg.DBHost, "port", cfg.DBPort, "name", cfg.DBName)
+		dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+			cfg.DBHost, cfg.DBPort, cfg.DBUser, cfg.DBPassword, cfg.DBName)
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			return nil, nil, fmt.Errorf("connect to database: %w", err)
+		}
+		a.closers = append(a.closers, db.Close)
+
+		if err := goose.Up(db, "migrations"); err != nil {
+			return nil, nil, fmt.Errorf("apply migrations: %w", err)
+		}
+		migrations, err := goose.CollectMigrations("migrations", 0, goose.MaxVersion)
+		if err != nil {
+			return nil, nil, fmt.Errorf("collect migrations: %w", err)
+		}
+		pg := postgres.NewPostgres(db)
+		a.health.Add("postgres", pg.Ping)
+		a.health.Add("migrations", migrationsCheck(db, migrations[len(migrations)-1].Version))
+		appStorage, clickStorage, a.keys = pg, pg, pg
+		a.sharedLimits = pg
+	case "file":
+		fs, err := file.NewFile(cfg.StoragePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open storage file: %w", err)
+		}
+		a.closers = append(a.closers, fs.Close)
+		a.health.Add("file", fs.Ping)
+		appStorage, clickStorage, a.keys = fs, fs, fs
+	case "redis":
+		client := goredis.NewClient(&goredis.Options{
+			Addr:     cfg.RedisAddr,
+			Password: cfg.RedisPassword,
+			DB:       cfg.RedisDB,
+		})
+		a.closers = append(a.closers, client.Close)
+		if err := client.Ping(context.Background()).Err(); err != nil {
+			return nil, nil, fmt.Errorf("connect to redis: %w", err)
+		}
+		rs := redis.NewRedis(client)
+		if err := rs.Migrate(context.Background()); err != nil {
+			return nil, nil, fmt.Errorf("migrate redis: %w", err)
+		}
+		a.health.Add("redis", rs.Ping)
+		appStorage, clickStorage, a.keys = rs, rs, rs
+		a.sharedLimits = rs
+	case "memory":
+		mem := memory.NewMemory()
+		if cfg.MemoryPersistDir != "" {
+			var err error
+			mem, err = memory.Open(memory.PersistConfig{
+				Dir:              cfg.MemoryPersistDir,
+				Fsync:            memory.FsyncPolicy(cfg.MemoryFsync),
+				FsyncInterval:    cfg.MemoryFsyncInterval,
+				SnapshotInterval: cfg.MemorySnapshotInterval,
+			})
+			if err != nil {
+				return nil, nil, fmt.Errorf("restore memory storage: %w", err)
+			}
+			a.closers = append(a.closers, mem.Close)
+		}
+		appStorage, clickStorage, a.keys = mem, mem, mem
+	default:
+		return nil, nil, fmt.Errorf("unknown storage type %q", cfg.StorageType)
+	}
+
+	appStorage = a.metrics.WrapStorage(appStorage, cfg.StorageType)
+
+	// Кэш ссылок перед хранилищем для редиректов
+	if cfg.CacheSize > 0 {
+		c := cache.New(appStorage, cache.Config{
+			Size:        cfg.CacheSize,
+			TTL:         cfg.CacheTTL,
+			NegativeTTL: cfg.CacheNegativeTTL,
+			LoadTimeout: cfg.RequestTimeout,
+		})
+		a.metrics.RegisterCache(c)
+		appStorage = c
+	}
+	return appStorage, clickStorage, nil
+}
+
+// migrationsCheck проверяет, что схема базы данных не старше последней миграции сервиса
+func migrationsCheck(db *sql.DB, expected int64) health.Check {
+	return func(ctx context.Context) error {
+		version, err := goose.GetDBVersionContext(ctx, db)
+		if err != nil {
+			return err
+		}
+		if version < expected {
+			return fmt.Errorf("database schema version %d, expected %d", version, expected)
+		}
+		return nil
+	}
+}
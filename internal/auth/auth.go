@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"url-shortener/internal/ratelimit"
+	"url-shortener/internal/storage"
+)
+
+const (
+	// keyPrefix отличает ключи API сервиса от других секретов в конфигурации и логах
+	keyPrefix = "us_"
+	// idBytes — длина случайного идентификатора ключа в байтах
+	idBytes = 8
+	// bearerScheme — схема заголовка Authorization и метаданных gRPC authorization
+	bearerScheme = "Bearer"
+)
+
+var (
+	// ErrInvalidKey возвращается для неизвестного или отозванного ключа API
+	ErrInvalidKey = errors.New("invalid API key")
+	// ErrOwnerRequired возвращается при выпуске ключа без владельца
+	ErrOwnerRequired = errors.New("owner ID required")
+)
+
+// HashKey возвращает хеш ключа API, под которым ключ хранится в хранилище.
+// Ключи случайные и длинные, поэтому соль и медленное хеширование не нужны
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// BearerToken извлекает ключ из значения заголовка Authorization вида "Bearer <ключ>"
+func BearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, bearerScheme) {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// Authenticator выпускает, проверяет и отзывает ключи API
+type Authenticator struct {
+	keys storage.KeyStorage
+	now  func() time.Time
+}
+
+// New создаёт аутентификатор, хранящий ключи в keys
+func New(keys storage.KeyStorage) *Authenticator {
+	return &Authenticator{keys: keys, now: time.Now}
+}
+
+// Issue выпускает ключ API владельца ownerID. Сам ключ возвращается только здесь, хранится лишь его хеш
+func (a *Authenticator) Issue(ctx context.Context, ownerID, name string) (storage.APIKey, string, error) {
+	if strings.TrimSpace(ownerID) == "" {
+		return storage.APIKey{}, "", ErrOwnerRequired
+	}
+	id := make([]byte, idBytes)
+	if _, err := rand.Read(id); err != nil {
+		return storage.APIKey{}, "", err
+	}
+	secret := keyPrefix + rand.Text()
+	key := storage.APIKey{
+		ID:        hex.EncodeToString(id),
+		Hash:      HashKey(secret),
+		OwnerID:   ownerID,
+		Name:      name,
+		CreatedAt: a.now().UTC(),
+	}
+	if err := a.keys.SaveKey(ctx, key); err != nil {
+		return storage.APIKey{}, "", err
+	}
+	return key, secret, nil
+}
+
+// Authenticate возвращает действующий ключ API по его значению или ErrInvalidKey
+func (a *Authenticator) Authenticate(ctx context.Context, secret string) (storage.APIKey, error) {
+	key, err := a.keys.KeyByHash(ctx, HashKey(secret))
+	if errors.Is(err, storage.ErrKeyNotFound) {
+		return storage.APIKey{}, ErrInvalidKey
+	}
+	if err != nil {
+		return storage.APIKey{}, err
+	}
+	if key.Revoked() {
+		return storage.APIKey{}, ErrInvalidKey
+	}
+	return key, nil
+}
+
+// List возвращает все ключи API в порядке выпуска
+func (a *Authenticator) List(ctx context.Context) ([]storage.APIKey, error) {
+	return a.keys.ListKeys(ctx)
+}
+
+// Revoke отзывает ключ API с идентификатором id
+func (a *Authenticator) Revoke(ctx context.Context, id string) error {
+	return a.keys.RevokeKey(ctx, id, a.now().UTC())
+}
+
+type ownerKey struct{}
+
+// ContextWithKey привязывает к контексту владельца ключа API. Ограничение частоты запросов
+// с ключом учитывается по ключу, а не по IP-адресу
+func ContextWithKey(ctx context.Context, key storage.APIKey) context.Context {
+	ctx = context.WithValue(ctx, ownerKey{}, key.OwnerID)
+	return ratelimit.ContextWithClient(ctx, key.ID)
+}
+
+// OwnerFromContext возвращает владельца ключа API запроса; false, если запрос без ключа
+func OwnerFromContext(ctx context.Context) (string, bool) {
+	owner, ok := ctx.Value(ownerKey{}).(string)
+	return owner, ok && owner != ""
+}
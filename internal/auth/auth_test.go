@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/ratelimit"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/memory"
+)
+
+func TestAuthenticator(t *testing.T) {
+	store := memory.NewMemory()
+	a := New(store)
+	now := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+
+	key, secret, err := a.Issue(context.Background(), "alice", "ci")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(secret, keyPrefix))
+	assert.Equal(t, "alice", key.OwnerID)
+	assert.Equal(t, now, key.CreatedAt)
+
+	// Хранится только хеш ключа
+	stored, err := store.KeyByHash(context.Background(), HashKey(secret))
+	require.NoError(t, err)
+	assert.Equal(t, key, stored)
+	assert.NotContains(t, stored.Hash, secret)
+
+	authenticated, err := a.Authenticate(context.Background(), secret)
+	assert.NoError(t, err)
+	assert.Equal(t, key.ID, authenticated.ID)
+	_, err = a.Authenticate(context.Background(), secret+"x")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	require.NoError(t, a.Revoke(context.Background(), key.ID))
+	_, err = a.Authenticate(context.Background(), secret)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	assert.ErrorIs(t, a.Revoke(context.Background(), "unknown"), storage.ErrKeyNotFound)
+
+	_, _, err = a.Issue(context.Background(), " ", "")
+	assert.ErrorIs(t, err, ErrOwnerRequired)
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		name          string
+		header        string
+		expectedToken string
+		expectedOK    bool
+	}{
+		{name: "Ключ в схеме Bearer", header: "Bearer us_abc", expectedToken: "us_abc", expectedOK: true},
+		{name: "Схема в нижнем регистре", header: "bearer us_abc", expectedToken: "us_abc", expectedOK: true},
+		{name: "Другая схема", header: "Basic dXNlcjpwYXNz", expectedOK: false},
+		{name: "Пустой ключ", header: "Bearer ", expectedOK: false},
+		{name: "Ключ без схемы", header: "us_abc", expectedOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, ok := BearerToken(tt.header)
+			assert.Equal(t, tt.expectedOK, ok)
+			assert.Equal(t, tt.expectedToken, token)
+		})
+	}
+}
+
+func TestContextWithKey(t *testing.T) {
+	_, ok := OwnerFromContext(context.Background())
+	assert.False(t, ok)
+
+	ctx := ContextWithKey(context.Background(), storage.APIKey{ID: "key1", OwnerID: "alice"})
+	owner, ok := OwnerFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "alice", owner)
+	// Бюджет ограничения частоты считается по ключу
+	assert.Equal(t, "client:key1", ratelimit.Client(ctx, "10.0.0.1"))
+}
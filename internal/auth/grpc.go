@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"url-shortener/internal/logging"
+)
+
+const (
+	// errorDomain — домен ошибок в errdetails.ErrorInfo, общий с ошибками сервиса
+	errorDomain = "url-shortener"
+	// metadataKey — ключ метаданных gRPC с ключом API в виде "Bearer <ключ>"
+	metadataKey = "authorization"
+)
+
+// UnaryServerInterceptor проверяет ключ API из метаданных authorization и привязывает его владельца
+// к контексту вызова. Вызовы без ключа проходят анонимно, с неверным ключом — отклоняются
+func (a *Authenticator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := a.authenticateIncoming(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor проверяет ключ API потоковых вызовов так же, как UnaryServerInterceptor
+func (a *Authenticator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authenticateIncoming(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticatedStream подменяет контекст потока контекстом с владельцем ключа
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// authenticateIncoming проверяет ключ из входящих метаданных и возвращает контекст с его владельцем
+func (a *Authenticator) authenticateIncoming(ctx context.Context) (context.Context, error) {
+	values := metadata.ValueFromIncomingContext(ctx, metadataKey)
+	if len(values) == 0 {
+		return ctx, nil
+	}
+	secret, ok := BearerToken(values[0])
+	if !ok {
+		return nil, statusError(ErrInvalidKey)
+	}
+	key, err := a.Authenticate(ctx, secret)
+	if err != nil {
+		if !errors.Is(err, ErrInvalidKey) {
+			logging.FromContext(ctx).Error("Failed to check API key", "error", err)
+		}
+		return nil, statusError(err)
+	}
+	return ContextWithKey(ctx, key), nil
+}
+
+// statusError преобразует ErrInvalidKey в статус Unauthenticated, остальные ошибки — в Internal
+// без текста исходной ошибки: она уже записана в лог
+func statusError(err error) error {
+	if !errors.Is(err, ErrInvalidKey) {
+		return status.Error(codes.Internal, "internal error")
+	}
+	st := status.New(codes.Unauthenticated, err.Error())
+	if withDetails, detailsErr := st.WithDetails(
+		&errdetails.ErrorInfo{Reason: "INVALID_API_KEY", Domain: errorDomain},
+	); detailsErr == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"url-shortener/internal/storage/memory"
+)
+
+func TestAuthenticator_UnaryServerInterceptor(t *testing.T) {
+	a := New(memory.NewMemory())
+	_, secret, err := a.Issue(context.Background(), "alice", "")
+	require.NoError(t, err)
+	interceptor := a.UnaryServerInterceptor()
+	owner := func(ctx context.Context, _ any) (any, error) {
+		owner, _ := OwnerFromContext(ctx)
+		return owner, nil
+	}
+
+	tests := []struct {
+		name          string
+		md            metadata.MD
+		expectedOwner any
+		expectedCode  codes.Code
+	}{
+		{name: "Вызов с ключом", md: metadata.Pairs(metadataKey, "Bearer "+secret), expectedOwner: "alice"},
+		{name: "Вызов без ключа", md: metadata.MD{}, expectedOwner: ""},
+		{name: "Неизвестный ключ", md: metadata.Pairs(metadataKey, "Bearer us_unknown"), expectedCode: codes.Unauthenticated},
+		{name: "Другая схема", md: metadata.Pairs(metadataKey, "Basic "+secret), expectedCode: codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			resp, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/proto.URLShortener/DeleteURL"}, owner)
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+			if tt.expectedCode == codes.OK {
+				assert.Equal(t, tt.expectedOwner, resp)
+			}
+		})
+	}
+}
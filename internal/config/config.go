@@ -1,21 +1,120 @@
 package config
 
 import (
+	"fmt"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+const (
+	defaultRequestTimeout         = 5 * time.Second
+	defaultShutdownTimeout        = 10 * time.Second
+	defaultHealthCheckInterval    = 5 * time.Second
+	defaultReaperInterval         = time.Minute
+	defaultRedirectStatus         = http.StatusFound
+	defaultAnalyticsBufferSize    = 10000
+	defaultAnalyticsBatchSize     = 500
+	defaultAnalyticsFlushInterval = time.Second
+	defaultStoragePath            = "data/urls.db"
+	defaultRedisAddr              = "localhost:6379"
+	defaultMemoryFsync            = "interval"
+	defaultMemoryFsyncInterval    = time.Second
+	defaultMemorySnapshotInterval = 5 * time.Minute
+	defaultCacheTTL               = time.Minute
+	defaultCacheNegativeTTL       = 10 * time.Second
+	defaultTracingExporter        = "none"
+	defaultLogLevel               = "info"
+	defaultLogFormat              = "json"
+	defaultTracingFile            = "traces.json"
+	defaultTracingSampleRatio     = 1.0
+	defaultRateLimitCreateRate    = 1.0
+	defaultRateLimitCreateBurst   = 20
+	defaultRateLimitResolveRate   = 20.0
+	defaultRateLimitResolveBurst  = 100
+	defaultCodeGenerator          = "random"
+	defaultCodeLength             = 10
+	defaultKeyspaceReportInterval = time.Minute
+)
+
 // Config содержит конфигурационные параметры приложения
 type Config struct {
-	StorageType string
-	DBHost      string
-	DBPort      string
-	DBUser      string
-	DBPassword  string
-	DBName      string
-	ServerPort  string
-	GRPCPort    string
+	StorageType    string
+	StoragePath    string // путь к файлу хранилища при STORAGE_TYPE=file
+	RedisAddr      string
+	RedisPassword  string
+	RedisDB        int
+	DBHost         string
+	DBPort         string
+	DBUser         string
+	DBPassword     string
+	DBName         string
+	ServerPort     string
+	GRPCPort       string
+	AdminPort      string // порт /metrics и сервиса Admin, пустое значение отключает сервер администрирования
+	AdminToken     string // токен вызовов сервиса Admin, обязателен при заданном AdminPort
+	BaseURL        string // адрес, с которого начинаются полные короткие ссылки, например https://sho.rt
+	RequestTimeout time.Duration
+	// ShutdownTimeout ограничивает ожидание текущих запросов при остановке
+	ShutdownTimeout time.Duration
+	ReaperInterval  time.Duration
+	// HealthCheckInterval — период проверок готовности для gRPC health
+	HealthCheckInterval time.Duration
+	RedirectStatus      int
+
+	// GRPCLegacyErrors включает передачу ошибок в поле error ответа вместо gRPC-статуса
+	GRPCLegacyErrors bool
+
+	AnalyticsBufferSize    int
+	AnalyticsBatchSize     int
+	AnalyticsFlushInterval time.Duration
+	AnalyticsIPSalt        string
+
+	URLAllowedSchemes []string // допустимые схемы оригинальных URL
+	URLTrackingParams []string // параметры запроса, удаляемые из оригинальных URL, например utm_*
+
+	// MemoryPersistDir — каталог журнала и снимков memory-хранилища, пустое значение отключает сохранение на диск
+	MemoryPersistDir       string
+	MemoryFsync            string // always, interval или never
+	MemoryFsyncInterval    time.Duration
+	MemorySnapshotInterval time.Duration
+
+	// CacheSize — число ссылок в кэше перед хранилищем, 0 отключает кэш
+	CacheSize        int
+	CacheTTL         time.Duration
+	CacheNegativeTTL time.Duration // время кэширования отсутствующих ссылок
+
+	// TracingExporter — куда отправлять трассировки: none, stdout, file или otlp
+	TracingExporter    string
+	TracingFile        string  // файл span'ов при TRACING_EXPORTER=file
+	TracingSampleRatio float64 // доля трассировок, начатых сервисом
+
+	LogLevel  string // debug, info, warn или error
+	LogFormat string // json или text
+
+	// RateLimitCreateRate — пополнение бюджета создания ссылок одного клиента в секунду, 0 отключает ограничение
+	RateLimitCreateRate  float64
+	RateLimitCreateBurst int
+	// RateLimitResolveRate — пополнение бюджета запросов по коротким ссылкам в секунду, 0 отключает ограничение
+	RateLimitResolveRate  float64
+	RateLimitResolveBurst int
+	// RateLimitShared хранит бюджеты в postgres или redis, общих для всех экземпляров сервиса
+	RateLimitShared bool
+
+	// APIKeyRequired запрещает создавать ссылки без ключа API
+	APIKeyRequired bool
+
+	// CodeGenerator — стратегия генерации кодов коротких ссылок: random, counter, snowflake или hash
+	CodeGenerator string
+	CodeLength    int    // длина кодов random и hash
+	CodeAlphabet  string // алфавит кодов random, пустое значение — латинские буквы, цифры и _
+	CodeNodeID    int64  // номер экземпляра сервиса для snowflake, у каждого экземпляра свой
+	// KeyspaceReportInterval — период обновления метрик заполненности пространства кодов
+	KeyspaceReportInterval time.Duration
 }
 
 // LoadConfig загружает конфигурацию из .env файла и переменных окружения
@@ -24,14 +123,255 @@ func LoadConfig() (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	grpcLegacyErrors, err := getBool("GRPC_LEGACY_ERRORS", false)
+	if err != nil {
+		return nil, err
+	}
+	requestTimeout, err := getDuration("REQUEST_TIMEOUT", defaultRequestTimeout)
+	if err != nil {
+		return nil, err
+	}
+	shutdownTimeout, err := getDuration("SHUTDOWN_TIMEOUT", defaultShutdownTimeout)
+	if err != nil {
+		return nil, err
+	}
+	healthCheckInterval, err := getPositiveDuration("HEALTH_CHECK_INTERVAL", defaultHealthCheckInterval)
+	if err != nil {
+		return nil, err
+	}
+	reaperInterval, err := getPositiveDuration("REAPER_INTERVAL", defaultReaperInterval)
+	if err != nil {
+		return nil, err
+	}
+	redirectStatus, err := getInt("REDIRECT_STATUS", defaultRedirectStatus)
+	if err != nil {
+		return nil, err
+	}
+	analyticsBufferSize, err := getPositiveInt("ANALYTICS_BUFFER_SIZE", defaultAnalyticsBufferSize)
+	if err != nil {
+		return nil, err
+	}
+	analyticsBatchSize, err := getPositiveInt("ANALYTICS_BATCH_SIZE", defaultAnalyticsBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	analyticsFlushInterval, err := getPositiveDuration("ANALYTICS_FLUSH_INTERVAL", defaultAnalyticsFlushInterval)
+	if err != nil {
+		return nil, err
+	}
+	memoryFsyncInterval, err := getDuration("MEMORY_FSYNC_INTERVAL", defaultMemoryFsyncInterval)
+	if err != nil {
+		return nil, err
+	}
+	memorySnapshotInterval, err := getDuration("MEMORY_SNAPSHOT_INTERVAL", defaultMemorySnapshotInterval)
+	if err != nil {
+		return nil, err
+	}
+	redisDB, err := getInt("REDIS_DB", 0)
+	if err != nil {
+		return nil, err
+	}
+	cacheSize, err := getInt("CACHE_SIZE", 0)
+	if err != nil {
+		return nil, err
+	}
+	cacheTTL, err := getDuration("CACHE_TTL", defaultCacheTTL)
+	if err != nil {
+		return nil, err
+	}
+	cacheNegativeTTL, err := getDuration("CACHE_NEGATIVE_TTL", defaultCacheNegativeTTL)
+	if err != nil {
+		return nil, err
+	}
+	tracingSampleRatio, err := getFloat("TRACING_SAMPLE_RATIO", defaultTracingSampleRatio)
+	if err != nil {
+		return nil, err
+	}
+	rateLimitCreateRate, err := getFloat("RATE_LIMIT_CREATE_RATE", defaultRateLimitCreateRate)
+	if err != nil {
+		return nil, err
+	}
+	rateLimitCreateBurst, err := getInt("RATE_LIMIT_CREATE_BURST", defaultRateLimitCreateBurst)
+	if err != nil {
+		return nil, err
+	}
+	rateLimitResolveRate, err := getFloat("RATE_LIMIT_RESOLVE_RATE", defaultRateLimitResolveRate)
+	if err != nil {
+		return nil, err
+	}
+	rateLimitResolveBurst, err := getInt("RATE_LIMIT_RESOLVE_BURST", defaultRateLimitResolveBurst)
+	if err != nil {
+		return nil, err
+	}
+	rateLimitShared, err := getBool("RATE_LIMIT_SHARED", false)
+	if err != nil {
+		return nil, err
+	}
+	apiKeyRequired, err := getBool("API_KEY_REQUIRED", false)
+	if err != nil {
+		return nil, err
+	}
+	codeLength, err := getInt("CODE_LENGTH", defaultCodeLength)
+	if err != nil {
+		return nil, err
+	}
+	codeNodeID, err := getInt("CODE_NODE_ID", 0)
+	if err != nil {
+		return nil, err
+	}
+	keyspaceReportInterval, err := getPositiveDuration("KEYSPACE_REPORT_INTERVAL", defaultKeyspaceReportInterval)
+	if err != nil {
+		return nil, err
+	}
+	adminPort, adminToken := os.Getenv("ADMIN_PORT"), os.Getenv("ADMIN_TOKEN")
+	if adminPort != "" && adminToken == "" {
+		return nil, fmt.Errorf("ADMIN_TOKEN is required when ADMIN_PORT is set")
+	}
 	return &Config{
-		StorageType: os.Getenv("STORAGE_TYPE"),
-		DBHost:      os.Getenv("DB_HOST"),
-		DBPort:      os.Getenv("DB_PORT"),
-		DBUser:      os.Getenv("DB_USER"),
-		DBPassword:  os.Getenv("DB_PASSWORD"),
-		DBName:      os.Getenv("DB_NAME"),
-		ServerPort:  os.Getenv("SERVER_PORT"),
-		GRPCPort:    os.Getenv("GRPC_PORT"),
+		StorageType:         os.Getenv("STORAGE_TYPE"),
+		StoragePath:         getString("STORAGE_PATH", defaultStoragePath),
+		RedisAddr:           getString("REDIS_ADDR", defaultRedisAddr),
+		RedisPassword:       os.Getenv("REDIS_PASSWORD"),
+		RedisDB:             redisDB,
+		DBHost:              os.Getenv("DB_HOST"),
+		DBPort:              os.Getenv("DB_PORT"),
+		DBUser:              os.Getenv("DB_USER"),
+		DBPassword:          os.Getenv("DB_PASSWORD"),
+		DBName:              os.Getenv("DB_NAME"),
+		ServerPort:          os.Getenv("SERVER_PORT"),
+		GRPCPort:            os.Getenv("GRPC_PORT"),
+		AdminPort:           adminPort,
+		AdminToken:          adminToken,
+		BaseURL:             os.Getenv("BASE_URL"),
+		RequestTimeout:      requestTimeout,
+		ShutdownTimeout:     shutdownTimeout,
+		HealthCheckInterval: healthCheckInterval,
+		ReaperInterval:      reaperInterval,
+		RedirectStatus:      redirectStatus,
+
+		AnalyticsBufferSize:    analyticsBufferSize,
+		AnalyticsBatchSize:     analyticsBatchSize,
+		AnalyticsFlushInterval: analyticsFlushInterval,
+		AnalyticsIPSalt:        os.Getenv("ANALYTICS_IP_SALT"),
+
+		URLAllowedSchemes: getStrings("URL_ALLOWED_SCHEMES", []string{"http", "https"}),
+		URLTrackingParams: getStrings("URL_TRACKING_PARAMS", nil),
+
+		MemoryPersistDir:       os.Getenv("MEMORY_PERSIST_DIR"),
+		MemoryFsync:            getString("MEMORY_FSYNC", defaultMemoryFsync),
+		MemoryFsyncInterval:    memoryFsyncInterval,
+		MemorySnapshotInterval: memorySnapshotInterval,
+
+		CacheSize:        cacheSize,
+		CacheTTL:         cacheTTL,
+		CacheNegativeTTL: cacheNegativeTTL,
+
+		TracingExporter:    getString("TRACING_EXPORTER", defaultTracingExporter),
+		TracingFile:        getString("TRACING_FILE", defaultTracingFile),
+		TracingSampleRatio: tracingSampleRatio,
+
+		LogLevel:  getString("LOG_LEVEL", defaultLogLevel),
+		LogFormat: getString("LOG_FORMAT", defaultLogFormat),
+
+		RateLimitCreateRate:   rateLimitCreateRate,
+		RateLimitCreateBurst:  rateLimitCreateBurst,
+		RateLimitResolveRate:  rateLimitResolveRate,
+		RateLimitResolveBurst: rateLimitResolveBurst,
+		RateLimitShared:       rateLimitShared,
+
+		APIKeyRequired: apiKeyRequired,
+
+		CodeGenerator: getString("CODE_GENERATOR", defaultCodeGenerator),
+		CodeLength:    codeLength,
+		CodeAlphabet:  os.Getenv("CODE_ALPHABET"),
+		CodeNodeID:    int64(codeNodeID),
+
+		KeyspaceReportInterval: keyspaceReportInterval,
+
+		GRPCLegacyErrors: grpcLegacyErrors,
 	}, nil
 }
+
+// getString читает строку из переменной окружения, возвращая значение по умолчанию если она не задана
+func getString(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// getDuration читает длительность из переменной окружения, возвращая значение по умолчанию если она не задана
+func getDuration(key string, def time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// getPositiveDuration читает длительность, как getDuration, и отклоняет нулевые и отрицательные значения,
+// например для периодов фоновых задач
+func getPositiveDuration(key string, def time.Duration) (time.Duration, error) {
+	d, err := getDuration(key, def)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%s must be positive, got %s", key, d)
+	}
+	return d, nil
+}
+
+// getInt читает целое число из переменной окружения, возвращая значение по умолчанию если она не задана
+func getInt(key string, def int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// getPositiveInt читает целое число, как getInt, и отклоняет значения меньше 1, например для размеров очередей
+func getPositiveInt(key string, def int) (int, error) {
+	n, err := getInt(key, def)
+	if err != nil {
+		return 0, err
+	}
+	if n < 1 {
+		return 0, fmt.Errorf("%s must be positive, got %d", key, n)
+	}
+	return n, nil
+}
+
+// getFloat читает дробное число из переменной окружения, возвращая значение по умолчанию если она не задана
+func getFloat(key string, def float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// getBool читает логическое значение из переменной окружения, возвращая значение по умолчанию если она не задана
+func getBool(key string, def bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+// getStrings читает список значений через запятую из переменной окружения, возвращая значение по умолчанию если она не задана
+func getStrings(key string, def []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"url-shortener/proto"
+)
+
+// createURLRequest — тело запроса POST /api/v1/urls
+type createURLRequest struct {
+	URL            string     `json:"url"`
+	Alias          string     `json:"alias,omitempty"`
+	TTL            int64      `json:"ttl,omitempty"` // время жизни ссылки в секундах
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	RedirectStatus int32      `json:"redirect_status,omitempty"`
+}
+
+// urlResponse — JSON-представление короткой ссылки
+type urlResponse struct {
+	ShortURL       string     `json:"short_url"`
+	FullShortLink  string     `json:"full_short_link"`
+	OriginalURL    string     `json:"original_url"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	RedirectStatus int32      `json:"redirect_status,omitempty"`
+}
+
+// listURLsResponse — тело ответа GET /api/v1/urls
+type listURLsResponse struct {
+	URLs          []urlResponse `json:"urls"`
+	NextPageToken string        `json:"next_page_token,omitempty"`
+}
+
+// maxJSONBodySize ограничивает размер JSON-тела запроса на создание ссылки
+const maxJSONBodySize = 64 << 10
+
+// listOrders сопоставляет значения параметра order с порядком списка ссылок
+var listOrders = map[string]proto.ListOrder{
+	"":       proto.ListOrder_LIST_ORDER_NEWEST_FIRST,
+	"newest": proto.ListOrder_LIST_ORDER_NEWEST_FIRST,
+	"oldest": proto.ListOrder_LIST_ORDER_OLDEST_FIRST,
+}
+
+// CreateURLJSON обрабатывает POST /api/v1/urls: создаёт короткую ссылку по JSON-телу запроса.
+// Отвечает 201 Created для новой ссылки и 200 OK, если оригинальный URL уже был сокращён
+func (h *Handler) CreateURLJSON(w http.ResponseWriter, r *http.Request) {
+	if negotiate(r, jsonContentType) == "" {
+		writeProblem(w, r, http.StatusNotAcceptable, "Поддерживается только application/json")
+		return
+	}
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != jsonContentType {
+		writeProblem(w, r, http.StatusUnsupportedMediaType, "Тело запроса должно быть в формате application/json")
+		return
+	}
+
+	var body createURLRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJSONBodySize)).Decode(&body); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeProblem(w, r, http.StatusRequestEntityTooLarge, "Тело запроса слишком большое")
+			return
+		}
+		writeProblem(w, r, http.StatusBadRequest, "Некорректное тело запроса")
+		return
+	}
+	if body.URL == "" {
+		writeProblem(w, r, http.StatusBadRequest, "Отсутствует параметр url")
+		return
+	}
+
+	req := &proto.CreateURLRequest{
+		OriginalUrl:    body.URL,
+		CustomAlias:    body.Alias,
+		TtlSeconds:     body.TTL,
+		RedirectStatus: body.RedirectStatus,
+	}
+	if body.ExpiresAt != nil {
+		req.ExpiresAt = timestamppb.New(*body.ExpiresAt)
+	}
+	resp, err := h.service.CreateURL(r.Context(), req)
+	if err != nil {
+		status, message := errorStatus(r, err)
+		writeProblem(w, r, status, message)
+		return
+	}
+	setShortCode(r, resp.ShortUrl)
+
+	w.Header().Set("Location", "/api/v1/urls/"+resp.ShortUrl)
+	status := http.StatusCreated
+	if resp.Existing {
+		status = http.StatusOK
+	}
+	writeJSON(w, status, h.createdURLResponse(r, resp))
+}
+
+// GetURLJSON обрабатывает GET /api/v1/urls/{shortURL}: возвращает сведения о ссылке без редиректа
+func (h *Handler) GetURLJSON(w http.ResponseWriter, r *http.Request) {
+	if negotiate(r, jsonContentType) == "" {
+		writeProblem(w, r, http.StatusNotAcceptable, "Поддерживается только application/json")
+		return
+	}
+
+	resp, err := h.service.GetURLInfo(r.Context(), &proto.GetURLInfoRequest{
+		ShortUrl: mux.Vars(r)["shortURL"],
+	})
+	if err != nil {
+		status, message := errorStatus(r, err)
+		writeProblem(w, r, status, message)
+		return
+	}
+
+	body := urlResponse{
+		ShortURL:       resp.ShortUrl,
+		FullShortLink:  h.fullShortLink(r, resp.ShortUrl),
+		OriginalURL:    resp.OriginalUrl,
+		CreatedAt:      resp.CreatedAt.AsTime(),
+		RedirectStatus: resp.RedirectStatus,
+	}
+	if resp.ExpiresAt != nil {
+		expiresAt := resp.ExpiresAt.AsTime()
+		body.ExpiresAt = &expiresAt
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// ListURLsJSON обрабатывает GET /api/v1/urls: возвращает страницу ссылок владельца ключа API.
+// Параметры: page_size, page_token, created_from и created_to в RFC 3339, domain, contains, order (newest или oldest)
+func (h *Handler) ListURLsJSON(w http.ResponseWriter, r *http.Request) {
+	if negotiate(r, jsonContentType) == "" {
+		writeProblem(w, r, http.StatusNotAcceptable, "Поддерживается только application/json")
+		return
+	}
+
+	query := r.URL.Query()
+	req := &proto.ListURLsRequest{
+		PageToken: query.Get("page_token"),
+		Domain:    query.Get("domain"),
+		Contains:  query.Get("contains"),
+	}
+	if pageSize := query.Get("page_size"); pageSize != "" {
+		size, err := strconv.ParseInt(pageSize, 10, 32)
+		if err != nil || size <= 0 {
+			writeProblem(w, r, http.StatusBadRequest, "Некорректный параметр page_size")
+			return
+		}
+		req.PageSize = int32(size)
+	}
+	order, ok := listOrders[query.Get("order")]
+	if !ok {
+		writeProblem(w, r, http.StatusBadRequest, "Параметр order должен быть newest или oldest")
+		return
+	}
+	req.Order = order
+	for param, field := range map[string]**timestamppb.Timestamp{
+		"created_from": &req.CreatedFrom,
+		"created_to":   &req.CreatedTo,
+	} {
+		if value := query.Get(param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				writeProblem(w, r, http.StatusBadRequest, "Некорректный параметр "+param)
+				return
+			}
+			*field = timestamppb.New(t)
+		}
+	}
+
+	resp, err := h.service.ListURLs(r.Context(), req)
+	if err != nil {
+		status, message := errorStatus(r, err)
+		writeProblem(w, r, status, message)
+		return
+	}
+
+	body := listURLsResponse{
+		URLs:          make([]urlResponse, 0, len(resp.Urls)),
+		NextPageToken: resp.NextPageToken,
+	}
+	for _, url := range resp.Urls {
+		item := urlResponse{
+			ShortURL:       url.ShortUrl,
+			FullShortLink:  h.fullShortLink(r, url.ShortUrl),
+			OriginalURL:    url.OriginalUrl,
+			CreatedAt:      url.CreatedAt.AsTime(),
+			RedirectStatus: url.RedirectStatus,
+		}
+		if url.ExpiresAt != nil {
+			expiresAt := url.ExpiresAt.AsTime()
+			item.ExpiresAt = &expiresAt
+		}
+		body.URLs = append(body.URLs, item)
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// createdURLResponse формирует JSON-описание только что созданной ссылки
+func (h *Handler) createdURLResponse(r *http.Request, resp *proto.CreateURLResponse) urlResponse {
+	return urlResponse{
+		ShortURL:      resp.ShortUrl,
+		FullShortLink: h.fullShortLink(r, resp.ShortUrl),
+		OriginalURL:   resp.OriginalUrl,
+		CreatedAt:     resp.CreatedAt.AsTime(),
+	}
+}
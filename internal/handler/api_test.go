@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/service"
+	"url-shortener/internal/storage/memory"
+)
+
+func newTestRouter() http.Handler {
+	return NewHandler(service.NewService(memory.NewMemory()), http.StatusFound, "https://sho.rt").SetupRoutes()
+}
+
+func TestHandler_CreateURLJSON(t *testing.T) {
+	tests := []struct {
+		name           string
+		contentType    string
+		accept         string
+		body           string
+		expectedStatus int
+		expectedType   string
+	}{
+		{
+			name:           "Создание ссылки с алиасом",
+			contentType:    "application/json",
+			accept:         "application/json",
+			body:           `{"url": "HTTPS://Example.com:443/sale", "alias": "spring-sale", "ttl": 3600}`,
+			expectedStatus: http.StatusCreated,
+			expectedType:   jsonContentType,
+		},
+		{
+			name:           "Некорректный URL",
+			contentType:    "application/json",
+			body:           `{"url": "javascript:alert(1)"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedType:   problemContentType,
+		},
+		{
+			name:           "Некорректное тело запроса",
+			contentType:    "application/json",
+			body:           `{"url":`,
+			expectedStatus: http.StatusBadRequest,
+			expectedType:   problemContentType,
+		},
+		{
+			name:           "Тело не в формате JSON",
+			contentType:    "application/x-www-form-urlencoded",
+			body:           "url=https://example.com",
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedType:   problemContentType,
+		},
+		{
+			name:           "Слишком большое тело запроса",
+			contentType:    "application/json",
+			body:           `{"url": "https://example.com/` + strings.Repeat("a", maxJSONBodySize) + `"}`,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedType:   problemContentType,
+		},
+		{
+			name:           "Клиент не принимает JSON",
+			contentType:    "application/json",
+			accept:         "text/html",
+			body:           `{"url": "https://example.com"}`,
+			expectedStatus: http.StatusNotAcceptable,
+			expectedType:   problemContentType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/urls", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			newTestRouter().ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, tt.expectedType, rec.Header().Get("Content-Type"))
+			if tt.expectedStatus != http.StatusCreated {
+				var body problem
+				assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+				assert.Equal(t, tt.expectedStatus, body.Status)
+				assert.Equal(t, "/api/v1/urls", body.Instance)
+				return
+			}
+
+			var body urlResponse
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			assert.Equal(t, "spring-sale", body.ShortURL)
+			assert.Equal(t, "https://sho.rt/spring-sale", body.FullShortLink)
+			assert.Equal(t, "https://example.com/sale", body.OriginalURL)
+			assert.False(t, body.CreatedAt.IsZero())
+			assert.Equal(t, "/api/v1/urls/spring-sale", rec.Header().Get("Location"))
+		})
+	}
+}
+
+func TestHandler_CreateURLJSONExisting(t *testing.T) {
+	router := newTestRouter()
+	create := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/urls", strings.NewReader(`{"url": "https://example.com"}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := create()
+	require.Equal(t, http.StatusCreated, first.Code)
+	// Повторное сокращение возвращает существующую ссылку, но не создаёт новую
+	second := create()
+	require.Equal(t, http.StatusOK, second.Code)
+
+	var created, existing urlResponse
+	require.NoError(t, json.NewDecoder(first.Body).Decode(&created))
+	require.NoError(t, json.NewDecoder(second.Body).Decode(&existing))
+	assert.Equal(t, created.ShortURL, existing.ShortURL)
+	assert.Equal(t, first.Header().Get("Location"), second.Header().Get("Location"))
+}
+
+func TestHandler_GetURLJSON(t *testing.T) {
+	router := newTestRouter()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/urls", strings.NewReader(`{"url": "https://example.com", "alias": "abc123", "ttl": 60}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/urls/abc123", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var body urlResponse
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "https://example.com", body.OriginalURL)
+	assert.Equal(t, "https://sho.rt/abc123", body.FullShortLink)
+	assert.NotNil(t, body.ExpiresAt)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/urls/xyz789", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, problemContentType, rec.Header().Get("Content-Type"))
+}
+
+func TestHandler_ListURLsJSON(t *testing.T) {
+	store := memory.NewMemory()
+	authenticator := auth.New(store)
+	_, key, err := authenticator.Issue(context.Background(), "alice", "")
+	require.NoError(t, err)
+	router := NewHandler(service.NewService(store), http.StatusFound, "https://sho.rt", WithAuthenticator(authenticator)).SetupRoutes()
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	for _, alias := range []string{"first", "second", "third"} {
+		rec := do(http.MethodPost, "/api/v1/urls", `{"url": "https://example.com/`+alias+`", "alias": "`+alias+`"}`)
+		require.Equal(t, http.StatusCreated, rec.Code)
+	}
+
+	var shortURLs []string
+	target := "/api/v1/urls?page_size=2&order=oldest&domain=example.com"
+	for target != "" {
+		rec := do(http.MethodGet, target, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+		var body listURLsResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		for _, url := range body.URLs {
+			shortURLs = append(shortURLs, url.ShortURL)
+		}
+		target = ""
+		if body.NextPageToken != "" {
+			target = "/api/v1/urls?page_size=2&order=oldest&page_token=" + body.NextPageToken
+		}
+	}
+	assert.Equal(t, []string{"first", "second", "third"}, shortURLs)
+
+	tests := []struct {
+		name           string
+		target         string
+		key            bool
+		expectedStatus int
+	}{
+		{name: "Подстрока", target: "/api/v1/urls?contains=SECOND", key: true, expectedStatus: http.StatusOK},
+		{name: "Неизвестный порядок", target: "/api/v1/urls?order=random", key: true, expectedStatus: http.StatusBadRequest},
+		{name: "Некорректная дата", target: "/api/v1/urls?created_from=yesterday", key: true, expectedStatus: http.StatusBadRequest},
+		{name: "Некорректный токен", target: "/api/v1/urls?page_token=%21", key: true, expectedStatus: http.StatusBadRequest},
+		{name: "Запрос без ключа", target: "/api/v1/urls", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.key {
+				req.Header.Set("Authorization", "Bearer "+key)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestHandler_ErrorNegotiation(t *testing.T) {
+	tests := []struct {
+		name         string
+		accept       string
+		expectedType string
+	}{
+		{name: "Без заголовка Accept", accept: "", expectedType: "text/plain; charset=utf-8"},
+		{name: "Браузер", accept: "text/html,application/xhtml+xml,*/*;q=0.8", expectedType: "text/plain; charset=utf-8"},
+		{name: "JSON-клиент", accept: "application/json", expectedType: problemContentType},
+		{name: "problem+json важнее текста", accept: "text/plain;q=0.5, application/problem+json", expectedType: problemContentType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/xyz789", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			newTestRouter().ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Equal(t, tt.expectedType, rec.Header().Get("Content-Type"))
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"url-shortener/internal/auth"
+)
+
+// authenticate проверяет ключ API из заголовка Authorization и привязывает его владельца к контексту
+// запроса. Запросы без заголовка проходят анонимно, с неверным или отозванным ключом — получают 401.
+// Без аутентификатора возвращает next без изменений
+func (h *Handler) authenticate(next http.Handler) http.Handler {
+	if h.authenticator == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		secret, ok := auth.BearerToken(header)
+		if !ok {
+			rejectKey(w, r)
+			return
+		}
+		key, err := h.authenticator.Authenticate(r.Context(), secret)
+		if errors.Is(err, auth.ErrInvalidKey) {
+			rejectKey(w, r)
+			return
+		}
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.ContextWithKey(r.Context(), key)))
+	})
+}
+
+// rejectKey отвечает 401 на запрос с неверным ключом API
+func rejectKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	respondError(w, r, http.StatusUnauthorized, "Неверный или отозванный ключ API")
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/service"
+	"url-shortener/internal/storage/memory"
+)
+
+func TestHandler_Authentication(t *testing.T) {
+	store := memory.NewMemory()
+	authenticator := auth.New(store)
+	_, alice, err := authenticator.Issue(context.Background(), "alice", "")
+	require.NoError(t, err)
+	_, bob, err := authenticator.Issue(context.Background(), "bob", "")
+	require.NoError(t, err)
+	revokedKey, revoked, err := authenticator.Issue(context.Background(), "alice", "")
+	require.NoError(t, err)
+	require.NoError(t, authenticator.Revoke(context.Background(), revokedKey.ID))
+	router := NewHandler(service.NewService(store), http.StatusFound, "", WithAuthenticator(authenticator)).SetupRoutes()
+
+	do := func(method, target, key string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/", alice, url.Values{"url": {"https://example.com"}})
+	require.Equal(t, http.StatusOK, rec.Code)
+	shortURL := strings.TrimSpace(rec.Body.String())
+
+	tests := []struct {
+		name           string
+		method         string
+		key            string
+		expectedStatus int
+		expectedAuth   string
+	}{
+		{
+			name:           "Изменение без ключа",
+			method:         http.MethodPatch,
+			expectedStatus: http.StatusUnauthorized,
+			expectedAuth:   "Bearer",
+		},
+		{
+			name:           "Отозванный ключ",
+			method:         http.MethodGet,
+			key:            revoked,
+			expectedStatus: http.StatusUnauthorized,
+			expectedAuth:   `Bearer error="invalid_token"`,
+		},
+		{
+			name:           "Неизвестный ключ",
+			method:         http.MethodGet,
+			key:            "us_unknown",
+			expectedStatus: http.StatusUnauthorized,
+			expectedAuth:   `Bearer error="invalid_token"`,
+		},
+		{
+			name:           "Изменение чужой ссылки",
+			method:         http.MethodPatch,
+			key:            bob,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Удаление чужой ссылки",
+			method:         http.MethodDelete,
+			key:            bob,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Изменение своей ссылки",
+			method:         http.MethodPatch,
+			key:            alice,
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "Удаление своей ссылки",
+			method:         http.MethodDelete,
+			key:            alice,
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := "/" + shortURL
+			if tt.method == http.MethodGet {
+				target += "/stats"
+			}
+			rec := do(tt.method, target, tt.key, url.Values{"url": {"https://example.com/new"}})
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, tt.expectedAuth, rec.Header().Get("WWW-Authenticate"))
+		})
+	}
+
+	// Редирект не требует ключа
+	rec = do(http.MethodGet, "/missing", "", nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestHandler_AuthChallenge(t *testing.T) {
+	store := memory.NewMemory()
+	router := NewHandler(service.NewService(store, service.WithOwnerRequired()), http.StatusFound, "",
+		WithAuthenticator(auth.New(store))).SetupRoutes()
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+	}{
+		{name: "JSON API", method: http.MethodPost, target: "/api/v1/urls", contentType: "application/json", body: `{"url":"https://example.com"}`},
+		{name: "Список ссылок", method: http.MethodGet, target: "/api/v1/urls"},
+		{name: "Форма", method: http.MethodPost, target: "/", contentType: "application/x-www-form-urlencoded", body: "url=https%3A%2F%2Fexample.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+		})
+	}
+}
@@ -1,77 +1,316 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 	"url-shortener/proto"
 
 	"github.com/gorilla/mux"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"url-shortener/internal/analytics"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/logging"
+	"url-shortener/internal/ratelimit"
 	"url-shortener/internal/service"
 	"url-shortener/internal/storage"
+	"url-shortener/internal/urlnorm"
 )
 
+// previewSuffix — суффикс короткой ссылки, при котором вместо редиректа возвращается целевой URL
+const previewSuffix = "+"
+
+// statusClientClosedRequest — нестандартный статус nginx для запросов, отменённых клиентом до ответа.
+// Клиент его уже не получит, но статус попадает в лог запроса и метрики вместо 500
+const statusClientClosedRequest = 499
+
 // Handler обрабатывает HTTP-запросы для сервиса сокращения ссылок
 type Handler struct {
-	service *service.Service
+	service        *service.Service
+	redirectStatus int
+	baseURL        string
+	limiter        *ratelimit.Limiter
+	authenticator  *auth.Authenticator
+}
+
+// Option задаёт необязательный параметр обработчика
+type Option func(*Handler)
+
+// WithRateLimiter включает ограничение частоты создания ссылок и запросов по коротким ссылкам
+func WithRateLimiter(l *ratelimit.Limiter) Option {
+	return func(h *Handler) {
+		h.limiter = l
+	}
+}
+
+// WithAuthenticator включает проверку ключей API из заголовка Authorization
+func WithAuthenticator(a *auth.Authenticator) Option {
+	return func(h *Handler) {
+		h.authenticator = a
+	}
 }
 
-// NewHandler создаёт экземпляр обработчика с переданным сервисом
-func NewHandler(service *service.Service) *Handler {
-	return &Handler{service: service}
+// NewHandler создаёт экземпляр обработчика с переданным сервисом, HTTP-статусом редиректа по умолчанию
+// и базовым адресом коротких ссылок. Если baseURL пуст, адрес берётся из запроса
+func NewHandler(service *service.Service, redirectStatus int, baseURL string, opts ...Option) *Handler {
+	h := &Handler{service: service, redirectStatus: redirectStatus, baseURL: strings.TrimSuffix(baseURL, "/")}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
-// CreateURL обрабатывает POST-запрос для создания короткой ссылки
+// CreateURL обрабатывает POST-запрос для создания короткой ссылки.
+// По умолчанию отвечает коротким кодом в виде текста, а при Accept: application/json — JSON-описанием ссылки
 func (h *Handler) CreateURL(w http.ResponseWriter, r *http.Request) {
 	originalURL := r.FormValue("url")
 	if originalURL == "" {
-		http.Error(w, "Отсутствует параметр url", http.StatusBadRequest)
+		respondError(w, r, http.StatusBadRequest, "Отсутствует параметр url")
 		return
 	}
 
-	resp, err := h.service.CreateURL(r.Context(), &proto.CreateURLRequest{
+	req := &proto.CreateURLRequest{
 		OriginalUrl: originalURL,
-	})
+		CustomAlias: r.FormValue("alias"),
+	}
+	if ttl := r.FormValue("ttl"); ttl != "" {
+		seconds, err := strconv.ParseInt(ttl, 10, 64)
+		if err != nil {
+			respondError(w, r, http.StatusBadRequest, "Некорректный параметр ttl")
+			return
+		}
+		req.TtlSeconds = seconds
+	}
+	if expiresAt := r.FormValue("expires_at"); expiresAt != "" {
+		t, err := time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			respondError(w, r, http.StatusBadRequest, "Некорректный параметр expires_at, ожидается RFC 3339")
+			return
+		}
+		req.ExpiresAt = timestamppb.New(t)
+	}
+	if redirectStatus := r.FormValue("redirect_status"); redirectStatus != "" {
+		code, err := strconv.ParseInt(redirectStatus, 10, 32)
+		if err != nil {
+			respondError(w, r, http.StatusBadRequest, "Некорректный параметр redirect_status")
+			return
+		}
+		req.RedirectStatus = int32(code)
+	}
+
+	resp, err := h.service.CreateURL(r.Context(), req)
 	if err != nil {
-		http.Error(w, "Не удалось создать короткую ссылку: "+err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
-	if resp.Error != "" {
-		http.Error(w, resp.Error, http.StatusInternalServerError)
+	setShortCode(r, resp.ShortUrl)
+
+	if negotiate(r, textContentType, jsonContentType) == jsonContentType {
+		writeJSON(w, http.StatusOK, h.createdURLResponse(r, resp))
 		return
 	}
-
 	fmt.Fprintln(w, resp.ShortUrl)
 }
 
-// GetURL обрабатывает GET-запрос для получения оригинальной ссылки по короткой
+// GetURL обрабатывает GET-запрос по короткой ссылке и перенаправляет на оригинальную.
+// В режиме предпросмотра (?preview=1 или суффикс "+") оригинальная ссылка возвращается в теле ответа
 func (h *Handler) GetURL(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	shortURL := vars["shortURL"]
+	preview := r.URL.Query().Get("preview") == "1"
+	if strings.HasSuffix(shortURL, previewSuffix) {
+		shortURL = strings.TrimSuffix(shortURL, previewSuffix)
+		preview = true
+	}
 
-	resp, err := h.service.GetURL(r.Context(), &proto.GetURLRequest{
+	ctx := analytics.ContextWithClient(r.Context(), analytics.Client{
+		IP:        clientIP(r),
+		Referrer:  r.Referer(),
+		UserAgent: r.UserAgent(),
+	})
+	resp, err := h.service.GetURL(ctx, &proto.GetURLRequest{
 		ShortUrl: shortURL,
 	})
 	if err != nil {
-		http.Error(w, "Внутренняя ошибка сервера", http.StatusInternalServerError)
+		writeError(w, r, err)
+		return
+	}
+
+	if preview {
+		fmt.Fprintln(w, resp.OriginalUrl)
+		return
+	}
+	redirectStatus := h.redirectStatus
+	if resp.RedirectStatus != 0 {
+		redirectStatus = int(resp.RedirectStatus)
+	}
+	http.Redirect(w, r, resp.OriginalUrl, redirectStatus)
+}
+
+// DeleteURL обрабатывает DELETE-запрос на удаление короткой ссылки
+func (h *Handler) DeleteURL(w http.ResponseWriter, r *http.Request) {
+	_, err := h.service.DeleteURL(r.Context(), &proto.DeleteURLRequest{
+		ShortUrl: mux.Vars(r)["shortURL"],
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UpdateURL обрабатывает PATCH-запрос на изменение оригинального URL короткой ссылки
+func (h *Handler) UpdateURL(w http.ResponseWriter, r *http.Request) {
+	originalURL := r.FormValue("url")
+	if originalURL == "" {
+		respondError(w, r, http.StatusBadRequest, "Отсутствует параметр url")
+		return
+	}
+
+	_, err := h.service.UpdateURL(r.Context(), &proto.UpdateURLRequest{
+		ShortUrl:    mux.Vars(r)["shortURL"],
+		OriginalUrl: originalURL,
+	})
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
-	if resp.Error != "" {
-		if errors.Is(err, storage.ErrNotFound) {
-			http.Error(w, "Ссылка не найдена", http.StatusNotFound)
-		} else {
-			http.Error(w, resp.Error, http.StatusInternalServerError)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// statsResponse — JSON-представление статистики переходов
+type statsResponse struct {
+	ShortURL       string        `json:"short_url"`
+	TotalClicks    int64         `json:"total_clicks"`
+	UniqueVisitors int64         `json:"unique_visitors"`
+	Buckets        []statsBucket `json:"buckets"`
+}
+
+// statsBucket — количество переходов за интервал
+type statsBucket struct {
+	Start  time.Time `json:"start"`
+	Clicks int64     `json:"clicks"`
+}
+
+// GetStats обрабатывает GET-запрос статистики переходов по короткой ссылке.
+// Размер интервала группировки задаётся параметром bucket в формате длительности Go (например, 1h)
+func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
+	shortURL := mux.Vars(r)["shortURL"]
+	req := &proto.GetStatsRequest{ShortUrl: shortURL}
+	if bucket := r.URL.Query().Get("bucket"); bucket != "" {
+		d, err := time.ParseDuration(bucket)
+		if err != nil || d < time.Second {
+			respondError(w, r, http.StatusBadRequest, "Некорректный параметр bucket")
+			return
 		}
+		req.BucketSeconds = int64(d / time.Second)
+	}
+
+	resp, err := h.service.GetStats(r.Context(), req)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	fmt.Fprintln(w, resp.OriginalUrl)
+	body := statsResponse{
+		ShortURL:       shortURL,
+		TotalClicks:    resp.TotalClicks,
+		UniqueVisitors: resp.UniqueVisitors,
+		Buckets:        make([]statsBucket, 0, len(resp.Buckets)),
+	}
+	for _, bucket := range resp.Buckets {
+		body.Buckets = append(body.Buckets, statsBucket{Start: bucket.Start.AsTime(), Clicks: bucket.Clicks})
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// errorResponses сопоставляет известные ошибки сервиса и хранилища с HTTP-статусом и текстом ответа
+var errorResponses = []struct {
+	err     error
+	status  int
+	message string
+}{
+	{storage.ErrExpired, http.StatusGone, "Срок действия ссылки истёк"},
+	{storage.ErrNotFound, http.StatusNotFound, "Ссылка не найдена"},
+	{storage.ErrOriginalURLExists, http.StatusConflict, "Для этого URL уже есть короткая ссылка"},
+	{storage.ErrShortURLConflict, http.StatusConflict, "Короткая ссылка уже занята"},
+	{storage.ErrInvalid, http.StatusBadRequest, "Некорректные данные ссылки"},
+	{urlnorm.ErrInvalidURL, http.StatusBadRequest, "Некорректный URL"},
+	{service.ErrInvalidAlias, http.StatusBadRequest, "Некорректный алиас"},
+	{service.ErrAliasTaken, http.StatusConflict, "Алиас уже занят"},
+	{service.ErrInvalidExpiry, http.StatusBadRequest, "Некорректный срок действия ссылки"},
+	{service.ErrInvalidRedirectStatus, http.StatusBadRequest, "Статус редиректа должен быть 301, 302, 307 или 308"},
+	{service.ErrInvalidStatsQuery, http.StatusBadRequest, "Некорректные параметры статистики"},
+	{service.ErrInvalidListQuery, http.StatusBadRequest, "Некорректные параметры списка ссылок"},
+	{service.ErrUnauthenticated, http.StatusUnauthorized, "Требуется ключ API"},
+	{service.ErrPermissionDenied, http.StatusForbidden, "Ссылка принадлежит другому владельцу"},
+	{service.ErrCodesExhausted, http.StatusServiceUnavailable, "Не удалось подобрать свободный код ссылки, повторите запрос"},
+	{service.ErrAnalyticsDisabled, http.StatusNotImplemented, "Статистика переходов отключена"},
+	{context.DeadlineExceeded, http.StatusGatewayTimeout, "Превышено время обработки запроса"},
+	{context.Canceled, statusClientClosedRequest, "Запрос отменён клиентом"},
+}
+
+// errorStatus возвращает HTTP-статус и текст ответа для ошибки сервиса.
+// Непредвиденные ошибки пишутся в лог запроса, клиент получает только общий текст.
+// Отмена запроса клиентом — штатная ситуация и пишется в лог только на уровне debug
+func errorStatus(r *http.Request, err error) (int, string) {
+	for _, resp := range errorResponses {
+		if errors.Is(err, resp.err) {
+			if resp.status == statusClientClosedRequest {
+				logging.FromContext(r.Context()).Debug("Request canceled by client", "error", err)
+			}
+			return resp.status, resp.message
+		}
+	}
+	logging.FromContext(r.Context()).Error("Request failed", "error", err)
+	return http.StatusInternalServerError, "Внутренняя ошибка сервера"
+}
+
+// writeError отвечает HTTP-статусом, соответствующим ошибке сервиса
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status, message := errorStatus(r, err)
+	respondError(w, r, status, message)
+}
+
+// fullShortLink возвращает полный адрес короткой ссылки
+func (h *Handler) fullShortLink(r *http.Request, shortURL string) string {
+	baseURL := h.baseURL
+	if baseURL == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		baseURL = scheme + "://" + r.Host
+	}
+	return baseURL + "/" + shortURL
+}
+
+// clientIP возвращает IP-адрес клиента без порта
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
 }
 
 // SetupRoutes настраивает маршруты API с использованием маршрутизатора gorilla/mux
 func (h *Handler) SetupRoutes() *mux.Router {
 	r := mux.NewRouter()
-	r.HandleFunc("/", h.CreateURL).Methods("POST")
-	r.HandleFunc("/{shortURL}", h.GetURL).Methods("GET")
+	r.Use(logRequests, traceRoute, h.authenticate)
+	api := r.PathPrefix("/api/v1").Subrouter()
+	api.Handle("/urls", h.limit(ratelimit.Create, h.CreateURLJSON)).Methods("POST")
+	api.Handle("/urls", h.limit(ratelimit.Resolve, h.ListURLsJSON)).Methods("GET")
+	api.Handle("/urls/{shortURL}", h.limit(ratelimit.Resolve, h.GetURLJSON)).Methods("GET")
+	r.Handle("/", h.limit(ratelimit.Create, h.CreateURL)).Methods("POST")
+	r.Handle("/{shortURL}", h.limit(ratelimit.Resolve, h.GetURL)).Methods("GET")
+	r.HandleFunc("/{shortURL}", h.DeleteURL).Methods("DELETE")
+	r.HandleFunc("/{shortURL}", h.UpdateURL).Methods("PATCH")
+	r.Handle("/{shortURL}/stats", h.limit(ratelimit.Resolve, h.GetStats)).Methods("GET")
 	return r
 }
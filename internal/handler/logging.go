@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"url-shortener/internal/logging"
+)
+
+// logRequests принимает или назначает X-Request-ID, привязывает логгер запроса к контексту
+// и пишет запись о каждом запросе
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		requestID := logging.RequestID(r.Header.Get(logging.RequestIDHeader))
+		w.Header().Set(logging.RequestIDHeader, requestID)
+		ctx := logging.ContextWithRequest(r.Context(), requestID)
+		if shortURL := mux.Vars(r)["shortURL"]; shortURL != "" {
+			logging.SetShortCode(ctx, strings.TrimSuffix(shortURL, previewSuffix))
+		}
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r.WithContext(ctx))
+
+		attrs := []any{
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", sw.status,
+			"latency", time.Since(start),
+		}
+		if shortCode := logging.ShortCode(ctx); shortCode != "" {
+			attrs = append(attrs, "short_code", shortCode)
+		}
+		level := slog.LevelInfo
+		switch {
+		case sw.status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case sw.status == statusClientClosedRequest:
+			level = slog.LevelDebug
+		}
+		logging.FromContext(ctx).Log(ctx, level, "HTTP request", attrs...)
+	})
+}
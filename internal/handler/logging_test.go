@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"url-shortener/internal/logging"
+	"url-shortener/internal/service"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/memory"
+)
+
+func TestHandler_RequestLog(t *testing.T) {
+	var buf bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	tests := []struct {
+		name              string
+		requestID         string
+		expectedRequestID string // если пустой — ожидается сгенерированный идентификатор
+	}{
+		{name: "Идентификатор от клиента", requestID: "req-42", expectedRequestID: "req-42"},
+		{name: "Идентификатор не передан"},
+		{name: "Идентификатор с переводом строки", requestID: "req\n42"},
+	}
+
+	router := newTestRouter()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+			req.Header.Set(logging.RequestIDHeader, tt.requestID)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			requestID := rec.Header().Get(logging.RequestIDHeader)
+			if tt.expectedRequestID != "" {
+				assert.Equal(t, tt.expectedRequestID, requestID)
+			} else {
+				assert.NotEmpty(t, requestID)
+				assert.NotEqual(t, tt.requestID, requestID)
+			}
+
+			var record map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+			assert.Equal(t, "HTTP request", record["msg"])
+			assert.Equal(t, requestID, record["request_id"])
+			assert.Equal(t, "/missing", record["path"])
+			assert.Equal(t, float64(http.StatusNotFound), record["status"])
+			assert.Equal(t, "missing", record["short_code"])
+		})
+	}
+}
+
+// canceledStorage имитирует хранилище, запрос к которому прерван отменой запроса клиентом
+type canceledStorage struct {
+	*memory.Memory
+}
+
+func (canceledStorage) Get(context.Context, string) (storage.URL, error) {
+	return storage.URL{}, context.Canceled
+}
+
+func TestHandler_CanceledRequest(t *testing.T) {
+	var buf bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	router := NewHandler(service.NewService(canceledStorage{memory.NewMemory()}), http.StatusFound, "").SetupRoutes()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/abc123", nil))
+
+	// Отмена не считается ошибкой сервера и не пишется в лог на уровне info
+	assert.Equal(t, statusClientClosedRequest, rec.Code)
+	assert.Empty(t, buf.String())
+}
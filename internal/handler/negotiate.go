@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	jsonContentType    = "application/json"
+	problemContentType = "application/problem+json"
+	textContentType    = "text/plain"
+)
+
+// problem — описание ошибки в формате RFC 7807
+type problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
+
+// respondError отвечает ошибкой в формате problem+json или обычным текстом в зависимости от заголовка Accept
+func respondError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	if negotiate(r, textContentType, problemContentType, jsonContentType) == textContentType {
+		setChallenge(w, status)
+		http.Error(w, message, status)
+		return
+	}
+	writeProblem(w, r, status, message)
+}
+
+// writeProblem отвечает ошибкой в формате RFC 7807
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	setChallenge(w, status)
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem{ //nolint:errcheck
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+	})
+}
+
+// setChallenge добавляет к ответу 401 заголовок WWW-Authenticate со схемой Bearer,
+// если обработчик не задал более точный, например с error="invalid_token"
+func setChallenge(w http.ResponseWriter, status int) {
+	if status == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+}
+
+// writeJSON отвечает телом body в формате JSON
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", jsonContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body) //nolint:errcheck
+}
+
+// negotiate выбирает из offers тип содержимого, который клиент предпочитает по заголовку Accept.
+// Без заголовка Accept выбирается первый вариант, если клиент не принимает ни один — пустая строка
+func negotiate(r *http.Request, offers ...string) string {
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return offers[0]
+	}
+	best, bestQuality := "", 0.0
+	for _, offer := range offers {
+		if q := acceptQuality(accept, offer); q > bestQuality {
+			best, bestQuality = offer, q
+		}
+	}
+	return best
+}
+
+// acceptQuality возвращает вес q, с которым заголовок Accept допускает mediaType.
+// Учитывается наиболее точное совпадение: тип целиком, затем type/* и */*
+func acceptQuality(accept, mediaType string) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	quality, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		accepted, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		var s int
+		switch accepted {
+		case mediaType:
+			s = 2
+		case mainType + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s <= specificity {
+			continue
+		}
+		specificity, quality = s, 1
+		if q, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+	}
+	return quality
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"url-shortener/internal/ratelimit"
+)
+
+// limit списывает токен из бюджета операции op перед вызовом next. Клиенту, исчерпавшему бюджет,
+// отвечает 429 с заголовком Retry-After. Без ограничителя возвращает next без изменений
+func (h *Handler) limit(op ratelimit.Operation, next http.HandlerFunc) http.Handler {
+	if h.limiter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := h.limiter.Allow(r.Context(), op, ratelimit.Client(r.Context(), clientIP(r)), 1)
+		var limitErr *ratelimit.LimitError
+		if errors.As(err, &limitErr) {
+			w.Header().Set("Retry-After", strconv.FormatInt(limitErr.RetryAfterSeconds(), 10))
+			respondError(w, r, http.StatusTooManyRequests, "Слишком много запросов, повторите позже")
+			return
+		}
+		next(w, r)
+	})
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"url-shortener/internal/ratelimit"
+	"url-shortener/internal/service"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/memory"
+)
+
+func TestHandler_RateLimit(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.NewMemory(), ratelimit.Config{
+		Create:  storage.TokenBucket{Rate: 0.001, Burst: 1},
+		Resolve: storage.TokenBucket{Rate: 0.001, Burst: 2},
+	})
+	router := NewHandler(service.NewService(memory.NewMemory()), http.StatusFound, "", WithRateLimiter(limiter)).SetupRoutes()
+
+	create := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(url.Values{"url": {"https://example.com"}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, create("10.0.0.1:5000").Code)
+	rec := create("10.0.0.1:5001")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "1000", rec.Header().Get("Retry-After"))
+	// Бюджет считается по IP-адресу, а не по соединению
+	assert.Equal(t, http.StatusOK, create("10.0.0.2:5000").Code)
+
+	// Редиректы расходуют отдельный бюджет
+	for _, expected := range []int{http.StatusNotFound, http.StatusNotFound, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+		req.RemoteAddr = "10.0.0.1:5000"
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, expected, rec.Code)
+	}
+}
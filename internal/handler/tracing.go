@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"url-shortener/internal/logging"
+	"url-shortener/internal/tracing"
+)
+
+// tracerName — имя трассировщика HTTP-запросов
+const tracerName = "url-shortener/internal/handler"
+
+// traceRoute создаёт span запроса с шаблоном маршрута и короткой ссылкой. Если запрос пришёл
+// с заголовком traceparent, span продолжает трассировку клиента
+func traceRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, _ := mux.CurrentRoute(r).GetPathTemplate()
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := otel.Tracer(tracerName).Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+		if shortURL := mux.Vars(r)["shortURL"]; shortURL != "" {
+			span.SetAttributes(tracing.ShortCodeKey.String(strings.TrimSuffix(shortURL, previewSuffix)))
+		}
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r.WithContext(ctx))
+		span.SetAttributes(semconv.HTTPResponseStatusCode(sw.status))
+		if sw.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(sw.status))
+		}
+	})
+}
+
+// setShortCode добавляет короткую ссылку, созданную обработчиком, к span'у и записи лога запроса
+func setShortCode(r *http.Request, shortURL string) {
+	trace.SpanFromContext(r.Context()).SetAttributes(tracing.ShortCodeKey.String(shortURL))
+	logging.SetShortCode(r.Context(), shortURL)
+}
+
+// statusWriter запоминает код ответа для span'а и записи лога запроса
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
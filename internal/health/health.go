@@ -0,0 +1,134 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// checkTimeout ограничивает одну проверку готовности
+const checkTimeout = 2 * time.Second
+
+// Check проверяет доступность зависимости, например подключение к хранилищу
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker выполняет проверки готовности для /readyz и gRPC-протокола grpc.health.v1
+type Checker struct {
+	mu       sync.RWMutex
+	checks   []namedCheck
+	stopping atomic.Bool
+
+	grpc *health.Server
+}
+
+// New создает Checker без проверок: пока проверки не добавлены, сервис считается готовым
+func New() *Checker {
+	return &Checker{grpc: health.NewServer()}
+}
+
+// Add добавляет проверку готовности с именем name
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// GRPCServer возвращает реализацию grpc.health.v1 для регистрации на gRPC-сервере
+func (c *Checker) GRPCServer() healthpb.HealthServer {
+	return c.grpc
+}
+
+// Shutdown переводит сервис в состояние NOT_SERVING перед остановкой серверов
+func (c *Checker) Shutdown() {
+	c.stopping.Store(true)
+	c.grpc.Shutdown()
+}
+
+// Ready выполняет проверки и возвращает ошибки не прошедших, пустой результат означает готовность
+func (c *Checker) Ready(ctx context.Context) map[string]string {
+	failed := make(map[string]string)
+	if c.stopping.Load() {
+		failed["shutdown"] = "service is shutting down"
+		return failed
+	}
+
+	c.mu.RLock()
+	checks := c.checks
+	c.mu.RUnlock()
+	for _, nc := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+		err := nc.check(checkCtx)
+		cancel()
+		if err != nil {
+			failed[nc.name] = err.Error()
+		}
+	}
+	return failed
+}
+
+// Run обновляет статус gRPC health по результатам проверок каждые interval до отмены ctx
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	c.update(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.update(ctx)
+		}
+	}
+}
+
+// update выставляет общий статус сервера (пустое имя сервиса) по результатам проверок
+func (c *Checker) update(ctx context.Context) {
+	if c.stopping.Load() {
+		return
+	}
+	status := healthpb.HealthCheckResponse_SERVING
+	if failed := c.Ready(ctx); len(failed) > 0 {
+		slog.Warn("Readiness checks failed", "checks", failed)
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	c.grpc.SetServingStatus("", status)
+}
+
+// response — тело ответов /healthz и /readyz
+type response struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Liveness отвечает на /healthz: процесс жив и обрабатывает запросы
+func (c *Checker) Liveness(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, response{Status: "ok"})
+}
+
+// Readiness отвечает на /readyz: 200 если все проверки прошли, иначе 503 со списком ошибок
+func (c *Checker) Readiness(w http.ResponseWriter, r *http.Request) {
+	if failed := c.Ready(r.Context()); len(failed) > 0 {
+		writeJSON(w, http.StatusServiceUnavailable, response{Status: "unavailable", Checks: failed})
+		return
+	}
+	writeJSON(w, http.StatusOK, response{Status: "ok"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body) //nolint:errcheck
+}
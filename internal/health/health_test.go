@@ -0,0 +1,72 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestChecker_Readiness(t *testing.T) {
+	tests := []struct {
+		name           string
+		checkErr       error
+		shutdown       bool
+		expectedStatus int
+		expectedGRPC   healthpb.HealthCheckResponse_ServingStatus
+		expectedChecks map[string]string
+	}{
+		{
+			name:           "Все проверки прошли",
+			expectedStatus: http.StatusOK,
+			expectedGRPC:   healthpb.HealthCheckResponse_SERVING,
+		},
+		{
+			name:           "Хранилище недоступно",
+			checkErr:       errors.New("connection refused"),
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedGRPC:   healthpb.HealthCheckResponse_NOT_SERVING,
+			expectedChecks: map[string]string{"storage": "connection refused"},
+		},
+		{
+			name:           "Остановка сервиса",
+			shutdown:       true,
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedGRPC:   healthpb.HealthCheckResponse_NOT_SERVING,
+			expectedChecks: map[string]string{"shutdown": "service is shutting down"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New()
+			c.Add("storage", func(context.Context) error { return tt.checkErr })
+			c.update(context.Background())
+			if tt.shutdown {
+				c.Shutdown()
+			}
+
+			rec := httptest.NewRecorder()
+			c.Readiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			var body response
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			assert.Equal(t, tt.expectedChecks, body.Checks)
+
+			resp, err := c.GRPCServer().Check(context.Background(), &healthpb.HealthCheckRequest{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedGRPC, resp.Status)
+
+			// Liveness не зависит от проверок готовности
+			rec = httptest.NewRecorder()
+			c.Liveness(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			assert.Equal(t, http.StatusOK, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDHeader — заголовок HTTP и ключ метаданных gRPC с идентификатором запроса
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength ограничивает длину идентификатора, принятого от клиента
+const maxRequestIDLength = 128
+
+// healthServicePrefix — методы проверок grpc.health.v1, которые не попадают в лог
+const healthServicePrefix = "/grpc.health.v1."
+
+// Форматы логов
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// New создаёт логгер, пишущий в w записи уровня не ниже level (debug, info, warn, error) в формате format
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	switch format {
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q", format)
+}
+
+// request — данные запроса, общие для обработчиков и итоговой записи лога
+type request struct {
+	logger    *slog.Logger
+	shortCode string
+}
+
+type requestKey struct{}
+
+// ContextWithRequest привязывает к контексту логгер запроса с полем request_id
+func ContextWithRequest(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestKey{}, &request{logger: slog.Default().With("request_id", requestID)})
+}
+
+// FromContext возвращает логгер запроса или логгер по умолчанию, если контекст не связан с запросом
+func FromContext(ctx context.Context) *slog.Logger {
+	if r, ok := ctx.Value(requestKey{}).(*request); ok {
+		return r.logger
+	}
+	return slog.Default()
+}
+
+// SetShortCode запоминает короткую ссылку запроса для итоговой записи лога
+func SetShortCode(ctx context.Context, shortCode string) {
+	if r, ok := ctx.Value(requestKey{}).(*request); ok {
+		r.shortCode = shortCode
+	}
+}
+
+// ShortCode возвращает короткую ссылку, сохранённую SetShortCode
+func ShortCode(ctx context.Context) string {
+	if r, ok := ctx.Value(requestKey{}).(*request); ok {
+		return r.shortCode
+	}
+	return ""
+}
+
+// RequestID возвращает идентификатор запроса от клиента или новый, если клиент его не передал.
+// Идентификаторы с пробелами и управляющими символами заменяются, чтобы не искажать логи
+func RequestID(incoming string) string {
+	if incoming != "" && len(incoming) <= maxRequestIDLength && !strings.ContainsFunc(incoming, invalidRequestIDRune) {
+		return incoming
+	}
+	return rand.Text()
+}
+
+func invalidRequestIDRune(r rune) bool {
+	return r <= ' ' || r > '~'
+}
+
+// shortURLMessage — gRPC-запрос или ответ, содержащий короткую ссылку
+type shortURLMessage interface {
+	GetShortUrl() string
+}
+
+// UnaryServerInterceptor принимает или назначает x-request-id, возвращает его в заголовках ответа
+// и пишет запись о каждом gRPC-вызове, кроме проверок состояния
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if strings.HasPrefix(info.FullMethod, healthServicePrefix) {
+			return handler(ctx, req)
+		}
+		start := time.Now()
+		requestID := incomingRequestID(ctx)
+		grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, requestID)) //nolint:errcheck
+		ctx = ContextWithRequest(ctx, requestID)
+		if m, ok := req.(shortURLMessage); ok {
+			SetShortCode(ctx, m.GetShortUrl())
+		}
+
+		resp, err := handler(ctx, req)
+		if m, ok := resp.(shortURLMessage); ok && m.GetShortUrl() != "" {
+			SetShortCode(ctx, m.GetShortUrl())
+		}
+		logCall(ctx, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor делает для потоковых gRPC-вызовов то же, что UnaryServerInterceptor:
+// запись пишется после завершения потока
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if strings.HasPrefix(info.FullMethod, healthServicePrefix) {
+			return handler(srv, ss)
+		}
+		start := time.Now()
+		requestID := incomingRequestID(ss.Context())
+		ss.SetHeader(metadata.Pairs(RequestIDHeader, requestID)) //nolint:errcheck
+		ctx := ContextWithRequest(ss.Context(), requestID)
+
+		err := handler(srv, &requestStream{ServerStream: ss, ctx: ctx})
+		logCall(ctx, info.FullMethod, start, err)
+		return err
+	}
+}
+
+// requestStream подменяет контекст потока контекстом с логгером запроса
+type requestStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *requestStream) Context() context.Context {
+	return s.ctx
+}
+
+// incomingRequestID возвращает идентификатор запроса из метаданных gRPC или новый
+func incomingRequestID(ctx context.Context) string {
+	var incoming string
+	if values := metadata.ValueFromIncomingContext(ctx, RequestIDHeader); len(values) > 0 {
+		incoming = values[0]
+	}
+	return RequestID(incoming)
+}
+
+// logCall пишет итоговую запись о gRPC-вызове; сбои сервера пишутся с уровнем ERROR и текстом ошибки
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	code := status.Code(err)
+	attrs := []any{
+		"method", method,
+		"code", code.String(),
+		"latency", time.Since(start),
+	}
+	if shortCode := ShortCode(ctx); shortCode != "" {
+		attrs = append(attrs, "short_code", shortCode)
+	}
+	level := slog.LevelInfo
+	switch {
+	case err != nil && serverError(code):
+		level = slog.LevelError
+		attrs = append(attrs, "error", err)
+	case code == codes.Canceled:
+		// Клиент отменил вызов сам, ответ ему уже не нужен
+		level = slog.LevelDebug
+	}
+	FromContext(ctx).Log(ctx, level, "gRPC request", attrs...)
+}
+
+// serverError сообщает, вызвана ли ошибка сбоем сервера, а не запросом клиента
+func serverError(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.Internal, codes.DataLoss, codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"url-shortener/proto"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name        string
+		level       string
+		format      string
+		expectedErr bool
+	}{
+		{name: "JSON", level: "info", format: FormatJSON},
+		{name: "Текст", level: "debug", format: FormatText},
+		{name: "Уровень в верхнем регистре", level: "WARN", format: FormatJSON},
+		{name: "Неизвестный уровень", level: "verbose", format: FormatJSON, expectedErr: true},
+		{name: "Неизвестный формат", level: "info", format: "xml", expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(&bytes.Buffer{}, tt.level, tt.format)
+			if tt.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "Идентификатор от клиента", incoming: "3f0c1a9e-7b2d-4c1e-9a55-2f1d0b8e6c44", keep: true},
+		{name: "Пустой идентификатор"},
+		{name: "Управляющие символы", incoming: "id\r\nlevel=ERROR"},
+		{name: "Слишком длинный идентификатор", incoming: strings.Repeat("a", maxRequestIDLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := RequestID(tt.incoming)
+			if tt.keep {
+				assert.Equal(t, tt.incoming, id)
+				return
+			}
+			assert.NotEmpty(t, id)
+			assert.NotEqual(t, tt.incoming, id)
+		})
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer slog.SetDefault(defaultLogger)
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIDHeader, "req-42"))
+	info := &grpc.UnaryServerInfo{FullMethod: "/proto.URLShortener/GetURL"}
+	_, err := UnaryServerInterceptor()(ctx, &proto.GetURLRequest{ShortUrl: "abc123"}, info, func(ctx context.Context, _ any) (any, error) {
+		// Логгер запроса доступен сервису и хранилищу через контекст
+		FromContext(ctx).Debug("Loading URL")
+		return nil, status.Error(codes.Internal, "connection refused")
+	})
+	assert.Error(t, err)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var inner, record map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &inner))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.Equal(t, "req-42", inner["request_id"])
+	assert.Equal(t, "req-42", record["request_id"])
+	assert.Equal(t, "ERROR", record["level"])
+	assert.Equal(t, info.FullMethod, record["method"])
+	assert.Equal(t, "Internal", record["code"])
+	assert.Equal(t, "abc123", record["short_code"])
+}
+
+// headerStream запоминает заголовки, отправленные интерсептором
+type headerStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (s *headerStream) Context() context.Context { return s.ctx }
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	defer slog.SetDefault(defaultLogger)
+
+	stream := &headerStream{ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIDHeader, "req-7"))}
+	info := &grpc.StreamServerInfo{FullMethod: proto.URLShortener_StreamCreateURLs_FullMethodName}
+	err := StreamServerInterceptor()(nil, stream, info, func(_ any, ss grpc.ServerStream) error {
+		FromContext(ss.Context()).Debug("Creating URLs")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"req-7"}, stream.header.Get(RequestIDHeader))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var inner, record map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &inner))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.Equal(t, "req-7", inner["request_id"])
+	assert.Equal(t, "req-7", record["request_id"])
+	assert.Equal(t, "INFO", record["level"])
+	assert.Equal(t, info.FullMethod, record["method"])
+	assert.Equal(t, "OK", record["code"])
+}
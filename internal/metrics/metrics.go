@@ -0,0 +1,245 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/cache"
+)
+
+const namespace = "url_shortener"
+
+// unmatchedRoute — метка запросов, не подошедших ни к одному маршруту
+const unmatchedRoute = "unmatched"
+
+// Metrics собирает метрики HTTP, gRPC и хранилища и отдаёт их в формате Prometheus
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests    *prometheus.CounterVec
+	httpDuration    *prometheus.HistogramVec
+	grpcRequests    *prometheus.CounterVec
+	grpcDuration    *prometheus.HistogramVec
+	storageDuration *prometheus.HistogramVec
+	storageErrors   *prometheus.CounterVec
+	collisions      prometheus.Counter
+	codeLength      prometheus.Gauge
+	links           prometheus.Gauge
+	occupancy       prometheus.Gauge
+}
+
+// New создаёт метрики в отдельном реестре вместе с метриками процесса и Go runtime
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by route and status.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		grpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "grpc_requests_total",
+			Help:      "Number of gRPC requests by method and status code.",
+		}, []string{"method", "code"}),
+		grpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "grpc_request_duration_seconds",
+			Help:      "gRPC request latency by method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+		storageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "storage_operation_duration_seconds",
+			Help:      "Storage operation latency by backend and operation.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"backend", "operation"}),
+		storageErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "storage_errors_total",
+			Help:      "Number of failed storage operations by backend and operation.",
+		}, []string{"backend", "operation"}),
+		collisions: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "short_url_collisions_total",
+			Help:      "Number of generated short codes that were already taken and retried.",
+		}),
+		codeLength: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "short_code_length",
+			Help:      "Current length of generated short codes, 0 if the generator has no fixed keyspace.",
+		}),
+		links: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "links",
+			Help:      "Number of stored links, including expired links not yet deleted.",
+		}),
+		occupancy: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "keyspace_occupancy_ratio",
+			Help:      "Share of short codes of the current length that are taken.",
+		}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests, m.httpDuration,
+		m.grpcRequests, m.grpcDuration,
+		m.storageDuration, m.storageErrors,
+		m.collisions, m.codeLength, m.links, m.occupancy,
+	)
+	return m
+}
+
+// Handler отдаёт метрики для /metrics
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ShortURLCollision учитывает повтор генерации короткой ссылки из-за занятого кода
+func (m *Metrics) ShortURLCollision() {
+	m.collisions.Inc()
+}
+
+// KeyspaceUsage обновляет длину кодов, число ссылок и долю занятых кодов
+func (m *Metrics) KeyspaceUsage(codeLength int, links int64, occupancy float64) {
+	m.codeLength.Set(float64(codeLength))
+	m.links.Set(float64(links))
+	m.occupancy.Set(occupancy)
+}
+
+// InstrumentHTTP считает запросы к router по шаблону маршрута, например /{shortURL},
+// чтобы число меток не зависело от коротких ссылок
+func (m *Metrics) InstrumentHTTP(router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := unmatchedRoute
+		var match mux.RouteMatch
+		if router.Match(r, &match) && match.Route != nil {
+			if tpl, err := match.Route.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		router.ServeHTTP(sw, r)
+
+		labels := prometheus.Labels{"method": r.Method, "route": route, "status": strconv.Itoa(sw.status)}
+		m.httpRequests.With(labels).Inc()
+		m.httpDuration.With(labels).Observe(time.Since(start).Seconds())
+	})
+}
+
+// statusWriter запоминает код ответа HTTP-обработчика
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// UnaryServerInterceptor считает gRPC-запросы по методу и коду ответа
+func (m *Metrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		labels := prometheus.Labels{"method": info.FullMethod, "code": status.Code(err).String()}
+		m.grpcRequests.With(labels).Inc()
+		m.grpcDuration.With(labels).Observe(time.Since(start).Seconds())
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor считает потоковые gRPC-вызовы по методу и коду ответа,
+// длительность измеряется до завершения потока
+func (m *Metrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		labels := prometheus.Labels{"method": info.FullMethod, "code": status.Code(err).String()}
+		m.grpcRequests.With(labels).Inc()
+		m.grpcDuration.With(labels).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// RegisterCache добавляет счётчики попаданий и промахов кэша ссылок.
+// Доля попаданий: rate(hits) / (rate(hits) + rate(misses))
+func (m *Metrics) RegisterCache(c *cache.Cache) {
+	m.registry.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_hits_total",
+			Help:      "Number of link lookups served from the cache, including cached misses.",
+		}, func() float64 { return float64(c.Stats().Hits) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_misses_total",
+			Help:      "Number of link lookups that went to the storage.",
+		}, func() float64 { return float64(c.Stats().Misses) }),
+	)
+}
+
+// Storage измеряет время и ошибки Save и Get хранилища backend.
+// Штатные ответы хранилища (storage.IsExpected) ошибками не считаются
+type Storage struct {
+	storage.Storage
+	metrics *Metrics
+	backend string
+}
+
+// WrapStorage оборачивает хранилище next метриками с меткой backend
+func (m *Metrics) WrapStorage(next storage.Storage, backend string) *Storage {
+	return &Storage{Storage: next, metrics: m, backend: backend}
+}
+
+// Save сохраняет ссылку в хранилище с учётом времени и ошибок
+func (s *Storage) Save(ctx context.Context, url storage.URL) (string, error) {
+	start := time.Now()
+	shortURL, err := s.Storage.Save(ctx, url)
+	s.observe("save", start, err)
+	return shortURL, err
+}
+
+// Get возвращает ссылку из хранилища с учётом времени и ошибок
+func (s *Storage) Get(ctx context.Context, shortURL string) (storage.URL, error) {
+	start := time.Now()
+	url, err := s.Storage.Get(ctx, shortURL)
+	s.observe("get", start, err)
+	return url, err
+}
+
+func (s *Storage) observe(operation string, start time.Time, err error) {
+	s.metrics.storageDuration.WithLabelValues(s.backend, operation).Observe(time.Since(start).Seconds())
+	if err != nil && !storage.IsExpected(err) {
+		s.metrics.storageErrors.WithLabelValues(s.backend, operation).Inc()
+	}
+}
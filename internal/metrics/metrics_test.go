@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/cache"
+	"url-shortener/internal/storage/memory"
+)
+
+func TestMetrics_InstrumentHTTP(t *testing.T) {
+	m := New()
+	r := mux.NewRouter()
+	r.HandleFunc("/{shortURL}", func(w http.ResponseWriter, r *http.Request) {
+		if mux.Vars(r)["shortURL"] == "missing" {
+			http.NotFound(w, r)
+			return
+		}
+		http.Redirect(w, r, "https://example.com", http.StatusFound)
+	}).Methods("GET")
+	handler := m.InstrumentHTTP(r)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		route  string
+		status string
+	}{
+		{name: "Редирект", method: http.MethodGet, path: "/abc123", route: "/{shortURL}", status: "302"},
+		{name: "Ссылка не найдена", method: http.MethodGet, path: "/missing", route: "/{shortURL}", status: "404"},
+		{name: "Неизвестный маршрут", method: http.MethodGet, path: "/a/b/c", route: unmatchedRoute, status: "404"},
+		{name: "Неподдерживаемый метод", method: http.MethodPut, path: "/abc123", route: unmatchedRoute, status: "405"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, 1.0, testutil.ToFloat64(m.httpRequests.WithLabelValues(tt.method, tt.route, tt.status)))
+		})
+	}
+}
+
+func TestMetrics_UnaryServerInterceptor(t *testing.T) {
+	m := New()
+	interceptor := m.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/proto.URLShortener/GetURL"}
+
+	_, err := interceptor(context.Background(), nil, info, func(context.Context, any) (any, error) {
+		return nil, status.Error(codes.NotFound, "not found")
+	})
+	assert.Error(t, err)
+	_, err = interceptor(context.Background(), nil, info, func(context.Context, any) (any, error) {
+		return "ok", nil
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.grpcRequests.WithLabelValues(info.FullMethod, "NotFound")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.grpcRequests.WithLabelValues(info.FullMethod, "OK")))
+}
+
+func TestMetrics_StreamServerInterceptor(t *testing.T) {
+	m := New()
+	info := &grpc.StreamServerInfo{FullMethod: "/proto.URLShortener/StreamCreateURLs"}
+
+	err := m.StreamServerInterceptor()(nil, nil, info, func(any, grpc.ServerStream) error {
+		return status.Error(codes.ResourceExhausted, "rate limit exceeded")
+	})
+	assert.Error(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.grpcRequests.WithLabelValues(info.FullMethod, "ResourceExhausted")))
+}
+
+// failingStorage возвращает ошибку на Get
+type failingStorage struct {
+	storage.Storage
+}
+
+func (failingStorage) Get(context.Context, string) (storage.URL, error) {
+	return storage.URL{}, errors.New("connection refused")
+}
+
+func TestStorage_Errors(t *testing.T) {
+	m := New()
+	s := m.WrapStorage(memory.NewMemory(), "memory")
+	ctx := context.Background()
+
+	_, err := s.Save(ctx, storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"})
+	require.NoError(t, err)
+	_, err = s.Save(ctx, storage.URL{ShortURL: "abc123", OriginalURL: "https://other.com"})
+	assert.ErrorIs(t, err, storage.ErrShortURLConflict)
+	_, err = s.Get(ctx, "missing")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	// Штатные ответы хранилища не считаются сбоями
+	assert.Equal(t, 0, testutil.CollectAndCount(m.storageErrors))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.storageDuration))
+
+	_, err = m.WrapStorage(failingStorage{}, "redis").Get(ctx, "abc123")
+	assert.Error(t, err)
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.storageErrors.WithLabelValues("redis", "get")))
+}
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	c := cache.New(memory.NewMemory(), cache.Config{Size: 10, TTL: time.Minute, NegativeTTL: time.Minute})
+	m.RegisterCache(c)
+	_, err := c.Get(context.Background(), "missing")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, err = c.Get(context.Background(), "missing")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	m.ShortURLCollision()
+	m.KeyspaceUsage(10, 42, 0.5)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, "url_shortener_cache_hits_total 1")
+	assert.Contains(t, body, "url_shortener_cache_misses_total 1")
+	assert.Contains(t, body, "url_shortener_short_url_collisions_total 1")
+	assert.Contains(t, body, "url_shortener_short_code_length 10")
+	assert.Contains(t, body, "url_shortener_links 42")
+	assert.Contains(t, body, "url_shortener_keyspace_occupancy_ratio 0.5")
+	assert.Contains(t, body, "go_goroutines")
+}
@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"url-shortener/proto"
+)
+
+// errorDomain — домен ошибок в errdetails.ErrorInfo, общий с ошибками сервиса
+const errorDomain = "url-shortener"
+
+// grpcOperations сопоставляет gRPC-методы с бюджетами. Остальные методы не ограничиваются
+var grpcOperations = map[string]Operation{
+	proto.URLShortener_CreateURL_FullMethodName:        Create,
+	proto.URLShortener_BatchCreateURLs_FullMethodName:  Create,
+	proto.URLShortener_StreamCreateURLs_FullMethodName: Create,
+	proto.URLShortener_GetURL_FullMethodName:           Resolve,
+	proto.URLShortener_GetURLInfo_FullMethodName:       Resolve,
+	proto.URLShortener_GetStats_FullMethodName:         Resolve,
+	proto.URLShortener_BatchGetURLs_FullMethodName:     Resolve,
+	proto.URLShortener_ListURLs_FullMethodName:         Resolve,
+}
+
+// cost возвращает число токенов запроса: по одному на каждую ссылку пакета
+func cost(req any) int {
+	switch r := req.(type) {
+	case *proto.BatchCreateURLsRequest:
+		return max(len(r.GetUrls()), 1)
+	case *proto.BatchGetURLsRequest:
+		return max(len(r.GetShortUrls()), 1)
+	}
+	return 1
+}
+
+// UnaryServerInterceptor отклоняет вызовы клиента, исчерпавшего бюджет, со статусом ResourceExhausted
+func (l *Limiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if op, ok := grpcOperations[info.FullMethod]; ok {
+			if err := l.Allow(ctx, op, Client(ctx, peerIP(ctx)), cost(req)); err != nil {
+				return nil, statusError(err)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor списывает по токену на каждое сообщение потока
+// и прерывает поток со статусом ResourceExhausted, когда бюджет исчерпан
+func (l *Limiter) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		op, ok := grpcOperations[info.FullMethod]
+		if !ok {
+			return handler(srv, ss)
+		}
+		return handler(srv, &limitedStream{ServerStream: ss, limiter: l, op: op})
+	}
+}
+
+// limitedStream проверяет бюджет перед передачей каждого полученного сообщения обработчику
+type limitedStream struct {
+	grpc.ServerStream
+	limiter *Limiter
+	op      Operation
+}
+
+func (s *limitedStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	ctx := s.Context()
+	if err := s.limiter.Allow(ctx, s.op, Client(ctx, peerIP(ctx)), 1); err != nil {
+		return statusError(err)
+	}
+	return nil
+}
+
+// statusError преобразует *LimitError в статус ResourceExhausted с errdetails.RetryInfo
+func statusError(err error) error {
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) {
+		return err
+	}
+	st := status.New(codes.ResourceExhausted, err.Error())
+	if withDetails, detailsErr := st.WithDetails(
+		&errdetails.ErrorInfo{Reason: "RATE_LIMITED", Domain: errorDomain},
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(limitErr.RetryAfter)},
+	); detailsErr == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// peerIP возвращает IP-адрес клиента gRPC без порта
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
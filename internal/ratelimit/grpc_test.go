@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"url-shortener/internal/storage"
+	"url-shortener/proto"
+)
+
+func TestLimiter_UnaryServerInterceptor(t *testing.T) {
+	l := New(NewMemory(), Config{
+		Create:  storage.TokenBucket{Rate: 1, Burst: 2},
+		Resolve: storage.TokenBucket{Rate: 1, Burst: 1},
+	})
+	interceptor := l.UnaryServerInterceptor()
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5000}})
+	ok := func(context.Context, any) (any, error) { return "ok", nil }
+	call := func(method string, req any) error {
+		_, err := interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, ok)
+		return err
+	}
+
+	require.NoError(t, call(proto.URLShortener_GetURL_FullMethodName, &proto.GetURLRequest{}))
+	err := call(proto.URLShortener_GetURL_FullMethodName, &proto.GetURLRequest{})
+	st := status.Convert(err)
+	assert.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Len(t, st.Details(), 2)
+	retryInfo, isRetryInfo := st.Details()[1].(*errdetails.RetryInfo)
+	require.True(t, isRetryInfo)
+	assert.Positive(t, retryInfo.GetRetryDelay().AsDuration())
+
+	// Пакет списывает токен за каждую ссылку
+	batch := &proto.BatchCreateURLsRequest{Urls: []*proto.CreateURLRequest{{}, {}}}
+	require.NoError(t, call(proto.URLShortener_BatchCreateURLs_FullMethodName, batch))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(call(proto.URLShortener_CreateURL_FullMethodName, &proto.CreateURLRequest{})))
+
+	// Методы без бюджета не ограничиваются
+	assert.NoError(t, call(proto.URLShortener_DeleteURL_FullMethodName, &proto.DeleteURLRequest{}))
+}
+
+// recvStream отдаёт обработчику бесконечный поток пустых сообщений
+type recvStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s recvStream) Context() context.Context { return s.ctx }
+
+func (s recvStream) RecvMsg(any) error { return nil }
+
+func TestLimiter_StreamServerInterceptor(t *testing.T) {
+	l := New(NewMemory(), Config{Create: storage.TokenBucket{Rate: 0.001, Burst: 3}})
+	now := time.Now()
+	l.now = func() time.Time { return now }
+	info := &grpc.StreamServerInfo{FullMethod: proto.URLShortener_StreamCreateURLs_FullMethodName}
+
+	var received int
+	err := l.StreamServerInterceptor()(nil, recvStream{ctx: context.Background()}, info, func(_ any, stream grpc.ServerStream) error {
+		for {
+			if err := stream.RecvMsg(&proto.CreateURLRequest{}); err != nil {
+				return err
+			}
+			received++
+		}
+	})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, 3, received)
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"url-shortener/internal/storage"
+)
+
+// sweepInterval — период удаления заполненных корзин из памяти
+const sweepInterval = time.Minute
+
+// bucketState — состояние корзины: число токенов в момент updated и момент, когда корзина снова заполнится
+type bucketState struct {
+	tokens  float64
+	updated time.Time
+	fullAt  time.Time
+}
+
+// Memory хранит корзины токенов в памяти процесса. Подходит для одного экземпляра сервиса
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]bucketState
+	lastSweep time.Time
+}
+
+// NewMemory создаёт пустое хранилище корзин
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]bucketState)}
+}
+
+// TakeTokens списывает n токенов из корзины key к моменту now
+func (m *Memory) TakeTokens(_ context.Context, key string, bucket storage.TokenBucket, n int, now time.Time) (bool, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep(now)
+
+	tokens := float64(bucket.Burst)
+	if state, ok := m.buckets[key]; ok {
+		tokens = bucket.Refill(state.tokens, state.updated, now)
+	}
+	need := bucket.Need(n)
+	if tokens < need {
+		return false, bucket.Wait(tokens, need), nil
+	}
+	tokens -= float64(n)
+	m.buckets[key] = bucketState{
+		tokens:  tokens,
+		updated: now,
+		fullAt:  now.Add(bucket.Wait(tokens, float64(bucket.Burst))),
+	}
+	return true, 0, nil
+}
+
+// sweep раз в sweepInterval удаляет корзины, которые к моменту now заполнились:
+// они не отличаются от отсутствующих
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for key, state := range m.buckets {
+		if !state.fullAt.After(now) {
+			delete(m.buckets, key)
+		}
+	}
+}
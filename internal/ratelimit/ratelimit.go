@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"url-shortener/internal/logging"
+	"url-shortener/internal/storage"
+)
+
+// Operation — группа запросов с отдельным бюджетом
+type Operation string
+
+const (
+	// Create — создание коротких ссылок
+	Create Operation = "create"
+	// Resolve — запросы по короткой ссылке: редирект, описание и статистика
+	Resolve Operation = "resolve"
+)
+
+// Config задаёт бюджеты одного клиента. Корзина с нулевой скоростью отключает ограничение операции
+type Config struct {
+	Create  storage.TokenBucket
+	Resolve storage.TokenBucket
+}
+
+// LimitError возвращается, когда клиент исчерпал бюджет операции
+type LimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded, retry after %s", e.RetryAfter)
+}
+
+// RetryAfterSeconds возвращает время ожидания в целых секундах для заголовка Retry-After, не меньше 1
+func (e *LimitError) RetryAfterSeconds() int64 {
+	return max(int64((e.RetryAfter+time.Second-1)/time.Second), 1)
+}
+
+// Limiter ограничивает частоту запросов клиентов по алгоритму корзины токенов
+type Limiter struct {
+	store   storage.RateLimitStorage
+	buckets map[Operation]storage.TokenBucket
+	now     func() time.Time
+}
+
+// New создаёт ограничитель, хранящий корзины в store: NewMemory для одного экземпляра сервиса
+// или хранилище ссылок, общее для нескольких экземпляров
+func New(store storage.RateLimitStorage, cfg Config) *Limiter {
+	return &Limiter{
+		store: store,
+		buckets: map[Operation]storage.TokenBucket{
+			Create:  cfg.Create,
+			Resolve: cfg.Resolve,
+		},
+		now: time.Now,
+	}
+}
+
+// Allow списывает n токенов из бюджета операции op клиента client и возвращает *LimitError,
+// если их не хватает. Сбой хранилища корзин не блокирует запросы: ошибка пишется в лог
+func (l *Limiter) Allow(ctx context.Context, op Operation, client string, n int) error {
+	bucket := l.buckets[op]
+	if bucket.Rate <= 0 || n <= 0 {
+		return nil
+	}
+	ok, retryAfter, err := l.store.TakeTokens(ctx, string(op)+":"+client, bucket, n, l.now())
+	if err != nil {
+		logging.FromContext(ctx).Error("Failed to check rate limit", "operation", op, "error", err)
+		return nil
+	}
+	if !ok {
+		return &LimitError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+type clientKey struct{}
+
+// ContextWithClient привязывает к контексту идентификатор аутентифицированного клиента,
+// например API-ключа. Запросы такого клиента учитываются по нему, а не по IP-адресу
+func ContextWithClient(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, clientKey{}, id)
+}
+
+// Client возвращает ключ корзины клиента: идентификатор из ContextWithClient или IP-адрес
+func Client(ctx context.Context, ip string) string {
+	if id, ok := ctx.Value(clientKey{}).(string); ok && id != "" {
+		return "client:" + id
+	}
+	return "ip:" + ip
+}
@@ -0,0 +1,141 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"url-shortener/internal/storage"
+)
+
+func TestMemory_TakeTokens(t *testing.T) {
+	bucket := storage.TokenBucket{Rate: 2, Burst: 3}
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	type take struct {
+		n          int
+		at         time.Duration // смещение от start
+		ok         bool
+		retryAfter time.Duration
+	}
+	tests := []struct {
+		name  string
+		takes []take
+	}{
+		{
+			name: "Запас корзины и пополнение",
+			takes: []take{
+				{n: 1, ok: true},
+				{n: 1, ok: true},
+				{n: 1, ok: true},
+				{n: 1, ok: false, retryAfter: 500 * time.Millisecond},
+				{n: 1, at: 500 * time.Millisecond, ok: true},
+			},
+		},
+		{
+			name: "Корзина не переполняется",
+			takes: []take{
+				{n: 3, ok: true},
+				{n: 3, at: time.Hour, ok: true},
+				{n: 1, at: time.Hour, ok: false, retryAfter: 500 * time.Millisecond},
+			},
+		},
+		{
+			name: "Пакет больше ёмкости уходит в долг",
+			takes: []take{
+				{n: 5, ok: true},
+				{n: 1, ok: false, retryAfter: 1500 * time.Millisecond},
+				{n: 1, at: 1500 * time.Millisecond, ok: true},
+			},
+		},
+		{
+			name: "Пакет больше ёмкости требует полной корзины",
+			takes: []take{
+				{n: 1, ok: true},
+				{n: 5, ok: false, retryAfter: 500 * time.Millisecond},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemory()
+			for i, take := range tt.takes {
+				ok, retryAfter, err := m.TakeTokens(context.Background(), "client", bucket, take.n, start.Add(take.at))
+				require.NoError(t, err)
+				assert.Equal(t, take.ok, ok, "запрос %d", i)
+				assert.Equal(t, take.retryAfter, retryAfter, "запрос %d", i)
+			}
+		})
+	}
+}
+
+func TestMemory_Sweep(t *testing.T) {
+	m := NewMemory()
+	bucket := storage.TokenBucket{Rate: 1, Burst: 100}
+	now := time.Now()
+	for key, n := range map[string]int{"idle": 1, "busy": 100} {
+		_, _, err := m.TakeTokens(context.Background(), key, bucket, n, now)
+		require.NoError(t, err)
+	}
+	_, _, err := m.TakeTokens(context.Background(), "new", bucket, 1, now.Add(sweepInterval))
+	require.NoError(t, err)
+
+	// Заполнившаяся корзина удалена, израсходованная осталась
+	assert.NotContains(t, m.buckets, "idle")
+	assert.Contains(t, m.buckets, "busy")
+	assert.Contains(t, m.buckets, "new")
+}
+
+// failingStore возвращает ошибку на каждый запрос
+type failingStore struct{}
+
+func (failingStore) TakeTokens(context.Context, string, storage.TokenBucket, int, time.Time) (bool, time.Duration, error) {
+	return false, 0, errors.New("connection refused")
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	l := New(NewMemory(), Config{Create: storage.TokenBucket{Rate: 1, Burst: 1}})
+	now := time.Now()
+	l.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	require.NoError(t, l.Allow(ctx, Create, Client(ctx, "10.0.0.1"), 1))
+	err := l.Allow(ctx, Create, Client(ctx, "10.0.0.1"), 1)
+	var limitErr *LimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, time.Second, limitErr.RetryAfter)
+	assert.Equal(t, int64(1), limitErr.RetryAfterSeconds())
+
+	// Бюджеты клиентов и операций независимы
+	assert.NoError(t, l.Allow(ctx, Create, Client(ctx, "10.0.0.2"), 1))
+	assert.NoError(t, l.Allow(ContextWithClient(ctx, "key-1"), Create, Client(ContextWithClient(ctx, "key-1"), "10.0.0.1"), 1))
+	for range 10 {
+		assert.NoError(t, l.Allow(ctx, Resolve, Client(ctx, "10.0.0.1"), 1), "ограничение resolve отключено")
+	}
+
+	// Сбой хранилища корзин не блокирует запросы
+	assert.NoError(t, New(failingStore{}, Config{Create: storage.TokenBucket{Rate: 1, Burst: 1}}).Allow(ctx, Create, "ip:10.0.0.1", 1))
+}
+
+func TestLimitError_RetryAfterSeconds(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter time.Duration
+		expected   int64
+	}{
+		{name: "Меньше секунды", retryAfter: 10 * time.Millisecond, expected: 1},
+		{name: "Округление вверх", retryAfter: 1500 * time.Millisecond, expected: 2},
+		{name: "Целые секунды", retryAfter: 3 * time.Second, expected: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, (&LimitError{RetryAfter: tt.retryAfter}).RetryAfterSeconds())
+		})
+	}
+}
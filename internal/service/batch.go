@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"url-shortener/internal/logging"
+	"url-shortener/internal/storage"
+	"url-shortener/proto"
+)
+
+const (
+	// maxBatchSize ограничивает число элементов пакетного запроса; большие объёмы передаются через StreamCreateURLs
+	maxBatchSize = 10000
+	// streamChunkSize — число URL из потока, сохраняемых в хранилище за один вызов SaveMany
+	streamChunkSize = 1000
+)
+
+// BatchCreateURLs реализует gRPC-метод для сокращения пакета URL
+func (s *Service) BatchCreateURLs(ctx context.Context, req *proto.BatchCreateURLsRequest) (*proto.BatchCreateURLsResponse, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if len(req.GetUrls()) > maxBatchSize {
+		return nil, toStatusError(ErrBatchTooLarge, "")
+	}
+	results, err := s.createMany(ctx, req.GetUrls())
+	if err != nil {
+		return nil, toStatusError(err, "")
+	}
+	return &proto.BatchCreateURLsResponse{Results: results}, nil
+}
+
+// StreamCreateURLs реализует gRPC-метод для сокращения потока URL.
+// URL сохраняются частями по streamChunkSize, таймаут запроса действует на каждую часть.
+// Результаты части отправляются сразу после её сохранения, поэтому память сервера и размер
+// ответа не зависят от длины потока
+func (s *Service) StreamCreateURLs(stream proto.URLShortener_StreamCreateURLsServer) error {
+	pending := make([]*proto.CreateURLRequest, 0, streamChunkSize)
+	flush := func() error {
+		ctx, cancel := s.withTimeout(stream.Context())
+		defer cancel()
+
+		results, err := s.createMany(ctx, pending)
+		if err != nil {
+			return toStatusError(err, "")
+		}
+		pending = pending[:0]
+		return stream.Send(&proto.BatchCreateURLsResponse{Results: results})
+	}
+
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		pending = append(pending, req)
+		if len(pending) == streamChunkSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if len(pending) > 0 {
+		return flush()
+	}
+	return nil
+}
+
+// BatchGetURLs реализует gRPC-метод для получения пакета оригинальных URL.
+// Переходы в статистику не записываются
+func (s *Service) BatchGetURLs(ctx context.Context, req *proto.BatchGetURLsRequest) (*proto.BatchGetURLsResponse, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	shortURLs := req.GetShortUrls()
+	if len(shortURLs) > maxBatchSize {
+		return nil, toStatusError(ErrBatchTooLarge, "")
+	}
+	found, err := s.storage.GetMany(ctx, shortURLs)
+	if err != nil {
+		return nil, toStatusError(err, "")
+	}
+
+	resp := &proto.BatchGetURLsResponse{Results: make([]*proto.BatchGetURLResult, len(found))}
+	for i, res := range found {
+		result := &proto.BatchGetURLResult{ShortUrl: shortURLs[i]}
+		if res.Err != nil {
+			result.Error = itemError(ctx, res.Err)
+		} else {
+			result.OriginalUrl = res.URL.OriginalURL
+			result.RedirectStatus = res.URL.RedirectStatus
+		}
+		resp.Results[i] = result
+	}
+	return resp, nil
+}
+
+// createMany сокращает пакет URL. Ошибки проверки и занятые алиасы возвращаются для каждого URL отдельно,
+// для URL без алиаса при конфликте генерируется новый код. Общая ошибка означает сбой хранилища
+func (s *Service) createMany(ctx context.Context, reqs []*proto.CreateURLRequest) ([]*proto.BatchCreateURLResult, error) {
+	owner, err := s.creator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	results := make([]*proto.BatchCreateURLResult, len(reqs))
+	urls := make([]storage.URL, 0, len(reqs))
+	positions := make([]int, 0, len(reqs)) // индекс запроса для каждой ссылки из urls
+	for i, req := range reqs {
+		url, err := s.urlFromRequest(req, owner, now)
+		if err != nil {
+			results[i] = &proto.BatchCreateURLResult{OriginalUrl: req.GetOriginalUrl(), Error: itemError(ctx, err)}
+			continue
+		}
+		urls = append(urls, url)
+		positions = append(positions, i)
+	}
+
+	length := s.codeLength()
+	for attempt := 0; len(urls) > 0; attempt++ {
+		if attempt == maxCodeAttempts {
+			logging.FromContext(ctx).Warn("No free short code found", "attempts", maxCodeAttempts, "urls", len(urls))
+			for j, i := range positions {
+				results[i] = &proto.BatchCreateURLResult{OriginalUrl: urls[j].OriginalURL, Error: itemError(ctx, ErrCodesExhausted)}
+			}
+			break
+		}
+		for j := range urls {
+			if reqs[positions[j]].GetCustomAlias() != "" {
+				continue
+			}
+			shortURL, err := s.codes.Generate(urls[j], attempt)
+			if err != nil {
+				return nil, err
+			}
+			urls[j].ShortURL = shortURL
+		}
+		saved, err := s.storage.SaveMany(ctx, urls)
+		if err != nil {
+			return nil, err
+		}
+
+		var retryURLs []storage.URL
+		var retryPositions []int
+		for j, res := range saved {
+			i := positions[j]
+			switch {
+			case res.Err == nil && reqs[i].GetCustomAlias() != "" && res.URL.ShortURL != urls[j].ShortURL:
+				// оригинальный URL уже сокращён под другим кодом — алиас не создан
+				results[i] = &proto.BatchCreateURLResult{OriginalUrl: urls[j].OriginalURL, Error: itemError(ctx, storage.ErrOriginalURLExists)}
+			case res.Err == nil:
+				results[i] = &proto.BatchCreateURLResult{
+					ShortUrl:    res.URL.ShortURL,
+					OriginalUrl: res.URL.OriginalURL,
+					CreatedAt:   timestamppb.New(res.URL.CreatedAt),
+				}
+			case errors.Is(res.Err, storage.ErrShortURLConflict) && reqs[i].GetCustomAlias() == "":
+				// сгенерированный код уже занят — повторить с новым кодом
+				s.codeCollision(ctx, length, attempt)
+				retryURLs = append(retryURLs, urls[j])
+				retryPositions = append(retryPositions, i)
+			case errors.Is(res.Err, storage.ErrShortURLConflict):
+				results[i] = &proto.BatchCreateURLResult{OriginalUrl: urls[j].OriginalURL, Error: itemError(ctx, ErrAliasTaken)}
+			default:
+				results[i] = &proto.BatchCreateURLResult{OriginalUrl: urls[j].OriginalURL, Error: itemError(ctx, res.Err)}
+			}
+		}
+		urls, positions = retryURLs, retryPositions
+	}
+	return results, nil
+}
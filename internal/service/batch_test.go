@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"url-shortener/internal/storage"
+	"url-shortener/proto"
+)
+
+func TestService_BatchCreateURLs(t *testing.T) {
+	fakeStorage := NewFakeStorage()
+	fakeStorage.storage["abc123"] = "https://example.com"
+	fakeStorage.storage["spring-sale"] = "https://example.com/other"
+	// Первый сгенерированный код окажется занят, и его нужно сгенерировать заново
+	fakeStorage.err = storage.ErrShortURLConflict
+	s := NewService(fakeStorage)
+
+	resp, err := s.BatchCreateURLs(context.Background(), &proto.BatchCreateURLsRequest{
+		Urls: []*proto.CreateURLRequest{
+			{OriginalUrl: "https://example.com/new"},
+			{OriginalUrl: "HTTPS://Example.com"},
+			{OriginalUrl: "javascript:alert(1)"},
+			{OriginalUrl: "https://example.com/sale", CustomAlias: "spring-sale"},
+			{OriginalUrl: "https://example.com/alias", CustomAlias: "my-alias"},
+			{OriginalUrl: "https://example.com", CustomAlias: "home-page"},
+		},
+	})
+	assert.NoError(t, err)
+	if !assert.Len(t, resp.Results, 6) {
+		return
+	}
+
+	assert.Nil(t, resp.Results[0].Error)
+	assert.Len(t, resp.Results[0].ShortUrl, shortURLLength)
+	assert.Equal(t, "https://example.com/new", fakeStorage.storage[resp.Results[0].ShortUrl])
+
+	assert.Nil(t, resp.Results[1].Error)
+	assert.Equal(t, "abc123", resp.Results[1].ShortUrl)
+
+	if assert.NotNil(t, resp.Results[2].Error) {
+		assert.Equal(t, int32(codes.InvalidArgument), resp.Results[2].Error.Code)
+		assert.Equal(t, "INVALID_URL", resp.Results[2].Error.Reason)
+	}
+
+	if assert.NotNil(t, resp.Results[3].Error) {
+		assert.Equal(t, int32(codes.AlreadyExists), resp.Results[3].Error.Code)
+		assert.Equal(t, "ALIAS_TAKEN", resp.Results[3].Error.Reason)
+	}
+
+	assert.Nil(t, resp.Results[4].Error)
+	assert.Equal(t, "my-alias", resp.Results[4].ShortUrl)
+	assert.NotNil(t, resp.Results[4].CreatedAt)
+
+	// URL уже сокращён под кодом abc123, поэтому алиас не создаётся
+	if assert.NotNil(t, resp.Results[5].Error) {
+		assert.Equal(t, int32(codes.AlreadyExists), resp.Results[5].Error.Code)
+		assert.Equal(t, "ORIGINAL_URL_EXISTS", resp.Results[5].Error.Reason)
+	}
+	assert.Empty(t, resp.Results[5].ShortUrl)
+	assert.NotContains(t, fakeStorage.storage, "home-page")
+}
+
+func TestService_BatchCreateURLsTooLarge(t *testing.T) {
+	s := NewService(NewFakeStorage())
+	urls := make([]*proto.CreateURLRequest, maxBatchSize+1)
+	for i := range urls {
+		urls[i] = &proto.CreateURLRequest{OriginalUrl: "https://example.com"}
+	}
+
+	_, err := s.BatchCreateURLs(context.Background(), &proto.BatchCreateURLsRequest{Urls: urls})
+	assert.ErrorIs(t, err, ErrBatchTooLarge)
+}
+
+func TestService_BatchGetURLs(t *testing.T) {
+	fakeStorage := NewFakeStorage()
+	fakeStorage.storage["abc123"] = "https://example.com"
+	fakeStorage.redirects["abc123"] = 301
+	s := NewService(fakeStorage)
+
+	resp, err := s.BatchGetURLs(context.Background(), &proto.BatchGetURLsRequest{
+		ShortUrls: []string{"abc123", "xyz789"},
+	})
+	assert.NoError(t, err)
+	if !assert.Len(t, resp.Results, 2) {
+		return
+	}
+	assert.Equal(t, "https://example.com", resp.Results[0].OriginalUrl)
+	assert.Equal(t, int32(301), resp.Results[0].RedirectStatus)
+	assert.Nil(t, resp.Results[0].Error)
+	assert.Equal(t, "xyz789", resp.Results[1].ShortUrl)
+	if assert.NotNil(t, resp.Results[1].Error) {
+		assert.Equal(t, int32(codes.NotFound), resp.Results[1].Error.Code)
+	}
+}
+
+// fakeCreateStream — поддельный двунаправленный поток запросов на сокращение URL
+type fakeCreateStream struct {
+	grpc.ServerStream
+	reqs  []*proto.CreateURLRequest
+	resps []*proto.BatchCreateURLsResponse
+}
+
+func (f *fakeCreateStream) Context() context.Context {
+	return context.Background()
+}
+
+func (f *fakeCreateStream) Recv() (*proto.CreateURLRequest, error) {
+	if len(f.reqs) == 0 {
+		return nil, io.EOF
+	}
+	req := f.reqs[0]
+	f.reqs = f.reqs[1:]
+	return req, nil
+}
+
+func (f *fakeCreateStream) Send(resp *proto.BatchCreateURLsResponse) error {
+	f.resps = append(f.resps, resp)
+	return nil
+}
+
+func TestService_StreamCreateURLs(t *testing.T) {
+	fakeStorage := NewFakeStorage()
+	s := NewService(fakeStorage)
+
+	// Больше одной части, чтобы проверить сохранение по частям
+	stream := &fakeCreateStream{}
+	for i := 0; i < streamChunkSize+10; i++ {
+		stream.reqs = append(stream.reqs, &proto.CreateURLRequest{
+			OriginalUrl: fmt.Sprintf("https://example.com/%d", i),
+		})
+	}
+	stream.reqs = append(stream.reqs, &proto.CreateURLRequest{OriginalUrl: "/relative"})
+
+	err := s.StreamCreateURLs(stream)
+	assert.NoError(t, err)
+	// Результаты приходят отдельным сообщением на каждую часть
+	if !assert.Len(t, stream.resps, 2) {
+		return
+	}
+	assert.Len(t, stream.resps[0].Results, streamChunkSize)
+	if !assert.Len(t, stream.resps[1].Results, 11) {
+		return
+	}
+	results := append(stream.resps[0].Results, stream.resps[1].Results...)
+	for i, result := range results[:streamChunkSize+10] {
+		assert.Nil(t, result.Error)
+		assert.NotEmpty(t, result.ShortUrl)
+		assert.Equal(t, fmt.Sprintf("https://example.com/%d", i), result.OriginalUrl)
+	}
+	assert.NotNil(t, results[streamChunkSize+10].Error)
+	assert.Len(t, fakeStorage.storage, streamChunkSize+10)
+}
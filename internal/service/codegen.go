@@ -0,0 +1,295 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"url-shortener/internal/storage"
+)
+
+// Стратегии генерации кодов для CodeGeneratorConfig.Strategy
+const (
+	StrategyRandom    = "random"
+	StrategyCounter   = "counter"
+	StrategySnowflake = "snowflake"
+	StrategyHash      = "hash"
+)
+
+const (
+	// base62Chars — алфавит кодов счётчика, Snowflake и хеша
+	base62Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	// maxHashCodeLength — наибольшая длина кода-хеша: по 10 символов base62 из каждых 8 байт SHA-256
+	maxHashCodeLength = 40
+
+	// snowflakeNodeBits и snowflakeSequenceBits — доли идентификатора Snowflake под номер узла и счётчик
+	// внутри миллисекунды; остальные биты занимает время
+	snowflakeNodeBits     = 10
+	snowflakeSequenceBits = 12
+	maxSnowflakeNode      = 1<<snowflakeNodeBits - 1
+	maxSnowflakeSequence  = 1<<snowflakeSequenceBits - 1
+)
+
+// snowflakeEpoch — начало отсчёта времени идентификаторов Snowflake; чем оно позже, тем короче коды
+var snowflakeEpoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ErrInvalidCodeGenerator возвращается для некорректных параметров генератора кодов
+var ErrInvalidCodeGenerator = errors.New("invalid code generator config")
+
+// CodeGenerator создаёт коды коротких ссылок
+type CodeGenerator interface {
+	// Generate возвращает код для ссылки url. attempt — номер попытки после коллизий, начиная с 0:
+	// детерминированные генераторы учитывают его, чтобы после коллизии получить другой код
+	Generate(url storage.URL, attempt int) (string, error)
+}
+
+// SizedGenerator — генератор с конечным числом кодов текущей длины, которую можно увеличить,
+// когда коды начинают часто совпадать с занятыми
+type SizedGenerator interface {
+	CodeGenerator
+	// Length возвращает текущую длину кодов
+	Length() int
+	// Keyspace возвращает число различных кодов текущей длины
+	Keyspace() float64
+	// Grow увеличивает длину кодов с from на единицу. false, если длина уже не from или наибольшая
+	Grow(from int) bool
+}
+
+// CodeGeneratorConfig выбирает стратегию генерации кодов и её параметры
+type CodeGeneratorConfig struct {
+	Strategy string // random, counter, snowflake или hash; пустое значение — random
+	Length   int    // длина кода для random и hash, 0 — DefaultCodeLength
+	Alphabet string // алфавит кодов random, пустое значение — DefaultCodeAlphabet
+	NodeID   int64  // номер экземпляра сервиса для snowflake, от 0 до 1023
+}
+
+// NewCodeGenerator создаёт генератор кодов по конфигурации
+func NewCodeGenerator(cfg CodeGeneratorConfig) (CodeGenerator, error) {
+	if cfg.Length == 0 {
+		cfg.Length = DefaultCodeLength
+	}
+	if cfg.Alphabet == "" {
+		cfg.Alphabet = DefaultCodeAlphabet
+	}
+	switch cfg.Strategy {
+	case "", StrategyRandom:
+		return NewRandomGenerator(cfg.Length, cfg.Alphabet)
+	case StrategyCounter:
+		// Счётчик начинается с текущего времени в микросекундах, чтобы после перезапуска
+		// не повторять уже выданные коды
+		return NewCounterGenerator(uint64(time.Now().UnixMicro())), nil
+	case StrategySnowflake:
+		return NewSnowflakeGenerator(cfg.NodeID)
+	case StrategyHash:
+		return NewHashGenerator(cfg.Length)
+	}
+	return nil, fmt.Errorf("%w: unknown strategy %q", ErrInvalidCodeGenerator, cfg.Strategy)
+}
+
+// RandomGenerator создаёт криптографически случайные коды заданной длины из заданного алфавита
+type RandomGenerator struct {
+	length   atomic.Int64
+	alphabet string
+}
+
+// NewRandomGenerator создаёт генератор случайных кодов. Алфавит — от 2 до 256 различных ASCII-символов
+func NewRandomGenerator(length int, alphabet string) (*RandomGenerator, error) {
+	if length <= 0 {
+		return nil, fmt.Errorf("%w: length must be positive", ErrInvalidCodeGenerator)
+	}
+	if len(alphabet) < 2 || len(alphabet) > 256 {
+		return nil, fmt.Errorf("%w: alphabet must have from 2 to 256 characters", ErrInvalidCodeGenerator)
+	}
+	seen := make(map[rune]bool, len(alphabet))
+	for _, c := range alphabet {
+		if c >= 0x80 || seen[c] {
+			return nil, fmt.Errorf("%w: alphabet must consist of distinct ASCII characters", ErrInvalidCodeGenerator)
+		}
+		seen[c] = true
+	}
+	return newRandomGenerator(length, alphabet), nil
+}
+
+// newRandomGenerator создаёт генератор случайных кодов без проверки параметров
+func newRandomGenerator(length int, alphabet string) *RandomGenerator {
+	g := &RandomGenerator{alphabet: alphabet}
+	g.length.Store(int64(length))
+	return g
+}
+
+// Generate возвращает случайный код. Байты, выходящие за наибольшее кратное длине алфавита число,
+// отбрасываются, чтобы все символы были равновероятны
+func (g *RandomGenerator) Generate(storage.URL, int) (string, error) {
+	length := g.Length()
+	limit := 256 - 256%len(g.alphabet)
+	code := make([]byte, 0, length)
+	buf := make([]byte, length+length/2)
+	for len(code) < length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if int(b) >= limit {
+				continue
+			}
+			code = append(code, g.alphabet[int(b)%len(g.alphabet)])
+			if len(code) == length {
+				break
+			}
+		}
+	}
+	return string(code), nil
+}
+
+// Length возвращает текущую длину кодов
+func (g *RandomGenerator) Length() int {
+	return int(g.length.Load())
+}
+
+// Keyspace возвращает число различных кодов текущей длины
+func (g *RandomGenerator) Keyspace() float64 {
+	return math.Pow(float64(len(g.alphabet)), float64(g.Length()))
+}
+
+// Grow увеличивает длину кодов с from на единицу
+func (g *RandomGenerator) Grow(from int) bool {
+	return g.length.CompareAndSwap(int64(from), int64(from+1))
+}
+
+// CounterGenerator выдаёт коды из монотонно возрастающего счётчика в base62.
+// Коды короткие, но предсказуемые, и их длина растёт вместе со счётчиком
+type CounterGenerator struct {
+	next atomic.Uint64
+}
+
+// NewCounterGenerator создаёт генератор, первый код которого соответствует значению start
+func NewCounterGenerator(start uint64) *CounterGenerator {
+	g := &CounterGenerator{}
+	g.next.Store(start)
+	return g
+}
+
+// Generate возвращает код следующего значения счётчика
+func (g *CounterGenerator) Generate(storage.URL, int) (string, error) {
+	return encodeBase62(g.next.Add(1) - 1), nil
+}
+
+// SnowflakeGenerator выдаёт упорядоченные по времени коды: миллисекунды от snowflakeEpoch, номер узла
+// и счётчик внутри миллисекунды. Экземпляры сервиса с разными номерами узлов не порождают одинаковых кодов
+type SnowflakeGenerator struct {
+	node     int64
+	now      func() time.Time
+	mu       sync.Mutex
+	lastTime int64
+	sequence int64
+}
+
+// NewSnowflakeGenerator создаёт генератор Snowflake для узла node
+func NewSnowflakeGenerator(node int64) (*SnowflakeGenerator, error) {
+	if node < 0 || node > maxSnowflakeNode {
+		return nil, fmt.Errorf("%w: node ID must be between 0 and %d", ErrInvalidCodeGenerator, maxSnowflakeNode)
+	}
+	return &SnowflakeGenerator{node: node, now: time.Now}, nil
+}
+
+// Generate возвращает следующий идентификатор. Если счётчик миллисекунды исчерпан или часы отстали,
+// ожидается следующая миллисекунда
+func (g *SnowflakeGenerator) Generate(storage.URL, int) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := g.now().Sub(snowflakeEpoch).Milliseconds()
+	if ms < 0 {
+		return "", fmt.Errorf("clock is before snowflake epoch %s", snowflakeEpoch.Format(time.RFC3339))
+	}
+	if ms <= g.lastTime {
+		g.sequence++
+		if g.sequence > maxSnowflakeSequence {
+			for ms <= g.lastTime {
+				time.Sleep(time.Millisecond)
+				ms = g.now().Sub(snowflakeEpoch).Milliseconds()
+			}
+			g.sequence = 0
+		} else {
+			ms = g.lastTime
+		}
+	} else {
+		g.sequence = 0
+	}
+	g.lastTime = ms
+
+	id := ms<<(snowflakeNodeBits+snowflakeSequenceBits) | g.node<<snowflakeSequenceBits | g.sequence
+	return encodeBase62(uint64(id)), nil
+}
+
+// HashGenerator выдаёт детерминированные коды из SHA-256 оригинального URL:
+// один и тот же URL на одной и той же попытке всегда получает один и тот же код
+type HashGenerator struct {
+	length atomic.Int64
+}
+
+// NewHashGenerator создаёт генератор кодов-хешей длиной length, не больше maxHashCodeLength символов
+func NewHashGenerator(length int) (*HashGenerator, error) {
+	if length <= 0 || length > maxHashCodeLength {
+		return nil, fmt.Errorf("%w: hash code length must be between 1 and %d", ErrInvalidCodeGenerator, maxHashCodeLength)
+	}
+	g := &HashGenerator{}
+	g.length.Store(int64(length))
+	return g, nil
+}
+
+// Generate возвращает код из хеша оригинального URL. После коллизии к URL добавляется номер попытки
+func (g *HashGenerator) Generate(url storage.URL, attempt int) (string, error) {
+	data := url.OriginalURL
+	if attempt > 0 {
+		data += "#" + strconv.Itoa(attempt)
+	}
+	sum := sha256.Sum256([]byte(data))
+	length := g.Length()
+	code := make([]byte, 0, length)
+	for i := 0; len(code) < length; i += 8 {
+		chunk := encodeBase62(binary.BigEndian.Uint64(sum[i : i+8]))
+		for len(chunk) < 10 {
+			chunk = "0" + chunk
+		}
+		code = append(code, chunk[:min(10, length-len(code))]...)
+	}
+	return string(code), nil
+}
+
+// Length возвращает текущую длину кодов
+func (g *HashGenerator) Length() int {
+	return int(g.length.Load())
+}
+
+// Keyspace возвращает число различных кодов текущей длины
+func (g *HashGenerator) Keyspace() float64 {
+	return math.Pow(62, float64(g.Length()))
+}
+
+// Grow увеличивает длину кодов с from на единицу, но не больше maxHashCodeLength
+func (g *HashGenerator) Grow(from int) bool {
+	return from < maxHashCodeLength && g.length.CompareAndSwap(int64(from), int64(from+1))
+}
+
+// encodeBase62 записывает число в base62 без ведущих нулей
+func encodeBase62(n uint64) string {
+	if n == 0 {
+		return base62Chars[:1]
+	}
+	var buf [11]byte
+	i := len(buf)
+	for n > 0 {
+		i--
+		buf[i] = base62Chars[n%62]
+		n /= 62
+	}
+	return string(buf[i:])
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"url-shortener/internal/storage"
+	"url-shortener/proto"
+)
+
+func TestNewCodeGenerator(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         CodeGeneratorConfig
+		expectedErr bool
+	}{
+		{name: "Случайные коды по умолчанию", cfg: CodeGeneratorConfig{}},
+		{name: "Счётчик", cfg: CodeGeneratorConfig{Strategy: StrategyCounter}},
+		{name: "Snowflake", cfg: CodeGeneratorConfig{Strategy: StrategySnowflake, NodeID: 5}},
+		{name: "Хеш", cfg: CodeGeneratorConfig{Strategy: StrategyHash, Length: 8}},
+		{name: "Неизвестная стратегия", cfg: CodeGeneratorConfig{Strategy: "uuid"}, expectedErr: true},
+		{name: "Отрицательная длина", cfg: CodeGeneratorConfig{Length: -1}, expectedErr: true},
+		{name: "Повтор символа в алфавите", cfg: CodeGeneratorConfig{Alphabet: "abca"}, expectedErr: true},
+		{name: "Алфавит не ASCII", cfg: CodeGeneratorConfig{Alphabet: "абв"}, expectedErr: true},
+		{name: "Номер узла вне диапазона", cfg: CodeGeneratorConfig{Strategy: StrategySnowflake, NodeID: 1024}, expectedErr: true},
+		{name: "Слишком длинный хеш", cfg: CodeGeneratorConfig{Strategy: StrategyHash, Length: 41}, expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewCodeGenerator(tt.cfg)
+			if tt.expectedErr {
+				assert.ErrorIs(t, err, ErrInvalidCodeGenerator)
+				return
+			}
+			require.NoError(t, err)
+			code, err := g.Generate(storage.URL{OriginalURL: "https://example.com"}, 0)
+			assert.NoError(t, err)
+			assert.NotEmpty(t, code)
+		})
+	}
+}
+
+func TestRandomGenerator(t *testing.T) {
+	g, err := NewRandomGenerator(16, "ab")
+	require.NoError(t, err)
+
+	seen := make(map[string]bool)
+	for range 100 {
+		code, err := g.Generate(storage.URL{}, 0)
+		require.NoError(t, err)
+		assert.Len(t, code, 16)
+		assert.Empty(t, strings.Trim(code, "ab"))
+		seen[code] = true
+	}
+	assert.Greater(t, len(seen), 90)
+}
+
+func TestCounterGenerator(t *testing.T) {
+	g := NewCounterGenerator(61)
+	var codes []string
+	for range 3 {
+		code, err := g.Generate(storage.URL{}, 0)
+		require.NoError(t, err)
+		codes = append(codes, code)
+	}
+	assert.Equal(t, []string{"z", "10", "11"}, codes)
+}
+
+func TestSnowflakeGenerator(t *testing.T) {
+	g, err := NewSnowflakeGenerator(3)
+	require.NoError(t, err)
+	now := snowflakeEpoch.Add(time.Second)
+	g.now = func() time.Time { return now }
+
+	first, err := g.Generate(storage.URL{}, 0)
+	require.NoError(t, err)
+	second, err := g.Generate(storage.URL{}, 0)
+	require.NoError(t, err)
+	now = now.Add(time.Millisecond)
+	third, err := g.Generate(storage.URL{}, 0)
+	require.NoError(t, err)
+
+	// 1000 мс, узел 3, счётчик 0 и 1, затем 1001 мс
+	assert.Equal(t, encodeBase62(1000<<22|3<<12), first)
+	assert.Equal(t, encodeBase62(1000<<22|3<<12|1), second)
+	assert.Equal(t, encodeBase62(1001<<22|3<<12), third)
+}
+
+func TestHashGenerator(t *testing.T) {
+	g, err := NewHashGenerator(12)
+	require.NoError(t, err)
+	url := storage.URL{OriginalURL: "https://example.com"}
+
+	first, err := g.Generate(url, 0)
+	require.NoError(t, err)
+	again, err := g.Generate(url, 0)
+	require.NoError(t, err)
+	retry, err := g.Generate(url, 1)
+	require.NoError(t, err)
+	other, err := g.Generate(storage.URL{OriginalURL: "https://example.org"}, 0)
+	require.NoError(t, err)
+
+	assert.Len(t, first, 12)
+	assert.Equal(t, first, again)
+	assert.NotEqual(t, first, retry)
+	assert.NotEqual(t, first, other)
+}
+
+func TestService_WithCodeGenerator(t *testing.T) {
+	fakeStorage := NewFakeStorage()
+	fakeStorage.storage["z"] = "https://example.com/taken"
+	s := NewService(fakeStorage, WithCodeGenerator(NewCounterGenerator(61)))
+
+	// Код z занят, поэтому ссылка получает следующий код счётчика
+	resp, err := s.CreateURL(context.Background(), &proto.CreateURLRequest{OriginalUrl: "https://example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "10", resp.ShortUrl)
+
+	batch, err := s.BatchCreateURLs(context.Background(), &proto.BatchCreateURLsRequest{
+		Urls: []*proto.CreateURLRequest{{OriginalUrl: "https://example.com/a"}, {OriginalUrl: "https://example.com/b"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "11", batch.Results[0].ShortUrl)
+	assert.Equal(t, "12", batch.Results[1].ShortUrl)
+}
+
+func TestSizedGenerator_Grow(t *testing.T) {
+	random, err := NewRandomGenerator(2, "abc")
+	require.NoError(t, err)
+	hash, err := NewHashGenerator(maxHashCodeLength)
+	require.NoError(t, err)
+
+	assert.Equal(t, 9.0, random.Keyspace())
+	// Длина увеличивается только с текущей: повторный вызов с прежней длиной ничего не меняет
+	assert.True(t, random.Grow(2))
+	assert.False(t, random.Grow(2))
+	assert.Equal(t, 3, random.Length())
+	assert.Equal(t, 27.0, random.Keyspace())
+	code, err := random.Generate(storage.URL{}, 0)
+	require.NoError(t, err)
+	assert.Len(t, code, 3)
+
+	// Хеш не длиннее maxHashCodeLength
+	assert.False(t, hash.Grow(maxHashCodeLength))
+	assert.Equal(t, maxHashCodeLength, hash.Length())
+}
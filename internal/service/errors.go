@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	protov2 "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+
+	"url-shortener/internal/logging"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/urlnorm"
+	"url-shortener/proto"
+)
+
+const (
+	// errorDomain — домен ошибок в errdetails.ErrorInfo
+	errorDomain = "url-shortener"
+	// internalErrorMessage заменяет текст непредвиденных ошибок, чтобы клиенту не попадали ошибки хранилища
+	internalErrorMessage = "internal error"
+)
+
+// errorMapping описывает, как ошибка сервиса или хранилища передаётся клиенту по gRPC
+type errorMapping struct {
+	err    error
+	code   codes.Code
+	reason string // причина для errdetails.ErrorInfo
+	field  string // поле запроса для errdetails.BadRequest, если ошибка вызвана аргументом
+}
+
+var errorMappings = []errorMapping{
+	{err: storage.ErrNotFound, code: codes.NotFound, reason: "URL_NOT_FOUND"},
+	{err: storage.ErrExpired, code: codes.NotFound, reason: "URL_EXPIRED"},
+	{err: storage.ErrOriginalURLExists, code: codes.AlreadyExists, reason: "ORIGINAL_URL_EXISTS", field: "original_url"},
+	{err: storage.ErrShortURLConflict, code: codes.AlreadyExists, reason: "SHORT_URL_CONFLICT"},
+	{err: storage.ErrInvalid, code: codes.InvalidArgument, reason: "INVALID_URL_DATA", field: "original_url"},
+	{err: urlnorm.ErrInvalidURL, code: codes.InvalidArgument, reason: "INVALID_URL", field: "original_url"},
+	{err: ErrAliasTaken, code: codes.AlreadyExists, reason: "ALIAS_TAKEN", field: "custom_alias"},
+	{err: ErrInvalidAlias, code: codes.InvalidArgument, reason: "INVALID_ALIAS", field: "custom_alias"},
+	{err: ErrInvalidExpiry, code: codes.InvalidArgument, reason: "INVALID_EXPIRY", field: "ttl_seconds"},
+	{err: ErrInvalidRedirectStatus, code: codes.InvalidArgument, reason: "INVALID_REDIRECT_STATUS", field: "redirect_status"},
+	{err: ErrInvalidStatsQuery, code: codes.InvalidArgument, reason: "INVALID_STATS_QUERY", field: "bucket_seconds"},
+	{err: ErrInvalidListQuery, code: codes.InvalidArgument, reason: "INVALID_LIST_QUERY"},
+	{err: ErrBatchTooLarge, code: codes.InvalidArgument, reason: "BATCH_TOO_LARGE", field: "urls"},
+	{err: ErrUnauthenticated, code: codes.Unauthenticated, reason: "API_KEY_REQUIRED"},
+	{err: ErrPermissionDenied, code: codes.PermissionDenied, reason: "NOT_OWNER"},
+	{err: ErrCodesExhausted, code: codes.Unavailable, reason: "SHORT_CODES_EXHAUSTED"},
+	{err: ErrAnalyticsDisabled, code: codes.Unimplemented, reason: "ANALYTICS_DISABLED"},
+	{err: context.DeadlineExceeded, code: codes.DeadlineExceeded, reason: "DEADLINE_EXCEEDED"},
+	{err: context.Canceled, code: codes.Canceled, reason: "CANCELED"},
+}
+
+// statusError связывает gRPC-статус с исходной ошибкой, чтобы её можно было проверить через errors.Is
+type statusError struct {
+	status *status.Status
+	err    error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+// GRPCStatus возвращает статус, который gRPC-сервер передаёт клиенту
+func (e *statusError) GRPCStatus() *status.Status {
+	return e.status
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+// toStatusError преобразует ошибку в gRPC-статус с подробностями errdetails.
+// shortURL указывается в ResourceInfo для ошибок, связанных с конкретной ссылкой.
+// Клиент получает текст непредвиденной ошибки только как internalErrorMessage, исходная ошибка
+// остаётся в Error() и попадает в лог запроса
+func toStatusError(err error, shortURL string) error {
+	mapping := mappingFor(err)
+	message := err.Error()
+	if mapping.code == codes.Internal {
+		message = internalErrorMessage
+	}
+
+	details := []protoadapt.MessageV1{
+		&errdetails.ErrorInfo{Reason: mapping.reason, Domain: errorDomain},
+	}
+	if mapping.field != "" && mapping.code == codes.InvalidArgument {
+		details = append(details, &errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: mapping.field, Description: err.Error()},
+			},
+		})
+	}
+	if shortURL != "" && (mapping.code == codes.NotFound || mapping.code == codes.AlreadyExists) {
+		details = append(details, &errdetails.ResourceInfo{
+			ResourceType: "short_url",
+			ResourceName: shortURL,
+			Description:  err.Error(),
+		})
+	}
+
+	st := status.New(mapping.code, message)
+	if withDetails, detailsErr := st.WithDetails(details...); detailsErr == nil {
+		st = withDetails
+	}
+	return &statusError{status: st, err: err}
+}
+
+// itemError описывает ошибку одного элемента пакетного запроса.
+// Непредвиденная ошибка пишется в лог запроса, а клиент получает internalErrorMessage
+func itemError(ctx context.Context, err error) *proto.ItemError {
+	mapping := mappingFor(err)
+	message := err.Error()
+	if mapping.code == codes.Internal {
+		logging.FromContext(ctx).Error("Batch item failed", "error", err)
+		message = internalErrorMessage
+	}
+	return &proto.ItemError{
+		Code:    int32(mapping.code),
+		Reason:  mapping.reason,
+		Message: message,
+	}
+}
+
+// mappingFor находит описание ошибки в errorMappings, неизвестные ошибки считаются внутренними
+func mappingFor(err error) errorMapping {
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			return m
+		}
+	}
+	return errorMapping{code: codes.Internal, reason: "INTERNAL"}
+}
+
+// LegacyErrorsInterceptor возвращает gRPC-интерсептор для старых клиентов:
+// вместо gRPC-статуса ошибка передаётся в поле error успешного ответа
+func LegacyErrorsInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		legacy, ok := legacyResponse(info.FullMethod, status.Convert(err).Message())
+		if !ok {
+			return resp, err
+		}
+		return legacy, nil
+	}
+}
+
+// legacyResponse создаёт пустой ответ метода fullMethod с заполненным полем error
+func legacyResponse(fullMethod, message string) (protov2.Message, bool) {
+	serviceName, methodName, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return nil, false
+	}
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(serviceName))
+	if err != nil {
+		return nil, false
+	}
+	serviceDesc, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, false
+	}
+	method := serviceDesc.Methods().ByName(protoreflect.Name(methodName))
+	if method == nil {
+		return nil, false
+	}
+	msgType, err := protoregistry.GlobalTypes.FindMessageByName(method.Output().FullName())
+	if err != nil {
+		return nil, false
+	}
+	field := method.Output().Fields().ByName("error")
+	if field == nil || field.Kind() != protoreflect.StringKind {
+		return nil, false
+	}
+
+	msg := msgType.New()
+	msg.Set(field, protoreflect.ValueOfString(message))
+	return msg.Interface(), true
+}
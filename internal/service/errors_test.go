@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/urlnorm"
+	"url-shortener/proto"
+)
+
+func TestToStatusError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		shortURL       string
+		expectedCode   codes.Code
+		expectedReason string
+		expectedField  string // поле в BadRequest, если ожидается
+	}{
+		{
+			name:           "URL не найден",
+			err:            storage.ErrNotFound,
+			shortURL:       "abc123",
+			expectedCode:   codes.NotFound,
+			expectedReason: "URL_NOT_FOUND",
+		},
+		{
+			name:           "Срок действия URL истёк",
+			err:            storage.ErrExpired,
+			shortURL:       "abc123",
+			expectedCode:   codes.NotFound,
+			expectedReason: "URL_EXPIRED",
+		},
+		{
+			name:           "Алиас уже занят",
+			err:            ErrAliasTaken,
+			shortURL:       "spring-sale",
+			expectedCode:   codes.AlreadyExists,
+			expectedReason: "ALIAS_TAKEN",
+		},
+		{
+			name:           "Некорректные данные ссылки",
+			err:            storage.ErrInvalid,
+			expectedCode:   codes.InvalidArgument,
+			expectedReason: "INVALID_URL_DATA",
+			expectedField:  "original_url",
+		},
+		{
+			name:           "Некорректный URL",
+			err:            urlnorm.ErrInvalidURL,
+			expectedCode:   codes.InvalidArgument,
+			expectedReason: "INVALID_URL",
+			expectedField:  "original_url",
+		},
+		{
+			name:           "Некорректный алиас",
+			err:            ErrInvalidAlias,
+			expectedCode:   codes.InvalidArgument,
+			expectedReason: "INVALID_ALIAS",
+			expectedField:  "custom_alias",
+		},
+		{
+			name:           "Истёк таймаут запроса",
+			err:            context.DeadlineExceeded,
+			expectedCode:   codes.DeadlineExceeded,
+			expectedReason: "DEADLINE_EXCEEDED",
+		},
+		{
+			name:           "Непредвиденная ошибка",
+			err:            errors.New("database error"),
+			expectedCode:   codes.Internal,
+			expectedReason: "INTERNAL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := toStatusError(tt.err, tt.shortURL)
+
+			assert.ErrorIs(t, err, tt.err)
+			st := status.Convert(err)
+			assert.Equal(t, tt.expectedCode, st.Code())
+			if tt.expectedCode == codes.Internal {
+				// Текст ошибки хранилища не передаётся клиенту, но остаётся в ошибке для лога
+				assert.Equal(t, internalErrorMessage, st.Message())
+				assert.Equal(t, tt.err.Error(), err.Error())
+			} else {
+				assert.Equal(t, tt.err.Error(), st.Message())
+			}
+
+			var info *errdetails.ErrorInfo
+			var badRequest *errdetails.BadRequest
+			var resource *errdetails.ResourceInfo
+			for _, detail := range st.Details() {
+				switch d := detail.(type) {
+				case *errdetails.ErrorInfo:
+					info = d
+				case *errdetails.BadRequest:
+					badRequest = d
+				case *errdetails.ResourceInfo:
+					resource = d
+				}
+			}
+			if assert.NotNil(t, info) {
+				assert.Equal(t, tt.expectedReason, info.Reason)
+				assert.Equal(t, errorDomain, info.Domain)
+			}
+			if tt.expectedField != "" {
+				if assert.NotNil(t, badRequest) {
+					assert.Equal(t, tt.expectedField, badRequest.FieldViolations[0].Field)
+				}
+			}
+			if tt.shortURL != "" {
+				if assert.NotNil(t, resource) {
+					assert.Equal(t, tt.shortURL, resource.ResourceName)
+				}
+			}
+		})
+	}
+}
+
+func TestItemError(t *testing.T) {
+	item := itemError(context.Background(), ErrAliasTaken)
+	assert.Equal(t, int32(codes.AlreadyExists), item.Code)
+	assert.Equal(t, ErrAliasTaken.Error(), item.Message)
+
+	// Текст ошибки хранилища не передаётся клиенту
+	item = itemError(context.Background(), errors.New("pq: connection refused"))
+	assert.Equal(t, int32(codes.Internal), item.Code)
+	assert.Equal(t, "INTERNAL", item.Reason)
+	assert.Equal(t, internalErrorMessage, item.Message)
+}
+
+func TestLegacyErrorsInterceptor(t *testing.T) {
+	interceptor := LegacyErrorsInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: proto.URLShortener_GetURL_FullMethodName}
+
+	resp, err := interceptor(context.Background(), nil, info, func(context.Context, any) (any, error) {
+		return nil, toStatusError(storage.ErrNotFound, "abc123")
+	})
+	assert.NoError(t, err)
+	if assert.IsType(t, &proto.GetURLResponse{}, resp) {
+		assert.Equal(t, storage.ErrNotFound.Error(), resp.(*proto.GetURLResponse).Error)
+	}
+
+	expected := &proto.GetURLResponse{OriginalUrl: "https://example.com"}
+	resp, err = interceptor(context.Background(), nil, info, func(context.Context, any) (any, error) {
+		return expected, nil
+	})
+	assert.NoError(t, err)
+	assert.Same(t, expected, resp)
+}
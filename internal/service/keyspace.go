@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"url-shortener/internal/logging"
+)
+
+const (
+	// maxCodeAttempts ограничивает число кодов, которые пробуются для одной ссылки
+	maxCodeAttempts = 10
+	// growAfterCollisions — после стольких занятых кодов подряд у одной ссылки длина кодов увеличивается:
+	// частые коллизии означают, что пространство кодов текущей длины заполняется
+	growAfterCollisions = 3
+	// growOccupancy — доля занятых кодов, при которой длина кодов увеличивается при запуске сервиса. При ней
+	// каждая тысячная ссылка получает growAfterCollisions коллизий подряд, и длина выросла бы вскоре после запуска
+	growOccupancy = 0.1
+)
+
+// KeyspaceStats описывает заполненность пространства кодов коротких ссылок
+type KeyspaceStats struct {
+	CodeLength int     // текущая длина кодов, 0 — у генератора нет ограниченного пространства кодов
+	Keyspace   float64 // число различных кодов текущей длины
+	Links      int64   // число хранимых ссылок
+	// Occupancy — доля занятых кодов, Links / Keyspace. Оценка сверху: алиасы и коды прежней длины
+	// считаются занимающими пространство текущей длины
+	Occupancy float64
+}
+
+// Keyspace возвращает заполненность пространства кодов и передаёт её в метрики
+func (s *Service) Keyspace(ctx context.Context) (KeyspaceStats, error) {
+	links, err := s.storage.Count(ctx)
+	if err != nil {
+		return KeyspaceStats{}, err
+	}
+	stats := KeyspaceStats{Links: links}
+	if sized, ok := s.codes.(SizedGenerator); ok {
+		stats.CodeLength = sized.Length()
+		stats.Keyspace = sized.Keyspace()
+		stats.Occupancy = float64(links) / stats.Keyspace
+	}
+	if s.metrics != nil {
+		s.metrics.KeyspaceUsage(stats.CodeLength, stats.Links, stats.Occupancy)
+	}
+	return stats, nil
+}
+
+// RunKeyspaceReporter обновляет метрики заполненности пространства кодов каждые interval до отмены ctx
+func (s *Service) RunKeyspaceReporter(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Keyspace(ctx); err != nil && ctx.Err() == nil {
+			logging.FromContext(ctx).Error("Failed to count short codes", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RestoreCodeLength увеличивает длину кодов, пока доля занятых кодов не станет меньше growOccupancy.
+// Вызывается при запуске: длина, до которой генератор вырос до перезапуска, хранилась только в памяти
+func (s *Service) RestoreCodeLength(ctx context.Context) error {
+	sized, ok := s.codes.(SizedGenerator)
+	if !ok {
+		return nil
+	}
+	links, err := s.storage.Count(ctx)
+	if err != nil {
+		return err
+	}
+	from := sized.Length()
+	for float64(links)/sized.Keyspace() >= growOccupancy {
+		if !sized.Grow(sized.Length()) {
+			break
+		}
+	}
+	if length := sized.Length(); length != from {
+		logging.FromContext(ctx).Info("Restored short code length", "code_length", length, "links", links)
+	}
+	return nil
+}
+
+// codeLength возвращает длину кодов в начале создания ссылки, 0 для генераторов без ограниченного пространства
+func (s *Service) codeLength() int {
+	if sized, ok := s.codes.(SizedGenerator); ok {
+		return sized.Length()
+	}
+	return 0
+}
+
+// codeCollision учитывает занятый сгенерированный код. attempt — номер попытки, length — длина кодов
+// в начале создания ссылки: одновременные запросы увеличивают длину только один раз
+func (s *Service) codeCollision(ctx context.Context, length, attempt int) {
+	if s.metrics != nil {
+		s.metrics.ShortURLCollision()
+	}
+	if attempt+1 != growAfterCollisions {
+		return
+	}
+	if sized, ok := s.codes.(SizedGenerator); ok && sized.Grow(length) {
+		logging.FromContext(ctx).Warn("Short code space is filling up, growing code length", "code_length", length+1)
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"url-shortener/internal/storage"
+	"url-shortener/proto"
+)
+
+// takenGenerator всегда возвращает один и тот же код
+type takenGenerator string
+
+func (g takenGenerator) Generate(storage.URL, int) (string, error) { return string(g), nil }
+
+func TestService_CreateURLGrowsCodeLength(t *testing.T) {
+	fakeStorage := NewFakeStorage()
+	fakeStorage.storage["a"] = "https://example.com/a"
+	fakeStorage.storage["b"] = "https://example.com/b"
+	codes, err := NewRandomGenerator(1, "ab")
+	require.NoError(t, err)
+	counter := &collisionCounter{}
+	s := NewService(fakeStorage, WithCodeGenerator(codes), WithMetrics(counter))
+
+	// Все коды длины 1 заняты: после growAfterCollisions коллизий длина увеличивается
+	resp, err := s.CreateURL(context.Background(), &proto.CreateURLRequest{OriginalUrl: "https://example.com"})
+	require.NoError(t, err)
+	assert.Len(t, resp.ShortUrl, 2)
+	assert.Equal(t, 2, codes.Length())
+	assert.Equal(t, growAfterCollisions, counter.collisions)
+}
+
+func TestService_CodesExhausted(t *testing.T) {
+	fakeStorage := NewFakeStorage()
+	fakeStorage.storage["taken"] = "https://example.com/taken"
+	counter := &collisionCounter{}
+	s := NewService(fakeStorage, WithCodeGenerator(takenGenerator("taken")), WithMetrics(counter))
+
+	_, err := s.CreateURL(context.Background(), &proto.CreateURLRequest{OriginalUrl: "https://example.com"})
+	assert.ErrorIs(t, err, ErrCodesExhausted)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, maxCodeAttempts, counter.collisions)
+
+	batch, err := s.BatchCreateURLs(context.Background(), &proto.BatchCreateURLsRequest{
+		Urls: []*proto.CreateURLRequest{{OriginalUrl: "https://example.com/a"}, {OriginalUrl: "https://example.com/b", CustomAlias: "free"}},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, batch.Results[0].ShortUrl)
+	assert.Equal(t, "SHORT_CODES_EXHAUSTED", batch.Results[0].Error.GetReason())
+	assert.Equal(t, "free", batch.Results[1].ShortUrl)
+}
+
+func TestService_Keyspace(t *testing.T) {
+	fakeStorage := NewFakeStorage()
+	fakeStorage.storage["aa"] = "https://example.com/a"
+	fakeStorage.storage["ab"] = "https://example.com/b"
+	fakeStorage.storage["ba"] = "https://example.com/c"
+	codes, err := NewRandomGenerator(2, "ab")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		codes    CodeGenerator
+		expected KeyspaceStats
+	}{
+		{
+			name:     "Случайные коды",
+			codes:    codes,
+			expected: KeyspaceStats{CodeLength: 2, Keyspace: 4, Links: 3, Occupancy: 0.75},
+		},
+		{
+			name:     "Счётчик без ограниченного пространства",
+			codes:    NewCounterGenerator(0),
+			expected: KeyspaceStats{Links: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := &collisionCounter{}
+			s := NewService(fakeStorage, WithCodeGenerator(tt.codes), WithMetrics(counter))
+
+			stats, err := s.Keyspace(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, stats)
+			assert.Equal(t, KeyspaceStats{CodeLength: stats.CodeLength, Links: stats.Links, Occupancy: stats.Occupancy}, counter.keyspace)
+		})
+	}
+}
+
+func TestService_RestoreCodeLength(t *testing.T) {
+	fakeStorage := NewFakeStorage()
+	fakeStorage.storage["aa"] = "https://example.com/a"
+	fakeStorage.storage["ab"] = "https://example.com/b"
+	fakeStorage.storage["ba"] = "https://example.com/c"
+
+	tests := []struct {
+		name     string
+		length   int
+		expected int
+	}{
+		// 3 ссылки из 2^5 кодов — меньше growOccupancy
+		{name: "Длина после перезапуска меньше нужной", length: 1, expected: 5},
+		{name: "Длины достаточно", length: 6, expected: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codes, err := NewRandomGenerator(tt.length, "ab")
+			require.NoError(t, err)
+			s := NewService(fakeStorage, WithCodeGenerator(codes))
+
+			require.NoError(t, s.RestoreCodeLength(context.Background()))
+			assert.Equal(t, tt.expected, codes.Length())
+		})
+	}
+
+	// Генераторы без ограниченного пространства кодов не меняются
+	s := NewService(fakeStorage, WithCodeGenerator(NewCounterGenerator(0)))
+	assert.NoError(t, s.RestoreCodeLength(context.Background()))
+}
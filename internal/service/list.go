@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/urlnorm"
+	"url-shortener/proto"
+)
+
+const (
+	// defaultListPageSize — число ссылок на странице ListURLs, если размер не задан
+	defaultListPageSize = 50
+	// maxListPageSize ограничивает размер страницы ListURLs
+	maxListPageSize = 1000
+)
+
+// ListURLs реализует gRPC-метод для постраничного получения ссылок владельца ключа API
+func (s *Service) ListURLs(ctx context.Context, req *proto.ListURLsRequest) (*proto.ListURLsResponse, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	owner, ok := auth.OwnerFromContext(ctx)
+	if !ok {
+		return nil, toStatusError(ErrUnauthenticated, "")
+	}
+	query, err := listQueryFromRequest(req, owner)
+	if err != nil {
+		return nil, toStatusError(err, "")
+	}
+	pageSize := query.Limit
+	// Лишняя ссылка показывает, что за страницей есть ещё ссылки
+	query.Limit++
+
+	urls, err := s.storage.List(ctx, query)
+	if err != nil {
+		return nil, toStatusError(err, "")
+	}
+	resp := &proto.ListURLsResponse{}
+	if len(urls) > pageSize {
+		urls = urls[:pageSize]
+		resp.NextPageToken = encodePageToken(storage.CursorOf(urls[len(urls)-1]))
+	}
+	for _, url := range urls {
+		listed := &proto.ListedURL{
+			ShortUrl:       url.ShortURL,
+			OriginalUrl:    url.OriginalURL,
+			CreatedAt:      timestamppb.New(url.CreatedAt),
+			RedirectStatus: url.RedirectStatus,
+		}
+		if !url.ExpiresAt.IsZero() {
+			listed.ExpiresAt = timestamppb.New(url.ExpiresAt)
+		}
+		resp.Urls = append(resp.Urls, listed)
+	}
+	return resp, nil
+}
+
+// listQueryFromRequest формирует выборку ссылок владельца owner из запроса
+func listQueryFromRequest(req *proto.ListURLsRequest, owner string) (storage.ListQuery, error) {
+	query := storage.ListQuery{
+		OwnerID:  owner,
+		Contains: req.GetContains(),
+		Limit:    defaultListPageSize,
+	}
+	switch size := req.GetPageSize(); {
+	case size < 0 || size > maxListPageSize:
+		return storage.ListQuery{}, fmt.Errorf("%w: page_size must be between 1 and %d", ErrInvalidListQuery, maxListPageSize)
+	case size > 0:
+		query.Limit = int(size)
+	}
+	switch req.GetOrder() {
+	case proto.ListOrder_LIST_ORDER_NEWEST_FIRST:
+		query.Order = storage.NewestFirst
+	case proto.ListOrder_LIST_ORDER_OLDEST_FIRST:
+		query.Order = storage.OldestFirst
+	default:
+		return storage.ListQuery{}, fmt.Errorf("%w: unknown order %d", ErrInvalidListQuery, req.GetOrder())
+	}
+	if req.GetCreatedFrom() != nil {
+		query.CreatedFrom = req.GetCreatedFrom().AsTime()
+	}
+	if req.GetCreatedTo() != nil {
+		query.CreatedTo = req.GetCreatedTo().AsTime()
+	}
+	if !query.CreatedFrom.IsZero() && !query.CreatedTo.IsZero() && !query.CreatedFrom.Before(query.CreatedTo) {
+		return storage.ListQuery{}, fmt.Errorf("%w: created_from must be before created_to", ErrInvalidListQuery)
+	}
+	if req.GetDomain() != "" {
+		// Оригинальные URL хранятся нормализованными, поэтому и домен фильтра нормализуется
+		domain, err := urlnorm.NormalizeHost(req.GetDomain())
+		if err != nil {
+			return storage.ListQuery{}, fmt.Errorf("%w: invalid domain %q", ErrInvalidListQuery, req.GetDomain())
+		}
+		query.Domain = domain
+	}
+	if req.GetPageToken() != "" {
+		cursor, err := decodePageToken(req.GetPageToken())
+		if err != nil {
+			return storage.ListQuery{}, err
+		}
+		query.After = &cursor
+	}
+	return query, nil
+}
+
+// encodePageToken кодирует позицию последней ссылки страницы в непрозрачный токен следующей страницы
+func encodePageToken(cursor storage.ListCursor) string {
+	raw := cursor.CreatedAt.UTC().Format(time.RFC3339Nano) + " " + cursor.ShortURL
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodePageToken восстанавливает позицию из токена страницы
+func decodePageToken(token string) (storage.ListCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return storage.ListCursor{}, fmt.Errorf("%w: malformed page_token", ErrInvalidListQuery)
+	}
+	createdAt, shortURL, ok := strings.Cut(string(raw), " ")
+	if !ok || shortURL == "" {
+		return storage.ListCursor{}, fmt.Errorf("%w: malformed page_token", ErrInvalidListQuery)
+	}
+	cursor := storage.ListCursor{ShortURL: shortURL}
+	if cursor.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return storage.ListCursor{}, fmt.Errorf("%w: malformed page_token", ErrInvalidListQuery)
+	}
+	return cursor, nil
+}
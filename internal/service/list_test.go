@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"url-shortener/proto"
+)
+
+func TestService_ListURLs(t *testing.T) {
+	fakeStorage := NewFakeStorage()
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	for i, shortURL := range []string{"a1", "a2", "a3"} {
+		fakeStorage.storage[shortURL] = "https://example.com/" + shortURL
+		fakeStorage.createdAt[shortURL] = base.Add(time.Duration(i) * time.Minute)
+		fakeStorage.owners[shortURL] = "alice"
+	}
+	fakeStorage.storage["b1"] = "https://go.dev/b1"
+	fakeStorage.owners["b1"] = "bob"
+	s := NewService(fakeStorage)
+
+	// Страницы по две ссылки, сначала новые
+	resp, err := s.ListURLs(ownerContext, &proto.ListURLsRequest{PageSize: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a3", "a2"}, listedShortURLs(resp))
+	assert.NotEmpty(t, resp.NextPageToken)
+
+	resp, err = s.ListURLs(ownerContext, &proto.ListURLsRequest{PageSize: 2, PageToken: resp.NextPageToken})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a1"}, listedShortURLs(resp))
+	assert.Empty(t, resp.NextPageToken)
+
+	resp, err = s.ListURLs(ownerContext, &proto.ListURLsRequest{
+		Order:       proto.ListOrder_LIST_ORDER_OLDEST_FIRST,
+		CreatedFrom: timestamppb.New(base.Add(time.Minute)),
+		Domain:      "EXAMPLE.com",
+		Contains:    "A",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a2", "a3"}, listedShortURLs(resp))
+	assert.Equal(t, "https://example.com/a2", resp.Urls[0].OriginalUrl)
+	assert.True(t, base.Add(time.Minute).Equal(resp.Urls[0].CreatedAt.AsTime()))
+}
+
+func TestService_ListURLsErrors(t *testing.T) {
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		req          *proto.ListURLsRequest
+		expectedCode codes.Code
+	}{
+		{
+			name:         "Запрос без ключа",
+			ctx:          context.Background(),
+			req:          &proto.ListURLsRequest{},
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "Слишком большая страница",
+			ctx:          ownerContext,
+			req:          &proto.ListURLsRequest{PageSize: maxListPageSize + 1},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "Некорректный токен страницы",
+			ctx:          ownerContext,
+			req:          &proto.ListURLsRequest{PageToken: "not a token"},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name: "Пустой интервал создания",
+			ctx:  ownerContext,
+			req: &proto.ListURLsRequest{
+				CreatedFrom: timestamppb.New(base),
+				CreatedTo:   timestamppb.New(base),
+			},
+			expectedCode: codes.InvalidArgument,
+		},
+		{
+			name:         "Неизвестный порядок",
+			ctx:          ownerContext,
+			req:          &proto.ListURLsRequest{Order: proto.ListOrder(7)},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewService(NewFakeStorage()).ListURLs(tt.ctx, tt.req)
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+		})
+	}
+}
+
+// listedShortURLs возвращает короткие URL страницы списка
+func listedShortURLs(resp *proto.ListURLsResponse) []string {
+	var shortURLs []string
+	for _, url := range resp.GetUrls() {
+		shortURLs = append(shortURLs, url.GetShortUrl())
+	}
+	return shortURLs
+}
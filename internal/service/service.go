@@ -2,78 +2,489 @@ package service
 
 import (
 	"context"
-	"crypto/rand"
-	"math/big"
+	"errors"
+	"net"
+	"net/http"
 	"strings"
+	"time"
 
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"url-shortener/internal/analytics"
+	"url-shortener/internal/auth"
+	"url-shortener/internal/logging"
 	"url-shortener/internal/storage"
+	"url-shortener/internal/urlnorm"
 	"url-shortener/proto"
 )
 
 const (
-	shortURLLength = 10
+	// DefaultCodeLength и DefaultCodeAlphabet — параметры случайных кодов по умолчанию
+	DefaultCodeLength   = 10
+	DefaultCodeAlphabet = chars
+
+	shortURLLength = DefaultCodeLength
 	chars          = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_"
+
+	// aliasChars — допустимые символы пользовательского алиаса; дефис разрешён для читаемых ссылок
+	aliasChars     = chars + "-"
+	minAliasLength = 3
+	maxAliasLength = 64
+
+	defaultStatsBucket = 24 * time.Hour
+)
+
+var (
+	// ErrInvalidAlias возвращается, если пользовательский алиас не прошёл валидацию
+	ErrInvalidAlias = errors.New("invalid alias")
+	// ErrAliasTaken возвращается, если пользовательский алиас уже занят
+	ErrAliasTaken = errors.New("alias already taken")
+	// ErrInvalidExpiry возвращается, если срок действия ссылки задан некорректно
+	ErrInvalidExpiry = errors.New("invalid expiry")
+	// ErrInvalidRedirectStatus возвращается, если статус редиректа не поддерживается
+	ErrInvalidRedirectStatus = errors.New("invalid redirect status")
+	// ErrInvalidStatsQuery возвращается, если параметры запроса статистики некорректны
+	ErrInvalidStatsQuery = errors.New("invalid stats query")
+	// ErrInvalidListQuery возвращается, если параметры запроса списка ссылок некорректны
+	ErrInvalidListQuery = errors.New("invalid list query")
+	// ErrAnalyticsDisabled возвращается, если сбор статистики переходов не настроен
+	ErrAnalyticsDisabled = errors.New("analytics disabled")
+	// ErrBatchTooLarge возвращается, если в пакетном запросе больше maxBatchSize элементов
+	ErrBatchTooLarge = errors.New("batch too large")
+	// ErrUnauthenticated возвращается, если операция требует ключ API, а запрос без ключа
+	ErrUnauthenticated = errors.New("API key required")
+	// ErrCodesExhausted возвращается, если за maxCodeAttempts попыток не нашлось свободного кода
+	ErrCodesExhausted = errors.New("no free short code found")
+	// ErrPermissionDenied возвращается, если ссылка принадлежит другому владельцу
+	ErrPermissionDenied = errors.New("short URL belongs to another owner")
 )
 
 // Service реализует интерфейс URLShortenerServer
 type Service struct {
 	proto.UnimplementedURLShortenerServer
-	storage storage.Storage
+	storage    storage.Storage
+	analytics  *analytics.Analytics
+	timeout    time.Duration
+	normalizer *urlnorm.Normalizer
+	metrics    Metrics
+	codes      CodeGenerator
+	// ownerRequired запрещает создавать ссылки без ключа API
+	ownerRequired bool
+}
+
+// Metrics принимает события сервиса для мониторинга
+type Metrics interface {
+	// ShortURLCollision вызывается, когда сгенерированный код уже занят и генерируется заново
+	ShortURLCollision()
+	// KeyspaceUsage вызывается с текущей длиной кодов, числом ссылок и долей занятых кодов
+	KeyspaceUsage(codeLength int, links int64, occupancy float64)
+}
+
+// Option задаёт необязательный параметр сервиса
+type Option func(*Service)
+
+// WithAnalytics включает запись переходов по ссылкам и статистику
+func WithAnalytics(a *analytics.Analytics) Option {
+	return func(s *Service) {
+		s.analytics = a
+	}
+}
+
+// WithTimeout ограничивает время обработки одного запроса, 0 — без ограничения
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *Service) {
+		s.timeout = timeout
+	}
+}
+
+// WithNormalizer задаёт правила проверки и нормализации оригинальных URL
+func WithNormalizer(n *urlnorm.Normalizer) Option {
+	return func(s *Service) {
+		s.normalizer = n
+	}
+}
+
+// WithMetrics включает учёт событий сервиса, например повторов генерации короткой ссылки
+func WithMetrics(m Metrics) Option {
+	return func(s *Service) {
+		s.metrics = m
+	}
+}
+
+// WithCodeGenerator задаёт стратегию генерации кодов коротких ссылок вместо случайных кодов по умолчанию
+func WithCodeGenerator(g CodeGenerator) Option {
+	return func(s *Service) {
+		s.codes = g
+	}
+}
+
+// WithOwnerRequired запрещает создавать ссылки без ключа API: у каждой ссылки будет владелец
+func WithOwnerRequired() Option {
+	return func(s *Service) {
+		s.ownerRequired = true
+	}
 }
 
 // NewService создаёт новый экземпляр сервиса с переданным хранилищем
-func NewService(storage storage.Storage) *Service {
-	return &Service{storage: storage}
+func NewService(storage storage.Storage, opts ...Option) *Service {
+	s := &Service{
+		storage:    storage,
+		normalizer: urlnorm.New(urlnorm.DefaultConfig()),
+		codes:      newRandomGenerator(DefaultCodeLength, DefaultCodeAlphabet),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // CreateURL реализует gRPC-метод для создания короткой ссылки
-func (s *Service) CreateURL(_ context.Context, req *proto.CreateURLRequest) (*proto.CreateURLResponse, error) {
-	originalURL := req.GetOriginalUrl()
-	for {
-		shortURL, err := generateShortURL()
+func (s *Service) CreateURL(ctx context.Context, req *proto.CreateURLRequest) (*proto.CreateURLResponse, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	owner, err := s.creator(ctx)
+	if err != nil {
+		return nil, toStatusError(err, "")
+	}
+	url, err := s.urlFromRequest(req, owner, time.Now())
+	if err != nil {
+		return nil, toStatusError(err, "")
+	}
+	if url.ShortURL != "" {
+		return s.createWithAlias(ctx, url)
+	}
+	length := s.codeLength()
+	for attempt := 0; attempt < maxCodeAttempts; attempt++ {
+		url.ShortURL, err = s.codes.Generate(url, attempt)
 		if err != nil {
-			return &proto.CreateURLResponse{
-				Error: err.Error(),
-			}, nil
+			return nil, toStatusError(err, "")
 		}
-		shortURL, err = s.storage.Save(shortURL, originalURL)
+		shortURL, err := s.storage.Save(ctx, url)
 		if err == nil {
-			return &proto.CreateURLResponse{
-				ShortUrl: shortURL,
-			}, nil
+			return s.createResponse(ctx, url, shortURL)
 		}
-		if strings.Contains(err.Error(), "short URL") || strings.Contains(err.Error(), "urls_pkey") {
+		if errors.Is(err, storage.ErrShortURLConflict) {
+			logging.FromContext(ctx).Debug("Short URL collision, generating a new one", "short_code", url.ShortURL)
+			s.codeCollision(ctx, length, attempt)
 			continue // если короткая ссылка уже существует — сгенерировать новую
 		}
-		return &proto.CreateURLResponse{
-			Error: err.Error(),
-		}, nil
+		return nil, toStatusError(err, "")
+	}
+	logging.FromContext(ctx).Warn("No free short code found", "attempts", maxCodeAttempts)
+	return nil, toStatusError(ErrCodesExhausted, "")
+}
+
+// urlFromRequest проверяет запрос на создание ссылки и собирает из него ссылку владельца owner
+// для сохранения. Если алиас не задан, ShortURL остаётся пустым
+func (s *Service) urlFromRequest(req *proto.CreateURLRequest, owner string, now time.Time) (storage.URL, error) {
+	originalURL, err := s.normalizer.Normalize(req.GetOriginalUrl())
+	if err != nil {
+		return storage.URL{}, err
+	}
+	expiresAt, err := expiryFromRequest(req, now)
+	if err != nil {
+		return storage.URL{}, err
+	}
+	redirectStatus := req.GetRedirectStatus()
+	if redirectStatus != 0 && !IsRedirectStatus(int(redirectStatus)) {
+		return storage.URL{}, ErrInvalidRedirectStatus
+	}
+	if alias := req.GetCustomAlias(); alias != "" {
+		if err := validateAlias(alias); err != nil {
+			return storage.URL{}, err
+		}
+	}
+	return storage.URL{
+		ShortURL:       req.GetCustomAlias(),
+		OriginalURL:    originalURL,
+		ExpiresAt:      expiresAt,
+		RedirectStatus: redirectStatus,
+		CreatedAt:      now,
+		OwnerID:        owner,
+	}, nil
+}
+
+// createWithAlias сохраняет ссылку под пользовательским алиасом без генерации кода.
+// Если оригинальный URL уже сокращён под другим кодом, алиас не создаётся: возвращается
+// storage.ErrOriginalURLExists с существующим кодом в ResourceInfo
+func (s *Service) createWithAlias(ctx context.Context, url storage.URL) (*proto.CreateURLResponse, error) {
+	shortURL, err := s.storage.Save(ctx, url)
+	if err != nil {
+		if errors.Is(err, storage.ErrShortURLConflict) {
+			err = ErrAliasTaken
+		}
+		return nil, toStatusError(err, url.ShortURL)
 	}
+	if shortURL != url.ShortURL {
+		return nil, toStatusError(storage.ErrOriginalURLExists, shortURL)
+	}
+	return s.createResponse(ctx, url, shortURL)
+}
+
+// createResponse формирует ответ на создание ссылки.
+// Если хранилище вернуло уже существующую ссылку, время создания берётся из неё
+func (s *Service) createResponse(ctx context.Context, url storage.URL, shortURL string) (*proto.CreateURLResponse, error) {
+	createdAt := url.CreatedAt
+	if shortURL != url.ShortURL {
+		existing, err := s.storage.Get(ctx, shortURL)
+		if err != nil {
+			return nil, toStatusError(err, shortURL)
+		}
+		createdAt = existing.CreatedAt
+	}
+	return &proto.CreateURLResponse{
+		ShortUrl:    shortURL,
+		OriginalUrl: url.OriginalURL,
+		CreatedAt:   timestamppb.New(createdAt),
+		Existing:    shortURL != url.ShortURL,
+	}, nil
 }
 
 // GetURL реализует gRPC-метод для получения оригинального URL по короткому
-func (s *Service) GetURL(_ context.Context, req *proto.GetURLRequest) (*proto.GetURLResponse, error) {
+func (s *Service) GetURL(ctx context.Context, req *proto.GetURLRequest) (*proto.GetURLResponse, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
 	shortURL := req.GetShortUrl()
-	originalURL, err := s.storage.Get(shortURL)
+	url, err := s.storage.Get(ctx, shortURL)
 	if err != nil {
-		return &proto.GetURLResponse{
-			Error: err.Error(),
-		}, nil
+		return nil, toStatusError(err, shortURL)
+	}
+	if s.analytics != nil {
+		s.analytics.Record(shortURL, clientFromContext(ctx))
 	}
 	return &proto.GetURLResponse{
-		OriginalUrl: originalURL,
+		OriginalUrl:    url.OriginalURL,
+		RedirectStatus: url.RedirectStatus,
 	}, nil
 }
 
-// generateShortURL генерирует случайный короткий URL заданной длины
-func generateShortURL() (string, error) {
-	var shortURL string
-	for i := 0; i < shortURLLength; i++ {
-		idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
-		if err != nil {
-			return "", err
+// GetURLInfo реализует gRPC-метод для получения сведений о короткой ссылке.
+// В отличие от GetURL, переход по ссылке не записывается в статистику
+func (s *Service) GetURLInfo(ctx context.Context, req *proto.GetURLInfoRequest) (*proto.GetURLInfoResponse, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	shortURL := req.GetShortUrl()
+	url, err := s.storage.Get(ctx, shortURL)
+	if err != nil {
+		return nil, toStatusError(err, shortURL)
+	}
+	resp := &proto.GetURLInfoResponse{
+		ShortUrl:       shortURL,
+		OriginalUrl:    url.OriginalURL,
+		CreatedAt:      timestamppb.New(url.CreatedAt),
+		RedirectStatus: url.RedirectStatus,
+	}
+	if !url.ExpiresAt.IsZero() {
+		resp.ExpiresAt = timestamppb.New(url.ExpiresAt)
+	}
+	return resp, nil
+}
+
+// DeleteURL реализует gRPC-метод для удаления короткой ссылки
+func (s *Service) DeleteURL(ctx context.Context, req *proto.DeleteURLRequest) (*proto.DeleteURLResponse, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if err := s.authorize(ctx, req.GetShortUrl()); err != nil {
+		return nil, toStatusError(err, req.GetShortUrl())
+	}
+	if err := s.storage.Delete(ctx, req.GetShortUrl()); err != nil {
+		return nil, toStatusError(err, req.GetShortUrl())
+	}
+	return &proto.DeleteURLResponse{}, nil
+}
+
+// UpdateURL реализует gRPC-метод для изменения оригинального URL короткой ссылки
+func (s *Service) UpdateURL(ctx context.Context, req *proto.UpdateURLRequest) (*proto.UpdateURLResponse, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	originalURL, err := s.normalizer.Normalize(req.GetOriginalUrl())
+	if err != nil {
+		return nil, toStatusError(err, "")
+	}
+	if err := s.authorize(ctx, req.GetShortUrl()); err != nil {
+		return nil, toStatusError(err, req.GetShortUrl())
+	}
+	if err := s.storage.Update(ctx, req.GetShortUrl(), originalURL); err != nil {
+		return nil, toStatusError(err, req.GetShortUrl())
+	}
+	return &proto.UpdateURLResponse{}, nil
+}
+
+// GetStats реализует gRPC-метод для получения статистики переходов по короткой ссылке
+func (s *Service) GetStats(ctx context.Context, req *proto.GetStatsRequest) (*proto.GetStatsResponse, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	if s.analytics == nil {
+		return nil, toStatusError(ErrAnalyticsDisabled, "")
+	}
+	query, err := statsQueryFromRequest(req)
+	if err != nil {
+		return nil, toStatusError(err, "")
+	}
+	// Статистика доступна и для истёкших ссылок, пока они не удалены
+	if err := s.authorize(ctx, query.ShortURL); err != nil {
+		return nil, toStatusError(err, query.ShortURL)
+	}
+
+	stats, err := s.analytics.Stats(ctx, query)
+	if err != nil {
+		return nil, toStatusError(err, "")
+	}
+	resp := &proto.GetStatsResponse{
+		TotalClicks:    stats.TotalClicks,
+		UniqueVisitors: stats.UniqueVisitors,
+	}
+	for _, bucket := range stats.Buckets {
+		resp.Buckets = append(resp.Buckets, &proto.ClickBucket{
+			Start:  timestamppb.New(bucket.Start),
+			Clicks: bucket.Clicks,
+		})
+	}
+	return resp, nil
+}
+
+// creator возвращает владельца создаваемых ссылок: владельца ключа API запроса или пустую строку,
+// если ключ не передан и ссылки без владельца разрешены
+func (s *Service) creator(ctx context.Context) (string, error) {
+	owner, ok := auth.OwnerFromContext(ctx)
+	if !ok && s.ownerRequired {
+		return "", ErrUnauthenticated
+	}
+	return owner, nil
+}
+
+// authorize проверяет, что ссылка shortURL, в том числе истёкшая, принадлежит владельцу ключа API запроса.
+// Ссылки без владельца созданы анонимно, и управлять ими может запрос с любым действительным ключом
+func (s *Service) authorize(ctx context.Context, shortURL string) error {
+	owner, ok := auth.OwnerFromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	url, err := s.storage.Get(ctx, shortURL)
+	if err != nil && !errors.Is(err, storage.ErrExpired) {
+		return err
+	}
+	if url.OwnerID != "" && url.OwnerID != owner {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// IsRedirectStatus сообщает, можно ли использовать HTTP-статус для редиректа по короткой ссылке
+func IsRedirectStatus(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// withTimeout ограничивает контекст запроса настроенным таймаутом
+func (s *Service) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.timeout)
+}
+
+// reservedAliases совпадают с путями HTTP-сервера и не могут быть короткими ссылками
+var reservedAliases = map[string]struct{}{
+	"api":     {},
+	"healthz": {},
+	"readyz":  {},
+}
+
+// validateAlias проверяет длину алиаса, допустимость его символов и что он не занят путями сервера
+func validateAlias(alias string) error {
+	if len(alias) < minAliasLength || len(alias) > maxAliasLength {
+		return ErrInvalidAlias
+	}
+	if _, reserved := reservedAliases[strings.ToLower(alias)]; reserved {
+		return ErrInvalidAlias
+	}
+	for _, c := range alias {
+		if !strings.ContainsRune(aliasChars, c) {
+			return ErrInvalidAlias
+		}
+	}
+	return nil
+}
+
+// expiryFromRequest вычисляет время истечения ссылки из ttl_seconds или expires_at.
+// Нулевое время означает бессрочную ссылку
+func expiryFromRequest(req *proto.CreateURLRequest, now time.Time) (time.Time, error) {
+	ttl := req.GetTtlSeconds()
+	switch {
+	case ttl < 0:
+		return time.Time{}, ErrInvalidExpiry
+	case ttl > 0 && req.GetExpiresAt() != nil:
+		return time.Time{}, ErrInvalidExpiry
+	case ttl > 0:
+		return now.Add(time.Duration(ttl) * time.Second), nil
+	case req.GetExpiresAt() != nil:
+		if err := req.GetExpiresAt().CheckValid(); err != nil {
+			return time.Time{}, ErrInvalidExpiry
+		}
+		expiresAt := req.GetExpiresAt().AsTime()
+		if !expiresAt.After(now) {
+			return time.Time{}, ErrInvalidExpiry
+		}
+		return expiresAt, nil
+	}
+	return time.Time{}, nil
+}
+
+// statsQueryFromRequest формирует параметры выборки статистики из запроса
+func statsQueryFromRequest(req *proto.GetStatsRequest) (storage.ClickStatsQuery, error) {
+	query := storage.ClickStatsQuery{
+		ShortURL: req.GetShortUrl(),
+		Bucket:   defaultStatsBucket,
+	}
+	if req.GetBucketSeconds() < 0 {
+		return storage.ClickStatsQuery{}, ErrInvalidStatsQuery
+	}
+	if req.GetBucketSeconds() > 0 {
+		query.Bucket = time.Duration(req.GetBucketSeconds()) * time.Second
+	}
+	if req.GetFrom() != nil {
+		query.From = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		query.To = req.GetTo().AsTime()
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		return storage.ClickStatsQuery{}, ErrInvalidStatsQuery
+	}
+	return query, nil
+}
+
+// clientFromContext возвращает информацию о клиенте, переданную HTTP-обработчиком,
+// а для gRPC-вызовов извлекает её из адреса пира и метаданных
+func clientFromContext(ctx context.Context) analytics.Client {
+	if client, ok := analytics.ClientFromContext(ctx); ok {
+		return client
+	}
+	var client analytics.Client
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		client.IP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(client.IP); err == nil {
+			client.IP = host
+		}
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("user-agent"); len(values) > 0 {
+			client.UserAgent = values[0]
+		}
+		if values := md.Get("referer"); len(values) > 0 {
+			client.Referrer = values[0]
 		}
-		shortURL += string(chars[idx.Int64()])
 	}
-	return shortURL, nil
+	return client
 }
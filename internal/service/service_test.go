@@ -4,55 +4,182 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
+	"url-shortener/internal/analytics"
+	"url-shortener/internal/auth"
 	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/memory"
+	"url-shortener/internal/urlnorm"
 	"url-shortener/proto"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // FakeStorage — поддельное хранилище для тестов
 type FakeStorage struct {
-	storage map[string]string // Короткий URL -> Оригинальный URL
-	err     error
+	storage   map[string]string    // Короткий URL -> Оригинальный URL
+	expiresAt map[string]time.Time // Короткий URL -> время истечения
+	redirects map[string]int32     // Короткий URL -> статус редиректа
+	createdAt map[string]time.Time // Короткий URL -> время создания
+	owners    map[string]string    // Короткий URL -> владелец
+	clicks    []storage.Click
+	lastCtx   context.Context // контекст последнего вызова Get
+	err       error
 }
 
+// ownerContext — контекст запроса с ключом API владельца alice
+var ownerContext = auth.ContextWithKey(context.Background(), storage.APIKey{ID: "key1", OwnerID: "alice"})
+
 func NewFakeStorage() *FakeStorage {
 	return &FakeStorage{
-		storage: make(map[string]string),
-		err:     nil,
+		storage:   make(map[string]string),
+		expiresAt: make(map[string]time.Time),
+		redirects: make(map[string]int32),
+		createdAt: make(map[string]time.Time),
+		owners:    make(map[string]string),
+		err:       nil,
 	}
 }
 
-func (f *FakeStorage) Save(shortURL, originalURL string) (string, error) {
+func (f *FakeStorage) Save(_ context.Context, url storage.URL) (string, error) {
 	if f.err != nil {
 		defer func() { f.err = nil }()
 		return "", f.err
 	}
-	// Если URL уже сохранён — вернуть существующий короткий URL.
+	// Если URL уже сохранён тем же владельцем — вернуть существующий короткий URL.
 	for k, v := range f.storage {
-		if v == originalURL {
+		if v == url.OriginalURL && f.owners[k] == url.OwnerID {
 			return k, nil
 		}
 	}
-	if _, exists := f.storage[shortURL]; exists {
-		return "", errors.New("short URL already exists")
+	if _, exists := f.storage[url.ShortURL]; exists {
+		return "", storage.ErrShortURLConflict
+	}
+	f.storage[url.ShortURL] = url.OriginalURL
+	f.expiresAt[url.ShortURL] = url.ExpiresAt
+	f.redirects[url.ShortURL] = url.RedirectStatus
+	f.createdAt[url.ShortURL] = url.CreatedAt
+	f.owners[url.ShortURL] = url.OwnerID
+	return url.ShortURL, nil
+}
+
+func (f *FakeStorage) SaveMany(ctx context.Context, urls []storage.URL) ([]storage.BatchResult, error) {
+	results := make([]storage.BatchResult, len(urls))
+	for i, url := range urls {
+		shortURL, err := f.Save(ctx, url)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].URL, results[i].Err = f.Get(ctx, shortURL)
 	}
-	f.storage[shortURL] = originalURL
-	return shortURL, nil
+	return results, nil
 }
 
-func (f *FakeStorage) Get(shortURL string) (string, error) {
+func (f *FakeStorage) GetMany(ctx context.Context, shortURLs []string) ([]storage.BatchResult, error) {
+	results := make([]storage.BatchResult, len(shortURLs))
+	for i, shortURL := range shortURLs {
+		results[i].URL, results[i].Err = f.Get(ctx, shortURL)
+	}
+	return results, nil
+}
+
+func (f *FakeStorage) Get(ctx context.Context, shortURL string) (storage.URL, error) {
+	f.lastCtx = ctx
 	originalURL, exists := f.storage[shortURL]
 	if !exists {
-		return "", storage.ErrNotFound
+		return storage.URL{}, storage.ErrNotFound
+	}
+	url := storage.URL{
+		ShortURL:       shortURL,
+		OriginalURL:    originalURL,
+		ExpiresAt:      f.expiresAt[shortURL],
+		RedirectStatus: f.redirects[shortURL],
+		CreatedAt:      f.createdAt[shortURL],
+		OwnerID:        f.owners[shortURL],
+	}
+	if url.Expired(time.Now()) {
+		return url, storage.ErrExpired
+	}
+	return url, nil
+}
+
+func (f *FakeStorage) Delete(_ context.Context, shortURL string) error {
+	if _, exists := f.storage[shortURL]; !exists {
+		return storage.ErrNotFound
+	}
+	delete(f.storage, shortURL)
+	return nil
+}
+
+func (f *FakeStorage) Update(_ context.Context, shortURL, newOriginalURL string) error {
+	if _, exists := f.storage[shortURL]; !exists {
+		return storage.ErrNotFound
+	}
+	for k, v := range f.storage {
+		if v == newOriginalURL && k != shortURL {
+			return storage.ErrOriginalURLExists
+		}
+	}
+	f.storage[shortURL] = newOriginalURL
+	return nil
+}
+
+func (f *FakeStorage) DeleteExpired(_ context.Context, now time.Time) (int64, error) {
+	var deleted int64
+	for shortURL, expiresAt := range f.expiresAt {
+		if !expiresAt.IsZero() && !expiresAt.After(now) {
+			delete(f.storage, shortURL)
+			delete(f.expiresAt, shortURL)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (f *FakeStorage) SaveClicks(_ context.Context, clicks []storage.Click) error {
+	f.clicks = append(f.clicks, clicks...)
+	return nil
+}
+
+func (f *FakeStorage) ClickStats(_ context.Context, query storage.ClickStatsQuery) (storage.ClickStats, error) {
+	var stats storage.ClickStats
+	for _, click := range f.clicks {
+		if click.ShortURL == query.ShortURL {
+			stats.TotalClicks++
+		}
+	}
+	return stats, nil
+}
+
+func (f *FakeStorage) Count(context.Context) (int64, error) {
+	return int64(len(f.storage)), nil
+}
+
+func (f *FakeStorage) List(ctx context.Context, query storage.ListQuery) ([]storage.URL, error) {
+	if f.err != nil {
+		defer func() { f.err = nil }()
+		return nil, f.err
+	}
+	urls := make([]storage.URL, 0, len(f.storage))
+	for shortURL := range f.storage {
+		url, _ := f.Get(ctx, shortURL)
+		urls = append(urls, url)
 	}
-	return originalURL, nil
+	return storage.Page(urls, query, time.Now()), nil
 }
 
 func TestService_CreateURL(t *testing.T) {
 	tests := []struct {
 		name          string
 		originalURL   string
+		alias         string
+		ttlSeconds    int64
+		expiresAt     *timestamppb.Timestamp
+		redirect      int32
 		setup         func(*FakeStorage)
 		expectedShort string // если не пустая — ожидается именно она
 		expectedErr   error
@@ -75,13 +202,46 @@ func TestService_CreateURL(t *testing.T) {
 			expectedShort: "abc123",
 			expectedErr:   nil,
 		},
+		{
+			name:        "Время создания существующей ссылки",
+			originalURL: "https://example.com",
+			setup: func(f *FakeStorage) {
+				f.storage["abc123"] = "https://example.com"
+				f.createdAt["abc123"] = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+			},
+			expectedShort: "abc123",
+			expectedErr:   nil,
+		},
+		{
+			name:        "Повторное использование эквивалентного URL",
+			originalURL: "HTTPS://Example.com:443",
+			setup: func(f *FakeStorage) {
+				f.storage["abc123"] = "https://example.com"
+			},
+			expectedShort: "abc123",
+			expectedErr:   nil,
+		},
+		{
+			name:          "Недопустимая схема URL",
+			originalURL:   "javascript:alert(1)",
+			setup:         func(f *FakeStorage) {},
+			expectedShort: "",
+			expectedErr:   errors.New(`invalid URL: scheme "javascript" is not allowed`),
+		},
+		{
+			name:          "Пустой URL",
+			originalURL:   "",
+			setup:         func(f *FakeStorage) {},
+			expectedShort: "",
+			expectedErr:   errors.New("invalid URL: empty URL"),
+		},
 		{
 			name:        "Конфликт короткого URL (повторная генерация)",
 			originalURL: "https://newexample.com",
 			setup: func(f *FakeStorage) {
 				// При первом вызове Save вернётся ошибка о конфликте,
 				// затем ошибка сбрасывается, и операция должна пройти успешно.
-				f.err = errors.New("short URL already exists")
+				f.err = storage.ErrShortURLConflict
 			},
 			expectedShort: "",
 			expectedErr:   nil,
@@ -95,6 +255,91 @@ func TestService_CreateURL(t *testing.T) {
 			expectedShort: "",
 			expectedErr:   errors.New("unexpected error"),
 		},
+		{
+			name:          "Отрицательный TTL",
+			originalURL:   "https://example.com/tmp",
+			setup:         func(f *FakeStorage) {},
+			ttlSeconds:    -1,
+			expectedShort: "",
+			expectedErr:   ErrInvalidExpiry,
+		},
+		{
+			name:          "Время истечения в прошлом",
+			originalURL:   "https://example.com/tmp",
+			setup:         func(f *FakeStorage) {},
+			expiresAt:     timestamppb.New(time.Now().Add(-time.Hour)),
+			expectedShort: "",
+			expectedErr:   ErrInvalidExpiry,
+		},
+		{
+			name:          "Одновременно заданы TTL и время истечения",
+			originalURL:   "https://example.com/tmp",
+			setup:         func(f *FakeStorage) {},
+			ttlSeconds:    60,
+			expiresAt:     timestamppb.New(time.Now().Add(time.Hour)),
+			expectedShort: "",
+			expectedErr:   ErrInvalidExpiry,
+		},
+		{
+			name:          "Неподдерживаемый статус редиректа",
+			originalURL:   "https://example.com/moved",
+			setup:         func(f *FakeStorage) {},
+			redirect:      200,
+			expectedShort: "",
+			expectedErr:   ErrInvalidRedirectStatus,
+		},
+		{
+			name:          "Создание URL с пользовательским алиасом",
+			originalURL:   "https://example.com/sale",
+			alias:         "spring-sale",
+			setup:         func(f *FakeStorage) {},
+			expectedShort: "spring-sale",
+			expectedErr:   nil,
+		},
+		{
+			name:          "Слишком короткий алиас",
+			originalURL:   "https://example.com/sale",
+			alias:         "ab",
+			setup:         func(f *FakeStorage) {},
+			expectedShort: "",
+			expectedErr:   ErrInvalidAlias,
+		},
+		{
+			name:          "Алиас с недопустимыми символами",
+			originalURL:   "https://example.com/sale",
+			alias:         "spring/sale",
+			setup:         func(f *FakeStorage) {},
+			expectedShort: "",
+			expectedErr:   ErrInvalidAlias,
+		},
+		{
+			name:          "Алиас совпадает с путём сервера",
+			originalURL:   "https://example.com/sale",
+			alias:         "Healthz",
+			setup:         func(f *FakeStorage) {},
+			expectedShort: "",
+			expectedErr:   ErrInvalidAlias,
+		},
+		{
+			name:        "Алиас уже занят",
+			originalURL: "https://example.com/sale",
+			alias:       "spring-sale",
+			setup: func(f *FakeStorage) {
+				f.storage["spring-sale"] = "https://example.com/other"
+			},
+			expectedShort: "",
+			expectedErr:   ErrAliasTaken,
+		},
+		{
+			name:        "Алиас для уже сокращённого URL",
+			originalURL: "https://example.com/sale",
+			alias:       "spring-sale",
+			setup: func(f *FakeStorage) {
+				f.storage["abc123"] = "https://example.com/sale"
+			},
+			expectedShort: "",
+			expectedErr:   storage.ErrOriginalURLExists,
+		},
 	}
 
 	for _, tt := range tests {
@@ -104,20 +349,23 @@ func TestService_CreateURL(t *testing.T) {
 
 			s := NewService(fakeStorage)
 			req := &proto.CreateURLRequest{
-				OriginalUrl: tt.originalURL,
+				OriginalUrl:    tt.originalURL,
+				CustomAlias:    tt.alias,
+				TtlSeconds:     tt.ttlSeconds,
+				ExpiresAt:      tt.expiresAt,
+				RedirectStatus: tt.redirect,
 			}
 			resp, err := s.CreateURL(context.Background(), req)
-			// Метод gRPC возвращает ошибку только при критических сбоях, остальные ошибки передаются в поле Error.
+			// Ошибки возвращаются как gRPC-статус, исходная ошибка доступна через Error().
 			if tt.expectedErr != nil {
-				// Если ожидается ошибка, то err должен быть nil, а сообщение об ошибке в ответе должно совпадать.
-				assert.NoError(t, err)
-				assert.Equal(t, tt.expectedErr.Error(), resp.Error)
-				assert.Empty(t, resp.ShortUrl)
+				assert.EqualError(t, err, tt.expectedErr.Error())
+				assert.Nil(t, resp)
 			} else {
 				assert.NoError(t, err)
-				assert.Empty(t, resp.Error)
 				if tt.expectedShort != "" {
 					assert.Equal(t, tt.expectedShort, resp.ShortUrl)
+					assert.True(t, fakeStorage.createdAt[tt.expectedShort].Equal(resp.CreatedAt.AsTime()))
+					assert.Equal(t, fakeStorage.storage[tt.expectedShort], resp.OriginalUrl)
 				} else {
 					// Проверяем, что сгенерированный короткий URL имеет нужную длину и соответствует шаблону.
 					assert.Len(t, resp.ShortUrl, shortURLLength)
@@ -130,11 +378,12 @@ func TestService_CreateURL(t *testing.T) {
 
 func TestService_GetURL(t *testing.T) {
 	tests := []struct {
-		name        string
-		shortURL    string
-		setup       func(*FakeStorage)
-		expectedURL string
-		expectedErr error
+		name             string
+		shortURL         string
+		setup            func(*FakeStorage)
+		expectedURL      string
+		expectedRedirect int32
+		expectedErr      error
 	}{
 		{
 			name:     "Успешное получение URL",
@@ -145,6 +394,17 @@ func TestService_GetURL(t *testing.T) {
 			expectedURL: "https://example.com",
 			expectedErr: nil,
 		},
+		{
+			name:     "Получение URL с собственным статусом редиректа",
+			shortURL: "abc123",
+			setup: func(f *FakeStorage) {
+				f.storage["abc123"] = "https://example.com"
+				f.redirects["abc123"] = 301
+			},
+			expectedURL:      "https://example.com",
+			expectedRedirect: 301,
+			expectedErr:      nil,
+		},
 		{
 			name:        "URL не найден",
 			shortURL:    "xyz789",
@@ -152,6 +412,16 @@ func TestService_GetURL(t *testing.T) {
 			expectedURL: "",
 			expectedErr: storage.ErrNotFound,
 		},
+		{
+			name:     "Срок действия URL истёк",
+			shortURL: "abc123",
+			setup: func(f *FakeStorage) {
+				f.storage["abc123"] = "https://example.com"
+				f.expiresAt["abc123"] = time.Now().Add(-time.Minute)
+			},
+			expectedURL: "",
+			expectedErr: storage.ErrExpired,
+		},
 	}
 
 	for _, tt := range tests {
@@ -164,16 +434,392 @@ func TestService_GetURL(t *testing.T) {
 				ShortUrl: tt.shortURL,
 			}
 			resp, err := s.GetURL(context.Background(), req)
-			// Как и в CreateURL, ошибка в gRPC-методе передаётся через поле Error.
 			if tt.expectedErr != nil {
-				assert.NoError(t, err)
-				assert.Equal(t, tt.expectedErr.Error(), resp.Error)
-				assert.Empty(t, resp.OriginalUrl)
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Equal(t, codes.NotFound, status.Code(err))
+				assert.Nil(t, resp)
 			} else {
 				assert.NoError(t, err)
-				assert.Empty(t, resp.Error)
 				assert.Equal(t, tt.expectedURL, resp.OriginalUrl)
+				assert.Equal(t, tt.expectedRedirect, resp.RedirectStatus)
+			}
+		})
+	}
+}
+
+func TestService_GetStats(t *testing.T) {
+	tests := []struct {
+		name          string
+		shortURL      string
+		analytics     bool
+		bucketSeconds int64
+		ctx           context.Context
+		setup         func(*FakeStorage)
+		expectedTotal int64
+		expectedErr   error
+	}{
+		{
+			name:      "Статистика переходов",
+			shortURL:  "abc123",
+			analytics: true,
+			setup: func(f *FakeStorage) {
+				f.storage["abc123"] = "https://example.com"
+				f.owners["abc123"] = "alice"
+				f.clicks = []storage.Click{{ShortURL: "abc123"}, {ShortURL: "abc123"}, {ShortURL: "other"}}
+			},
+			expectedTotal: 2,
+			expectedErr:   nil,
+		},
+		{
+			name:      "Статистика истёкшей ссылки",
+			shortURL:  "abc123",
+			analytics: true,
+			setup: func(f *FakeStorage) {
+				f.storage["abc123"] = "https://example.com"
+				f.owners["abc123"] = "alice"
+				f.expiresAt["abc123"] = time.Now().Add(-time.Minute)
+				f.clicks = []storage.Click{{ShortURL: "abc123"}}
+			},
+			expectedTotal: 1,
+			expectedErr:   nil,
+		},
+		{
+			name:      "Запрос без ключа API",
+			shortURL:  "abc123",
+			analytics: true,
+			ctx:       context.Background(),
+			setup: func(f *FakeStorage) {
+				f.storage["abc123"] = "https://example.com"
+				f.owners["abc123"] = "alice"
+			},
+			expectedErr: ErrUnauthenticated,
+		},
+		{
+			name:      "Ссылка другого владельца",
+			shortURL:  "abc123",
+			analytics: true,
+			setup: func(f *FakeStorage) {
+				f.storage["abc123"] = "https://example.com"
+				f.owners["abc123"] = "bob"
+			},
+			expectedErr: ErrPermissionDenied,
+		},
+		{
+			name:        "Аналитика не настроена",
+			shortURL:    "abc123",
+			analytics:   false,
+			setup:       func(f *FakeStorage) {},
+			expectedErr: ErrAnalyticsDisabled,
+		},
+		{
+			name:        "URL не найден",
+			shortURL:    "xyz789",
+			analytics:   true,
+			setup:       func(f *FakeStorage) {},
+			expectedErr: storage.ErrNotFound,
+		},
+		{
+			name:          "Отрицательный интервал группировки",
+			shortURL:      "abc123",
+			analytics:     true,
+			bucketSeconds: -1,
+			setup:         func(f *FakeStorage) {},
+			expectedErr:   ErrInvalidStatsQuery,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeStorage := NewFakeStorage()
+			tt.setup(fakeStorage)
+
+			var opts []Option
+			if tt.analytics {
+				a := analytics.New(fakeStorage, analytics.Config{BufferSize: 10, BatchSize: 10, FlushInterval: time.Hour})
+				defer a.Close()
+				opts = append(opts, WithAnalytics(a))
+			}
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = ownerContext
+			}
+			s := NewService(fakeStorage, opts...)
+			resp, err := s.GetStats(ctx, &proto.GetStatsRequest{
+				ShortUrl:      tt.shortURL,
+				BucketSeconds: tt.bucketSeconds,
+			})
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedTotal, resp.TotalClicks)
+			}
+		})
+	}
+}
+
+func TestService_GetURLRecordsClick(t *testing.T) {
+	fakeStorage := NewFakeStorage()
+	fakeStorage.storage["abc123"] = "https://example.com"
+	a := analytics.New(fakeStorage, analytics.Config{BufferSize: 10, BatchSize: 10, FlushInterval: time.Hour})
+	s := NewService(fakeStorage, WithAnalytics(a))
+
+	ctx := analytics.ContextWithClient(context.Background(), analytics.Client{IP: "10.0.0.1", UserAgent: "curl/8.0"})
+	_, err := s.GetURL(ctx, &proto.GetURLRequest{ShortUrl: "abc123"})
+	assert.NoError(t, err)
+	_, err = s.GetURL(ctx, &proto.GetURLRequest{ShortUrl: "xyz789"})
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	a.Close()
+
+	assert.Len(t, fakeStorage.clicks, 1)
+	assert.Equal(t, "abc123", fakeStorage.clicks[0].ShortURL)
+	assert.Equal(t, "curl/8.0", fakeStorage.clicks[0].UserAgent)
+}
+
+// collisionCounter считает повторы генерации короткой ссылки и запоминает последнюю заполненность пространства кодов
+type collisionCounter struct {
+	collisions int
+	keyspace   KeyspaceStats
+}
+
+func (c *collisionCounter) ShortURLCollision() { c.collisions++ }
+
+func (c *collisionCounter) KeyspaceUsage(codeLength int, links int64, occupancy float64) {
+	c.keyspace = KeyspaceStats{CodeLength: codeLength, Links: links, Occupancy: occupancy}
+}
+
+func TestService_CreateURLCountsCollisions(t *testing.T) {
+	fakeStorage := NewFakeStorage()
+	fakeStorage.err = storage.ErrShortURLConflict
+	counter := &collisionCounter{}
+	s := NewService(fakeStorage, WithMetrics(counter))
+
+	_, err := s.CreateURL(context.Background(), &proto.CreateURLRequest{OriginalUrl: "https://example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, counter.collisions)
+}
+
+func TestService_CreateURLOwner(t *testing.T) {
+	fakeStorage := NewFakeStorage()
+	s := NewService(fakeStorage)
+
+	resp, err := s.CreateURL(ownerContext, &proto.CreateURLRequest{OriginalUrl: "https://example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", fakeStorage.owners[resp.ShortUrl])
+
+	resp, err = s.CreateURL(context.Background(), &proto.CreateURLRequest{OriginalUrl: "https://other.com"})
+	assert.NoError(t, err)
+	assert.Empty(t, fakeStorage.owners[resp.ShortUrl])
+
+	// С WithOwnerRequired ссылки без ключа API не создаются
+	s = NewService(fakeStorage, WithOwnerRequired())
+	_, err = s.CreateURL(context.Background(), &proto.CreateURLRequest{OriginalUrl: "https://third.com"})
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = s.BatchCreateURLs(context.Background(), &proto.BatchCreateURLsRequest{
+		Urls: []*proto.CreateURLRequest{{OriginalUrl: "https://third.com"}},
+	})
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+}
+
+func TestService_CreateURLTwoOwners(t *testing.T) {
+	s := NewService(memory.NewMemory())
+	bobContext := auth.ContextWithKey(context.Background(), storage.APIKey{ID: "key2", OwnerID: "bob"})
+
+	alice, err := s.CreateURL(ownerContext, &proto.CreateURLRequest{OriginalUrl: "https://example.com"})
+	assert.NoError(t, err)
+	// Тот же URL другого владельца получает свой код, а не код alice
+	bob, err := s.CreateURL(bobContext, &proto.CreateURLRequest{OriginalUrl: "https://example.com"})
+	assert.NoError(t, err)
+	assert.False(t, bob.Existing)
+	assert.NotEqual(t, alice.ShortUrl, bob.ShortUrl)
+
+	// Повторное сокращение возвращает код того же владельца
+	again, err := s.CreateURL(bobContext, &proto.CreateURLRequest{OriginalUrl: "https://example.com"})
+	assert.NoError(t, err)
+	assert.True(t, again.Existing)
+	assert.Equal(t, bob.ShortUrl, again.ShortUrl)
+
+	list, err := s.ListURLs(bobContext, &proto.ListURLsRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{bob.ShortUrl}, listedShortURLs(list))
+
+	_, err = s.UpdateURL(bobContext, &proto.UpdateURLRequest{ShortUrl: bob.ShortUrl, OriginalUrl: "https://example.com/bob"})
+	assert.NoError(t, err)
+	_, err = s.DeleteURL(bobContext, &proto.DeleteURLRequest{ShortUrl: bob.ShortUrl})
+	assert.NoError(t, err)
+
+	resp, err := s.GetURL(context.Background(), &proto.GetURLRequest{ShortUrl: alice.ShortUrl})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com", resp.OriginalUrl)
+}
+
+func TestService_GetURLInfo(t *testing.T) {
+	createdAt := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	expiresAt := time.Now().Add(time.Hour).UTC()
+	fakeStorage := NewFakeStorage()
+	fakeStorage.storage["abc123"] = "https://example.com"
+	fakeStorage.createdAt["abc123"] = createdAt
+	fakeStorage.expiresAt["abc123"] = expiresAt
+	fakeStorage.redirects["abc123"] = 301
+	a := analytics.New(fakeStorage, analytics.Config{BufferSize: 10, BatchSize: 10, FlushInterval: time.Hour})
+	s := NewService(fakeStorage, WithAnalytics(a))
+
+	resp, err := s.GetURLInfo(context.Background(), &proto.GetURLInfoRequest{ShortUrl: "abc123"})
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", resp.ShortUrl)
+	assert.Equal(t, "https://example.com", resp.OriginalUrl)
+	assert.Equal(t, createdAt, resp.CreatedAt.AsTime())
+	assert.Equal(t, expiresAt, resp.ExpiresAt.AsTime())
+	assert.Equal(t, int32(301), resp.RedirectStatus)
+
+	_, err = s.GetURLInfo(context.Background(), &proto.GetURLInfoRequest{ShortUrl: "xyz789"})
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	a.Close()
+
+	// Получение сведений не считается переходом по ссылке
+	assert.Empty(t, fakeStorage.clicks)
+}
+
+func TestService_DeleteURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		shortURL    string
+		ctx         context.Context
+		expectedErr error
+	}{
+		{
+			name:        "Успешное удаление URL",
+			shortURL:    "abc123",
+			expectedErr: nil,
+		},
+		{
+			name:        "URL не найден",
+			shortURL:    "xyz789",
+			expectedErr: storage.ErrNotFound,
+		},
+		{
+			name:        "Запрос без ключа API",
+			shortURL:    "abc123",
+			ctx:         context.Background(),
+			expectedErr: ErrUnauthenticated,
+		},
+		{
+			name:        "Ссылка другого владельца",
+			shortURL:    "def456",
+			expectedErr: ErrPermissionDenied,
+		},
+		{
+			name:        "Ссылка без владельца",
+			shortURL:    "ghi789",
+			expectedErr: nil,
+		},
+		{
+			name:        "Ссылка без владельца, запрос без ключа API",
+			shortURL:    "ghi789",
+			ctx:         context.Background(),
+			expectedErr: ErrUnauthenticated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeStorage := NewFakeStorage()
+			fakeStorage.storage["abc123"] = "https://example.com"
+			fakeStorage.owners["abc123"] = "alice"
+			fakeStorage.storage["def456"] = "https://other.com"
+			fakeStorage.owners["def456"] = "bob"
+			fakeStorage.storage["ghi789"] = "https://anonymous.com"
+
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = ownerContext
+			}
+			s := NewService(fakeStorage)
+			_, err := s.DeleteURL(ctx, &proto.DeleteURLRequest{ShortUrl: tt.shortURL})
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.NotContains(t, fakeStorage.storage, tt.shortURL)
+			}
+		})
+	}
+}
+
+func TestService_UpdateURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		shortURL    string
+		originalURL string
+		expectedErr error
+	}{
+		{
+			name:        "Успешное изменение URL",
+			shortURL:    "abc123",
+			originalURL: "https://example.com/new",
+			expectedErr: nil,
+		},
+		{
+			name:        "URL не найден",
+			shortURL:    "xyz789",
+			originalURL: "https://example.com/new",
+			expectedErr: storage.ErrNotFound,
+		},
+		{
+			name:        "Новый URL уже сокращён",
+			shortURL:    "abc123",
+			originalURL: "https://other.com",
+			expectedErr: storage.ErrOriginalURLExists,
+		},
+		{
+			name:        "Относительный URL",
+			shortURL:    "abc123",
+			originalURL: "/relative",
+			expectedErr: urlnorm.ErrInvalidURL,
+		},
+		{
+			name:        "Ссылка другого владельца",
+			shortURL:    "def456",
+			originalURL: "https://example.com/new",
+			expectedErr: ErrPermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeStorage := NewFakeStorage()
+			fakeStorage.storage["abc123"] = "https://example.com"
+			fakeStorage.owners["abc123"] = "alice"
+			fakeStorage.storage["def456"] = "https://other.com"
+			fakeStorage.owners["def456"] = "bob"
+
+			s := NewService(fakeStorage)
+			_, err := s.UpdateURL(ownerContext, &proto.UpdateURLRequest{
+				ShortUrl:    tt.shortURL,
+				OriginalUrl: tt.originalURL,
+			})
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.originalURL, fakeStorage.storage[tt.shortURL])
 			}
 		})
 	}
 }
+
+func TestService_Timeout(t *testing.T) {
+	fakeStorage := NewFakeStorage()
+	fakeStorage.storage["abc123"] = "https://example.com"
+
+	s := NewService(fakeStorage, WithTimeout(time.Minute))
+	_, err := s.GetURL(context.Background(), &proto.GetURLRequest{ShortUrl: "abc123"})
+	assert.NoError(t, err)
+
+	deadline, ok := fakeStorage.lastCtx.Deadline()
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+	// После завершения запроса контекст хранилища отменяется
+	assert.Error(t, fakeStorage.lastCtx.Err())
+}
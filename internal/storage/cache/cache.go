@@ -0,0 +1,320 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"url-shortener/internal/storage"
+)
+
+// defaultLoadTimeout ограничивает общую загрузку ссылки из хранилища, если Config.LoadTimeout не задан
+const defaultLoadTimeout = 5 * time.Second
+
+// Config содержит параметры кэша
+type Config struct {
+	Size        int           // максимальное число ссылок в кэше
+	TTL         time.Duration // время жизни найденной ссылки в кэше
+	NegativeTTL time.Duration // время жизни записи об отсутствующей ссылке, 0 отключает кэширование промахов
+	// LoadTimeout ограничивает загрузку ссылки, общую для одновременных запросов, 0 — defaultLoadTimeout
+	LoadTimeout time.Duration
+}
+
+// Stats содержит счётчики обращений к кэшу
+type Stats struct {
+	Hits   uint64 // ответы из кэша, включая закэшированные промахи
+	Misses uint64 // обращения к хранилищу
+}
+
+// Cache — декоратор storage.Storage, кэширующий результаты Get в ограниченном LRU-кэше.
+// Изменения ссылок проходят в хранилище и сбрасывают соответствующие записи кэша
+type Cache struct {
+	next storage.Storage
+	cfg  Config
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // начало списка — недавно использованные записи
+	// expiring — короткие URL закэшированных ссылок со сроком действия по оригинальному URL. Истёкшие ссылки
+	// хранилище заменяет при Save и Update, и по индексу их старые коды сбрасываются из кэша
+	expiring map[string]map[string]struct{}
+	// gen увеличивается при каждом сбросе, чтобы загрузка, начатая до изменения ссылки, не вернула в кэш старое значение
+	gen uint64
+
+	group  singleflight.Group
+	hits   atomic.Uint64
+	misses atomic.Uint64
+	now    func() time.Time
+}
+
+// entry — запись кэша. found == false означает закэшированный промах
+type entry struct {
+	shortURL  string
+	url       storage.URL
+	found     bool
+	expiresAt time.Time
+}
+
+// New создает кэш поверх хранилища next
+func New(next storage.Storage, cfg Config) *Cache {
+	return &Cache{
+		next:     next,
+		cfg:      cfg,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		expiring: make(map[string]map[string]struct{}),
+		now:      time.Now,
+	}
+}
+
+// Stats возвращает счётчики попаданий и промахов кэша
+func (c *Cache) Stats() Stats {
+	return Stats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
+
+// Get возвращает ссылку из кэша или загружает её из хранилища.
+// Одновременные загрузки одной ссылки выполняются одним запросом к хранилищу. Загрузка не зависит
+// от отмены контекста запроса, начавшего её: отменённый запрос перестаёт ждать, остальные получают результат
+func (c *Cache) Get(ctx context.Context, shortURL string) (storage.URL, error) {
+	if e, ok := c.lookup(shortURL); ok {
+		c.hits.Add(1)
+		return c.result(e)
+	}
+	c.misses.Add(1)
+
+	loaded := c.group.DoChan(shortURL, func() (interface{}, error) {
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.loadTimeout())
+		defer cancel()
+
+		gen := c.generation()
+		url, err := c.next.Get(loadCtx, shortURL)
+		// истёкшие ссылки хранилище возвращает с данными и ErrExpired; срок действия проверяется
+		// при каждом чтении из кэша, поэтому они кэшируются как найденные
+		switch {
+		case err == nil, errors.Is(err, storage.ErrExpired):
+			c.store(gen, entry{shortURL: shortURL, url: url, found: true})
+		case errors.Is(err, storage.ErrNotFound):
+			c.store(gen, entry{shortURL: shortURL})
+		}
+		return url, err
+	})
+	select {
+	case <-ctx.Done():
+		return storage.URL{}, ctx.Err()
+	case res := <-loaded:
+		return res.Val.(storage.URL), res.Err
+	}
+}
+
+// GetMany возвращает ссылки из кэша и загружает недостающие из хранилища одним запросом
+func (c *Cache) GetMany(ctx context.Context, shortURLs []string) ([]storage.BatchResult, error) {
+	results := make([]storage.BatchResult, len(shortURLs))
+	var missing []string
+	var positions []int // индекс в shortURLs для каждой ссылки из missing
+	for i, shortURL := range shortURLs {
+		if e, ok := c.lookup(shortURL); ok {
+			c.hits.Add(1)
+			results[i].URL, results[i].Err = c.result(e)
+			continue
+		}
+		c.misses.Add(1)
+		missing = append(missing, shortURL)
+		positions = append(positions, i)
+	}
+	if len(missing) == 0 {
+		return results, nil
+	}
+
+	gen := c.generation()
+	loaded, err := c.next.GetMany(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for j, res := range loaded {
+		results[positions[j]] = res
+		switch {
+		case res.Err == nil, errors.Is(res.Err, storage.ErrExpired):
+			c.store(gen, entry{shortURL: missing[j], url: res.URL, found: true})
+		case errors.Is(res.Err, storage.ErrNotFound):
+			c.store(gen, entry{shortURL: missing[j]})
+		}
+	}
+	return results, nil
+}
+
+// Save сохраняет ссылку и сбрасывает кэш для её короткого URL, в том числе закэшированный промах,
+// и для ссылок со сроком действия на тот же оригинальный URL, которые хранилище могло заменить
+func (c *Cache) Save(ctx context.Context, url storage.URL) (string, error) {
+	defer c.invalidateOriginals([]string{url.ShortURL}, []string{url.OriginalURL})
+	return c.next.Save(ctx, url)
+}
+
+// SaveMany сохраняет пакет ссылок и сбрасывает кэш для их коротких URL, как Save
+func (c *Cache) SaveMany(ctx context.Context, urls []storage.URL) ([]storage.BatchResult, error) {
+	shortURLs := make([]string, len(urls))
+	originalURLs := make([]string, len(urls))
+	for i, url := range urls {
+		shortURLs[i] = url.ShortURL
+		originalURLs[i] = url.OriginalURL
+	}
+	defer c.invalidateOriginals(shortURLs, originalURLs)
+	return c.next.SaveMany(ctx, urls)
+}
+
+// Delete удаляет ссылку и сбрасывает её из кэша
+func (c *Cache) Delete(ctx context.Context, shortURL string) error {
+	defer c.invalidate(shortURL)
+	return c.next.Delete(ctx, shortURL)
+}
+
+// Update меняет оригинальный URL ссылки и сбрасывает её из кэша вместе со ссылками со сроком действия
+// на новый оригинальный URL, которые хранилище могло заменить
+func (c *Cache) Update(ctx context.Context, shortURL, newOriginalURL string) error {
+	defer c.invalidateOriginals([]string{shortURL}, []string{newOriginalURL})
+	return c.next.Update(ctx, shortURL, newOriginalURL)
+}
+
+// DeleteExpired удаляет истёкшие ссылки из хранилища и из кэша
+func (c *Cache) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	deleted, err := c.next.DeleteExpired(ctx, now)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	for _, el := range c.entries {
+		if e := el.Value.(*entry); e.found && e.url.Expired(now) {
+			c.remove(el)
+		}
+	}
+	return deleted, err
+}
+
+// Count возвращает число ссылок в хранилище
+func (c *Cache) Count(ctx context.Context) (int64, error) {
+	return c.next.Count(ctx)
+}
+
+// List возвращает страницу ссылок из хранилища в обход кэша
+func (c *Cache) List(ctx context.Context, query storage.ListQuery) ([]storage.URL, error) {
+	return c.next.List(ctx, query)
+}
+
+// lookup возвращает действующую запись кэша и помечает её как недавно использованную
+func (c *Cache) lookup(shortURL string) (*entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[shortURL]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if !c.now().Before(e.expiresAt) {
+		c.remove(el)
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return e, true
+}
+
+// result возвращает ссылку из записи кэша с учётом срока действия самой ссылки
+func (c *Cache) result(e *entry) (storage.URL, error) {
+	if !e.found {
+		return storage.URL{}, storage.ErrNotFound
+	}
+	if e.url.Expired(c.now()) {
+		return e.url, storage.ErrExpired
+	}
+	return e.url, nil
+}
+
+// store добавляет запись, если с начала загрузки кэш не сбрасывался, и вытесняет самые старые записи
+func (c *Cache) store(gen uint64, e entry) {
+	ttl := c.cfg.TTL
+	if !e.found {
+		ttl = c.cfg.NegativeTTL
+	}
+	if ttl <= 0 || c.cfg.Size <= 0 {
+		return
+	}
+	e.expiresAt = c.now().Add(ttl)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	if el, ok := c.entries[e.shortURL]; ok {
+		c.remove(el)
+	}
+	c.entries[e.shortURL] = c.lru.PushFront(&e)
+	if e.found && !e.url.ExpiresAt.IsZero() {
+		shortURLs := c.expiring[e.url.OriginalURL]
+		if shortURLs == nil {
+			shortURLs = make(map[string]struct{})
+			c.expiring[e.url.OriginalURL] = shortURLs
+		}
+		shortURLs[e.shortURL] = struct{}{}
+	}
+	for c.lru.Len() > c.cfg.Size {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove удаляет запись из кэша и из индекса expiring, вызывается под блокировкой
+func (c *Cache) remove(el *list.Element) {
+	e := el.Value.(*entry)
+	c.lru.Remove(el)
+	delete(c.entries, e.shortURL)
+	if shortURLs, ok := c.expiring[e.url.OriginalURL]; ok {
+		delete(shortURLs, e.shortURL)
+		if len(shortURLs) == 0 {
+			delete(c.expiring, e.url.OriginalURL)
+		}
+	}
+}
+
+// invalidate удаляет записи коротких URL из кэша
+func (c *Cache) invalidate(shortURLs ...string) {
+	c.invalidateOriginals(shortURLs, nil)
+}
+
+// invalidateOriginals удаляет из кэша записи коротких URL shortURLs и ссылок со сроком действия
+// на оригинальные URL originalURLs
+func (c *Cache) invalidateOriginals(shortURLs, originalURLs []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	for _, originalURL := range originalURLs {
+		for shortURL := range c.expiring[originalURL] {
+			shortURLs = append(shortURLs, shortURL)
+		}
+	}
+	for _, shortURL := range shortURLs {
+		// следующие чтения не должны присоединяться к загрузке, начатой до изменения
+		c.group.Forget(shortURL)
+		if el, ok := c.entries[shortURL]; ok {
+			c.remove(el)
+		}
+	}
+}
+
+// loadTimeout возвращает ограничение времени общей загрузки ссылки
+func (c *Cache) loadTimeout() time.Duration {
+	if c.cfg.LoadTimeout > 0 {
+		return c.cfg.LoadTimeout
+	}
+	return defaultLoadTimeout
+}
+
+// generation возвращает текущее поколение кэша
+func (c *Cache) generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
@@ -0,0 +1,258 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"url-shortener/internal/storage"
+	"url-shortener/internal/storage/memory"
+)
+
+// countingStorage считает обращения к хранилищу и может задерживать Get до закрытия release
+type countingStorage struct {
+	storage.Storage
+	gets    atomic.Int64
+	release chan struct{}
+}
+
+func (s *countingStorage) Get(ctx context.Context, shortURL string) (storage.URL, error) {
+	s.gets.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	if err := ctx.Err(); err != nil {
+		return storage.URL{}, err
+	}
+	return s.Storage.Get(ctx, shortURL)
+}
+
+func newTestCache(cfg Config) (*Cache, *countingStorage) {
+	next := &countingStorage{Storage: memory.NewMemory()}
+	return New(next, cfg), next
+}
+
+var testConfig = Config{Size: 100, TTL: time.Minute, NegativeTTL: time.Minute}
+
+func TestCache_Get(t *testing.T) {
+	ctx := context.Background()
+	c, next := newTestCache(testConfig)
+	_, err := c.Save(ctx, storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		url, err := c.Get(ctx, "abc123")
+		assert.NoError(t, err)
+		assert.Equal(t, "https://example.com", url.OriginalURL)
+	}
+	// Закэшированный промах тоже не доходит до хранилища
+	for i := 0; i < 3; i++ {
+		_, err := c.Get(ctx, "xyz789")
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	}
+
+	assert.Equal(t, int64(2), next.gets.Load())
+	assert.Equal(t, Stats{Hits: 4, Misses: 2}, c.Stats())
+}
+
+func TestCache_Invalidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		mutate   func(c *Cache) error
+		shortURL string
+		expected string
+		err      error
+	}{
+		{
+			name: "Сохранение сбрасывает закэшированный промах",
+			mutate: func(c *Cache) error {
+				_, err := c.Save(context.Background(), storage.URL{ShortURL: "xyz789", OriginalURL: "https://new.com"})
+				return err
+			},
+			shortURL: "xyz789",
+			expected: "https://new.com",
+		},
+		{
+			name: "Пакетное сохранение сбрасывает закэшированный промах",
+			mutate: func(c *Cache) error {
+				_, err := c.SaveMany(context.Background(), []storage.URL{{ShortURL: "xyz789", OriginalURL: "https://new.com"}})
+				return err
+			},
+			shortURL: "xyz789",
+			expected: "https://new.com",
+		},
+		{
+			name: "Обновление сбрасывает ссылку",
+			mutate: func(c *Cache) error {
+				return c.Update(context.Background(), "abc123", "https://example.com/new")
+			},
+			shortURL: "abc123",
+			expected: "https://example.com/new",
+		},
+		{
+			name: "Удаление сбрасывает ссылку",
+			mutate: func(c *Cache) error {
+				return c.Delete(context.Background(), "abc123")
+			},
+			shortURL: "abc123",
+			err:      storage.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			c, _ := newTestCache(testConfig)
+			_, err := c.Save(ctx, storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"})
+			require.NoError(t, err)
+			c.Get(ctx, "abc123") //nolint:errcheck
+			c.Get(ctx, "xyz789") //nolint:errcheck
+
+			require.NoError(t, tt.mutate(c))
+
+			url, err := c.Get(ctx, tt.shortURL)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, url.OriginalURL)
+		})
+	}
+}
+
+func TestCache_TTLAndEviction(t *testing.T) {
+	ctx := context.Background()
+	c, next := newTestCache(Config{Size: 2, TTL: time.Minute, NegativeTTL: time.Second})
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	for _, shortURL := range []string{"aaa", "bbb", "ccc"} {
+		_, err := c.Save(ctx, storage.URL{ShortURL: shortURL, OriginalURL: "https://example.com/" + shortURL})
+		require.NoError(t, err)
+	}
+
+	c.Get(ctx, "aaa") //nolint:errcheck
+	c.Get(ctx, "bbb") //nolint:errcheck
+	c.Get(ctx, "aaa") //nolint:errcheck
+	// ccc вытесняет bbb как давно не использованную
+	c.Get(ctx, "ccc") //nolint:errcheck
+	assert.Equal(t, int64(3), next.gets.Load())
+	c.Get(ctx, "aaa") //nolint:errcheck
+	assert.Equal(t, int64(3), next.gets.Load())
+	c.Get(ctx, "bbb") //nolint:errcheck
+	assert.Equal(t, int64(4), next.gets.Load())
+
+	// По истечении TTL ссылка загружается заново
+	now = now.Add(2 * time.Minute)
+	c.Get(ctx, "bbb") //nolint:errcheck
+	assert.Equal(t, int64(5), next.gets.Load())
+}
+
+func TestCache_ExpiredLink(t *testing.T) {
+	ctx := context.Background()
+	c, next := newTestCache(testConfig)
+	now := time.Now()
+	_, err := c.Save(ctx, storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com", ExpiresAt: now.Add(time.Second)})
+	require.NoError(t, err)
+	c.Get(ctx, "abc123") //nolint:errcheck
+
+	// Срок действия ссылки проверяется и для закэшированной записи
+	c.now = func() time.Time { return now.Add(2 * time.Second) }
+	_, err = c.Get(ctx, "abc123")
+	assert.ErrorIs(t, err, storage.ErrExpired)
+
+	// Уже истёкшая ссылка кэшируется вместе с данными
+	_, err = c.Save(ctx, storage.URL{ShortURL: "old", OriginalURL: "https://old.com", ExpiresAt: now.Add(-time.Second)})
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		url, err := c.Get(ctx, "old")
+		assert.ErrorIs(t, err, storage.ErrExpired)
+		assert.Equal(t, "https://old.com", url.OriginalURL)
+	}
+	assert.Equal(t, int64(2), next.gets.Load())
+
+	// Хранилище заменяет истёкшую ссылку на тот же оригинальный URL, и её старый код сбрасывается из кэша
+	_, err = c.Save(ctx, storage.URL{ShortURL: "new", OriginalURL: "https://old.com"})
+	require.NoError(t, err)
+	_, err = c.Get(ctx, "old")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	assert.Equal(t, int64(3), next.gets.Load())
+}
+
+func TestCache_Singleflight(t *testing.T) {
+	ctx := context.Background()
+	c, next := newTestCache(testConfig)
+	_, err := c.Save(ctx, storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"})
+	require.NoError(t, err)
+	next.release = make(chan struct{})
+
+	const readers = 10
+	var wg sync.WaitGroup
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			url, err := c.Get(ctx, "abc123")
+			assert.NoError(t, err)
+			assert.Equal(t, "https://example.com", url.OriginalURL)
+		}()
+	}
+	assert.Eventually(t, func() bool { return c.Stats().Misses == readers }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(next.release)
+	wg.Wait()
+
+	assert.Equal(t, int64(1), next.gets.Load())
+}
+
+func TestCache_SingleflightCanceledCaller(t *testing.T) {
+	c, next := newTestCache(testConfig)
+	_, err := c.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"})
+	require.NoError(t, err)
+	next.release = make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := c.Get(ctx, "abc123")
+		first <- err
+	}()
+	assert.Eventually(t, func() bool { return next.gets.Load() == 1 }, time.Second, time.Millisecond)
+	second := make(chan storage.URL, 1)
+	go func() {
+		url, err := c.Get(context.Background(), "abc123")
+		assert.NoError(t, err)
+		second <- url
+	}()
+	assert.Eventually(t, func() bool { return c.Stats().Misses == 2 }, time.Second, time.Millisecond)
+
+	// Отменённый запрос перестаёт ждать, но общая загрузка продолжается для остальных
+	cancel()
+	assert.ErrorIs(t, <-first, context.Canceled)
+	close(next.release)
+	assert.Equal(t, "https://example.com", (<-second).OriginalURL)
+	assert.Equal(t, int64(1), next.gets.Load())
+}
+
+func TestCache_GetMany(t *testing.T) {
+	ctx := context.Background()
+	c, next := newTestCache(testConfig)
+	_, err := c.Save(ctx, storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"})
+	require.NoError(t, err)
+	c.Get(ctx, "abc123") //nolint:errcheck
+
+	results, err := c.GetMany(ctx, []string{"abc123", "xyz789"})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, "https://example.com", results[0].URL.OriginalURL)
+		assert.ErrorIs(t, results[1].Err, storage.ErrNotFound)
+	}
+	// Промах из пакетного запроса закэширован
+	_, err = c.Get(ctx, "xyz789")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	assert.Equal(t, int64(1), next.gets.Load())
+}
@@ -0,0 +1,524 @@
+package file
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"url-shortener/internal/storage"
+)
+
+var (
+	urlsBucket      = []byte("urls")            // короткий URL -> storage.URL в JSON
+	originalsBucket = []byte("owner_originals") // storage.OriginalKey -> короткий URL
+	clicksBucket    = []byte("clicks")          // короткий URL -> вложенный бакет переходов
+	keysBucket      = []byte("keys")            // хеш ключа API -> storage.APIKey в JSON
+
+	// legacyOriginalsBucket — индекс оригинальных URL без владельца из прежних версий,
+	// при открытии файла заменяется на originalsBucket
+	legacyOriginalsBucket = []byte("originals")
+)
+
+// File реализует хранилище URL во встроенной базе bbolt в одном файле на диске
+type File struct {
+	db *bolt.DB
+}
+
+// NewFile открывает файл хранилища по пути path, создавая его при необходимости
+func NewFile(path string) (*File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{urlsBucket, originalsBucket, clicksBucket, keysBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return migrateOriginals(tx)
+	})
+	if err != nil {
+		db.Close() //nolint:errcheck
+		return nil, err
+	}
+	return &File{db: db}, nil
+}
+
+// migrateOriginals заполняет индекс originalsBucket по ссылкам файла прежней версии и удаляет
+// индекс legacyOriginalsBucket
+func migrateOriginals(tx *bolt.Tx) error {
+	if tx.Bucket(legacyOriginalsBucket) == nil {
+		return nil
+	}
+	originals := tx.Bucket(originalsBucket)
+	err := tx.Bucket(urlsBucket).ForEach(func(_, data []byte) error {
+		var url storage.URL
+		if err := json.Unmarshal(data, &url); err != nil {
+			return err
+		}
+		return originals.Put([]byte(storage.OriginalKey(url.OwnerID, url.OriginalURL)), []byte(url.ShortURL))
+	})
+	if err != nil {
+		return err
+	}
+	return tx.DeleteBucket(legacyOriginalsBucket)
+}
+
+// Close закрывает файл хранилища
+func (s *File) Close() error {
+	return s.db.Close()
+}
+
+// Ping проверяет, что файл хранилища открыт и доступен для чтения
+func (s *File) Ping(_ context.Context) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(urlsBucket) == nil {
+			return bolt.ErrBucketNotFound
+		}
+		return nil
+	})
+}
+
+// Save сохраняет ссылку, возвращает существующий короткий URL если оригинальный уже сохранен тем же владельцем.
+// Истёкшие, но ещё не удалённые ссылки не мешают сохранению
+func (s *File) Save(_ context.Context, url storage.URL) (string, error) {
+	var saved storage.URL
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		saved, err = save(tx, url, time.Now())
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return saved.ShortURL, nil
+}
+
+// SaveMany сохраняет пакет ссылок в одной транзакции
+func (s *File) SaveMany(_ context.Context, urls []storage.URL) ([]storage.BatchResult, error) {
+	results := make([]storage.BatchResult, len(urls))
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		now := time.Now()
+		for i, url := range urls {
+			saved, err := save(tx, url, now)
+			if err != nil && !isItemError(err) {
+				return err
+			}
+			results[i] = storage.BatchResult{URL: saved, Err: err}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Get возвращает ссылку по её короткой версии
+func (s *File) Get(_ context.Context, shortURL string) (storage.URL, error) {
+	var url storage.URL
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		url, err = get(tx, shortURL, time.Now())
+		return err
+	})
+	return url, err
+}
+
+// GetMany возвращает пакет ссылок по их коротким версиям
+func (s *File) GetMany(_ context.Context, shortURLs []string) ([]storage.BatchResult, error) {
+	results := make([]storage.BatchResult, len(shortURLs))
+	err := s.db.View(func(tx *bolt.Tx) error {
+		now := time.Now()
+		for i, shortURL := range shortURLs {
+			url, err := get(tx, shortURL, now)
+			if err != nil && !isItemError(err) {
+				return err
+			}
+			results[i] = storage.BatchResult{URL: url, Err: err}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Delete удаляет ссылку по её короткой версии
+func (s *File) Delete(_ context.Context, shortURL string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		url, ok, err := lookup(tx, shortURL)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return storage.ErrNotFound
+		}
+		return purge(tx, url)
+	})
+}
+
+// Update меняет оригинальный URL короткой ссылки. Истёкшая ссылка владельца с новым оригинальным URL
+// не мешает изменению и удаляется
+func (s *File) Update(_ context.Context, shortURL, newOriginalURL string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		url, ok, err := lookup(tx, shortURL)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return storage.ErrNotFound
+		}
+		if url.OriginalURL == newOriginalURL {
+			return nil
+		}
+		if existingShortURL := tx.Bucket(originalsBucket).Get([]byte(storage.OriginalKey(url.OwnerID, newOriginalURL))); existingShortURL != nil {
+			existing, ok, err := lookup(tx, string(existingShortURL))
+			if err != nil {
+				return err
+			}
+			if ok && !existing.Expired(time.Now()) {
+				return storage.ErrOriginalURLExists
+			}
+			if ok {
+				if err := purge(tx, existing); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := remove(tx, url); err != nil {
+			return err
+		}
+		url.OriginalURL = newOriginalURL
+		return put(tx, url)
+	})
+}
+
+// DeleteExpired удаляет ссылки, срок действия которых истёк к моменту now
+func (s *File) DeleteExpired(_ context.Context, now time.Time) (int64, error) {
+	var deleted int64
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		// bbolt не допускает изменения бакета во время обхода, поэтому ссылки сначала собираются
+		var expired []storage.URL
+		err := tx.Bucket(urlsBucket).ForEach(func(_, data []byte) error {
+			var url storage.URL
+			if err := json.Unmarshal(data, &url); err != nil {
+				return err
+			}
+			if url.Expired(now) {
+				expired = append(expired, url)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, url := range expired {
+			if err := purge(tx, url); err != nil {
+				return err
+			}
+		}
+		deleted = int64(len(expired))
+		return nil
+	})
+	return deleted, err
+}
+
+// Count возвращает число хранимых ссылок
+func (s *File) Count(_ context.Context) (int64, error) {
+	var count int64
+	err := s.db.View(func(tx *bolt.Tx) error {
+		count = int64(tx.Bucket(urlsBucket).Stats().KeyN)
+		return nil
+	})
+	return count, err
+}
+
+// List возвращает страницу ссылок владельца. Индекса по владельцу в файле нет,
+// поэтому ссылки перебираются целиком и отбираются storage.Page
+func (s *File) List(_ context.Context, query storage.ListQuery) ([]storage.URL, error) {
+	var urls []storage.URL
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(urlsBucket).ForEach(func(_, data []byte) error {
+			var url storage.URL
+			if err := json.Unmarshal(data, &url); err != nil {
+				return err
+			}
+			if url.OwnerID == query.OwnerID {
+				urls = append(urls, url)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return storage.Page(urls, query, time.Now()), nil
+}
+
+// SaveClicks сохраняет пачку переходов в одной транзакции
+func (s *File) SaveClicks(_ context.Context, clicks []storage.Click) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		root := tx.Bucket(clicksBucket)
+		for _, click := range clicks {
+			b, err := root.CreateBucketIfNotExists([]byte(click.ShortURL))
+			if err != nil {
+				return err
+			}
+			seq, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+			data, err := json.Marshal(click)
+			if err != nil {
+				return err
+			}
+			key := make([]byte, 8)
+			binary.BigEndian.PutUint64(key, seq)
+			if err := b.Put(key, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ClickStats возвращает статистику переходов, сгруппированную по интервалам
+func (s *File) ClickStats(_ context.Context, query storage.ClickStatsQuery) (storage.ClickStats, error) {
+	var stats storage.ClickStats
+	visitors := make(map[string]struct{})
+	buckets := make(map[time.Time]int64)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(clicksBucket).Bucket([]byte(query.ShortURL))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(_, data []byte) error {
+			var click storage.Click
+			if err := json.Unmarshal(data, &click); err != nil {
+				return err
+			}
+			if !query.From.IsZero() && click.ClickedAt.Before(query.From) {
+				return nil
+			}
+			if !query.To.IsZero() && !click.ClickedAt.Before(query.To) {
+				return nil
+			}
+			stats.TotalClicks++
+			visitors[click.IPHash] = struct{}{}
+			buckets[storage.BucketStart(click.ClickedAt.UTC(), query.Bucket)]++
+			return nil
+		})
+	})
+	if err != nil {
+		return storage.ClickStats{}, err
+	}
+	stats.UniqueVisitors = int64(len(visitors))
+	for start, clicks := range buckets {
+		stats.Buckets = append(stats.Buckets, storage.ClickBucket{Start: start, Clicks: clicks})
+	}
+	sort.Slice(stats.Buckets, func(i, j int) bool {
+		return stats.Buckets[i].Start.Before(stats.Buckets[j].Start)
+	})
+	return stats, nil
+}
+
+// SaveKey сохраняет ключ API
+func (s *File) SaveKey(_ context.Context, key storage.APIKey) error {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(keysBucket).Put([]byte(key.Hash), data)
+	})
+}
+
+// KeyByHash возвращает ключ API по хешу
+func (s *File) KeyByHash(_ context.Context, hash string) (storage.APIKey, error) {
+	var key storage.APIKey
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(keysBucket).Get([]byte(hash))
+		if data == nil {
+			return storage.ErrKeyNotFound
+		}
+		return json.Unmarshal(data, &key)
+	})
+	if err != nil {
+		return storage.APIKey{}, err
+	}
+	return key, nil
+}
+
+// ListKeys возвращает ключи API в порядке создания
+func (s *File) ListKeys(_ context.Context) ([]storage.APIKey, error) {
+	var keys []storage.APIKey
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(keysBucket).ForEach(func(_, data []byte) error {
+			var key storage.APIKey
+			if err := json.Unmarshal(data, &key); err != nil {
+				return err
+			}
+			keys = append(keys, key)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	storage.SortKeys(keys)
+	return keys, nil
+}
+
+// RevokeKey отзывает ключ API. Повторный отзыв не меняет время отзыва
+func (s *File) RevokeKey(_ context.Context, id string, now time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(keysBucket)
+		c := b.Cursor()
+		for hash, data := c.First(); hash != nil; hash, data = c.Next() {
+			var key storage.APIKey
+			if err := json.Unmarshal(data, &key); err != nil {
+				return err
+			}
+			if key.ID != id {
+				continue
+			}
+			if key.Revoked() {
+				return nil
+			}
+			key.RevokedAt = now
+			data, err := json.Marshal(key)
+			if err != nil {
+				return err
+			}
+			return b.Put(hash, data)
+		}
+		return storage.ErrKeyNotFound
+	})
+}
+
+// save сохраняет ссылку и возвращает её или уже существующую ссылку владельца с тем же оригинальным URL
+func save(tx *bolt.Tx, url storage.URL, now time.Time) (storage.URL, error) {
+	existing, ok, err := lookup(tx, url.ShortURL)
+	if err != nil {
+		return storage.URL{}, err
+	}
+	if ok {
+		if !existing.Expired(now) {
+			return storage.URL{}, storage.ErrShortURLConflict
+		}
+		if err := purge(tx, existing); err != nil {
+			return storage.URL{}, err
+		}
+	}
+	if shortURL := tx.Bucket(originalsBucket).Get([]byte(storage.OriginalKey(url.OwnerID, url.OriginalURL))); shortURL != nil {
+		existing, ok, err := lookup(tx, string(shortURL))
+		if err != nil {
+			return storage.URL{}, err
+		}
+		if ok && !existing.Expired(now) {
+			return existing, nil
+		}
+		if ok {
+			if err := purge(tx, existing); err != nil {
+				return storage.URL{}, err
+			}
+		}
+	}
+
+	if err := put(tx, url); err != nil {
+		return storage.URL{}, err
+	}
+	return url, nil
+}
+
+// get возвращает ссылку по короткой версии с учётом срока действия
+func get(tx *bolt.Tx, shortURL string, now time.Time) (storage.URL, error) {
+	url, ok, err := lookup(tx, shortURL)
+	if err != nil {
+		return storage.URL{}, err
+	}
+	if !ok {
+		return storage.URL{}, storage.ErrNotFound
+	}
+	if url.Expired(now) {
+		return url, storage.ErrExpired
+	}
+	return url, nil
+}
+
+// lookup читает ссылку из бакета urls, ok == false если ссылки нет
+func lookup(tx *bolt.Tx, shortURL string) (storage.URL, bool, error) {
+	data := tx.Bucket(urlsBucket).Get([]byte(shortURL))
+	if data == nil {
+		return storage.URL{}, false, nil
+	}
+	var url storage.URL
+	if err := json.Unmarshal(data, &url); err != nil {
+		return storage.URL{}, false, err
+	}
+	return url, true, nil
+}
+
+// put записывает ссылку в оба индекса. Ключи проверяются заранее,
+// чтобы недопустимая ссылка не оставила в транзакции половину записи
+func put(tx *bolt.Tx, url storage.URL) error {
+	originalKey := storage.OriginalKey(url.OwnerID, url.OriginalURL)
+	for _, key := range []string{url.ShortURL, originalKey} {
+		if key == "" || len(key) > bolt.MaxKeySize {
+			return fmt.Errorf("%w: key length %d", storage.ErrInvalid, len(key))
+		}
+	}
+	data, err := json.Marshal(url)
+	if err != nil {
+		return err
+	}
+	if err := tx.Bucket(urlsBucket).Put([]byte(url.ShortURL), data); err != nil {
+		return err
+	}
+	return tx.Bucket(originalsBucket).Put([]byte(originalKey), []byte(url.ShortURL))
+}
+
+// remove удаляет ссылку из обоих индексов
+func remove(tx *bolt.Tx, url storage.URL) error {
+	if err := tx.Bucket(urlsBucket).Delete([]byte(url.ShortURL)); err != nil {
+		return err
+	}
+	return tx.Bucket(originalsBucket).Delete([]byte(storage.OriginalKey(url.OwnerID, url.OriginalURL)))
+}
+
+// purge удаляет ссылку вместе с её переходами, чтобы новая ссылка с тем же кодом не унаследовала статистику
+func purge(tx *bolt.Tx, url storage.URL) error {
+	if err := remove(tx, url); err != nil {
+		return err
+	}
+	clicks := tx.Bucket(clicksBucket)
+	if clicks.Bucket([]byte(url.ShortURL)) == nil {
+		return nil
+	}
+	return clicks.DeleteBucket([]byte(url.ShortURL))
+}
+
+// isItemError сообщает, относится ли ошибка к отдельной ссылке пакета, а не к хранилищу целиком
+func isItemError(err error) bool {
+	for _, itemErr := range []error{storage.ErrShortURLConflict, storage.ErrNotFound, storage.ErrExpired, storage.ErrInvalid} {
+		if errors.Is(err, itemErr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,364 @@
+package file
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+	"url-shortener/internal/storage"
+)
+
+func newTestFile(t *testing.T) *File {
+	t.Helper()
+	s, err := NewFile(filepath.Join(t.TempDir(), "urls.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() }) //nolint:errcheck
+	return s
+}
+
+// Тест для метода Save
+func TestFile_Save(t *testing.T) {
+	tests := []struct {
+		name          string
+		url           storage.URL
+		setup         func(s *File)
+		expectedShort string
+		expectedErr   error
+	}{
+		{
+			name:          "Успешное сохранение нового URL",
+			url:           storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"},
+			setup:         func(s *File) {},
+			expectedShort: "abc123",
+		},
+		{
+			name: "Повторное использование существующего originalURL",
+			url:  storage.URL{ShortURL: "xyz789", OriginalURL: "https://example.com"},
+			setup: func(s *File) {
+				s.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"}) //nolint:errcheck
+			},
+			expectedShort: "abc123",
+		},
+		{
+			name: "Тот же originalURL другого владельца",
+			url:  storage.URL{ShortURL: "xyz789", OriginalURL: "https://example.com", OwnerID: "bob"},
+			setup: func(s *File) {
+				s.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com", OwnerID: "alice"}) //nolint:errcheck
+			},
+			expectedShort: "xyz789",
+		},
+		{
+			name: "Ошибка из-за дублирования shortURL",
+			url:  storage.URL{ShortURL: "abc123", OriginalURL: "https://newexample.com"},
+			setup: func(s *File) {
+				s.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"}) //nolint:errcheck
+			},
+			expectedErr: storage.ErrShortURLConflict,
+		},
+		{
+			name: "Замена истёкшей ссылки на тот же originalURL",
+			url:  storage.URL{ShortURL: "xyz789", OriginalURL: "https://example.com"},
+			setup: func(s *File) {
+				s.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com", ExpiresAt: time.Now().Add(-time.Minute)}) //nolint:errcheck
+			},
+			expectedShort: "xyz789",
+		},
+		{
+			name:        "Слишком длинный оригинальный URL",
+			url:         storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com/" + strings.Repeat("a", 40000)},
+			setup:       func(s *File) {},
+			expectedErr: storage.ErrInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestFile(t)
+			tt.setup(s)
+
+			shortURL, err := s.Save(context.Background(), tt.url)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Empty(t, shortURL)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedShort, shortURL)
+		})
+	}
+}
+
+func TestFile_Get(t *testing.T) {
+	s := newTestFile(t)
+	createdAt := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	s.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com", RedirectStatus: 301, CreatedAt: createdAt, OwnerID: "alice"}) //nolint:errcheck
+	s.Save(context.Background(), storage.URL{ShortURL: "def456", OriginalURL: "https://expired.com", ExpiresAt: time.Now().Add(-time.Minute)})                     //nolint:errcheck
+
+	url, err := s.Get(context.Background(), "abc123")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com", url.OriginalURL)
+	assert.Equal(t, int32(301), url.RedirectStatus)
+	assert.True(t, createdAt.Equal(url.CreatedAt))
+	assert.Equal(t, "alice", url.OwnerID)
+
+	url, err = s.Get(context.Background(), "def456")
+	assert.ErrorIs(t, err, storage.ErrExpired)
+	assert.Equal(t, "https://expired.com", url.OriginalURL)
+	_, err = s.Get(context.Background(), "xyz789")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestFile_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.db")
+	s, err := NewFile(path)
+	require.NoError(t, err)
+	_, err = s.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"})
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	s, err = NewFile(path)
+	require.NoError(t, err)
+	defer s.Close() //nolint:errcheck
+
+	url, err := s.Get(context.Background(), "abc123")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com", url.OriginalURL)
+	// Индекс оригинальных URL тоже восстановлен
+	shortURL, err := s.Save(context.Background(), storage.URL{ShortURL: "xyz789", OriginalURL: "https://example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", shortURL)
+}
+
+func TestFile_MigrateOriginals(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.db")
+	s, err := NewFile(path)
+	require.NoError(t, err)
+	_, err = s.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com", OwnerID: "alice"})
+	require.NoError(t, err)
+	// Индекс оригинальных URL в формате прежних версий, без владельца
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(originalsBucket); err != nil {
+			return err
+		}
+		legacy, err := tx.CreateBucket(legacyOriginalsBucket)
+		if err != nil {
+			return err
+		}
+		return legacy.Put([]byte("https://example.com"), []byte("abc123"))
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	s, err = NewFile(path)
+	require.NoError(t, err)
+	defer s.Close() //nolint:errcheck
+
+	shortURL, err := s.Save(context.Background(), storage.URL{ShortURL: "def456", OriginalURL: "https://example.com", OwnerID: "alice"})
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", shortURL)
+	err = s.db.View(func(tx *bolt.Tx) error {
+		assert.Nil(t, tx.Bucket(legacyOriginalsBucket))
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestFile_SaveManyGetMany(t *testing.T) {
+	s := newTestFile(t)
+	s.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"}) //nolint:errcheck
+
+	results, err := s.SaveMany(context.Background(), []storage.URL{
+		{ShortURL: "def456", OriginalURL: "https://example.com/new"},
+		{ShortURL: "ghi789", OriginalURL: "https://example.com"},
+		{ShortURL: "abc123", OriginalURL: "https://example.com/other"},
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 3) {
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, "def456", results[0].URL.ShortURL)
+		assert.Equal(t, "abc123", results[1].URL.ShortURL)
+		assert.ErrorIs(t, results[2].Err, storage.ErrShortURLConflict)
+	}
+
+	results, err = s.GetMany(context.Background(), []string{"def456", "xyz789"})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, "https://example.com/new", results[0].URL.OriginalURL)
+		assert.ErrorIs(t, results[1].Err, storage.ErrNotFound)
+	}
+}
+
+func TestFile_DeleteUpdate(t *testing.T) {
+	s := newTestFile(t)
+	s.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"}) //nolint:errcheck
+	s.Save(context.Background(), storage.URL{ShortURL: "def456", OriginalURL: "https://other.com"})   //nolint:errcheck
+
+	assert.ErrorIs(t, s.Update(context.Background(), "abc123", "https://other.com"), storage.ErrOriginalURLExists)
+	// Истёкшая ссылка на новый URL не мешает изменению и удаляется
+	s.Save(context.Background(), storage.URL{ShortURL: "stale", OriginalURL: "https://stale.com", ExpiresAt: time.Now().Add(-time.Minute)}) //nolint:errcheck
+	assert.NoError(t, s.Update(context.Background(), "def456", "https://stale.com"))
+	_, err := s.Get(context.Background(), "stale")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	url, err := s.Get(context.Background(), "def456")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://stale.com", url.OriginalURL)
+
+	assert.ErrorIs(t, s.Update(context.Background(), "xyz789", "https://example.com/new"), storage.ErrNotFound)
+	assert.NoError(t, s.Update(context.Background(), "abc123", "https://example.com/new"))
+
+	// Старый оригинальный URL освободился и может быть сокращён заново
+	shortURL, err := s.Save(context.Background(), storage.URL{ShortURL: "ghi789", OriginalURL: "https://example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, "ghi789", shortURL)
+
+	assert.NoError(t, s.Delete(context.Background(), "abc123"))
+	assert.ErrorIs(t, s.Delete(context.Background(), "abc123"), storage.ErrNotFound)
+	shortURL, err = s.Save(context.Background(), storage.URL{ShortURL: "jkl012", OriginalURL: "https://example.com/new"})
+	assert.NoError(t, err)
+	assert.Equal(t, "jkl012", shortURL)
+}
+
+func TestFile_DeleteExpired(t *testing.T) {
+	s := newTestFile(t)
+	now := time.Now()
+	s.Save(context.Background(), storage.URL{ShortURL: "expired", OriginalURL: "https://expired.com", ExpiresAt: now.Add(-time.Minute)}) //nolint:errcheck
+	s.Save(context.Background(), storage.URL{ShortURL: "active", OriginalURL: "https://active.com", ExpiresAt: now.Add(time.Hour)})      //nolint:errcheck
+	s.Save(context.Background(), storage.URL{ShortURL: "forever", OriginalURL: "https://forever.com"})                                   //nolint:errcheck
+
+	deleted, err := s.DeleteExpired(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	_, err = s.Get(context.Background(), "expired")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, err = s.Get(context.Background(), "active")
+	assert.NoError(t, err)
+}
+
+func TestFile_DeleteClicks(t *testing.T) {
+	s := newTestFile(t)
+	now := time.Now()
+	s.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"})                                    //nolint:errcheck
+	s.Save(context.Background(), storage.URL{ShortURL: "expired", OriginalURL: "https://expired.com", ExpiresAt: now.Add(-time.Minute)}) //nolint:errcheck
+	s.Save(context.Background(), storage.URL{ShortURL: "stale", OriginalURL: "https://stale.com", ExpiresAt: now.Add(-time.Minute)})     //nolint:errcheck
+	err := s.SaveClicks(context.Background(), []storage.Click{
+		{ShortURL: "abc123", ClickedAt: now, IPHash: "a"},
+		{ShortURL: "expired", ClickedAt: now, IPHash: "a"},
+		{ShortURL: "stale", ClickedAt: now, IPHash: "a"},
+	})
+	require.NoError(t, err)
+	clicks := func(shortURL string) int64 {
+		stats, err := s.ClickStats(context.Background(), storage.ClickStatsQuery{ShortURL: shortURL, Bucket: time.Hour})
+		require.NoError(t, err)
+		return stats.TotalClicks
+	}
+
+	// Изменение ссылки сохраняет её переходы, удаление — удаляет
+	require.NoError(t, s.Update(context.Background(), "abc123", "https://example.com/new"))
+	assert.Equal(t, int64(1), clicks("abc123"))
+	require.NoError(t, s.Delete(context.Background(), "abc123"))
+	assert.Zero(t, clicks("abc123"))
+
+	// Новая ссылка на месте истёкшей не наследует её переходы
+	_, err = s.Save(context.Background(), storage.URL{ShortURL: "stale", OriginalURL: "https://fresh.com"})
+	require.NoError(t, err)
+	assert.Zero(t, clicks("stale"))
+
+	_, err = s.DeleteExpired(context.Background(), now)
+	require.NoError(t, err)
+	assert.Zero(t, clicks("expired"))
+}
+
+func TestFile_ClickStats(t *testing.T) {
+	s := newTestFile(t)
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	err := s.SaveClicks(context.Background(), []storage.Click{
+		{ShortURL: "abc123", ClickedAt: base, IPHash: "a"},
+		{ShortURL: "abc123", ClickedAt: base.Add(10 * time.Minute), IPHash: "a"},
+		{ShortURL: "abc123", ClickedAt: base.Add(90 * time.Minute), IPHash: "b"},
+		{ShortURL: "def456", ClickedAt: base, IPHash: "c"},
+	})
+	assert.NoError(t, err)
+
+	stats, err := s.ClickStats(context.Background(), storage.ClickStatsQuery{ShortURL: "abc123", Bucket: time.Hour})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), stats.TotalClicks)
+	assert.Equal(t, int64(2), stats.UniqueVisitors)
+	assert.Equal(t, []storage.ClickBucket{
+		{Start: base, Clicks: 2},
+		{Start: base.Add(time.Hour), Clicks: 1},
+	}, stats.Buckets)
+
+	stats, err = s.ClickStats(context.Background(), storage.ClickStatsQuery{ShortURL: "xyz789", Bucket: time.Hour})
+	assert.NoError(t, err)
+	assert.Zero(t, stats.TotalClicks)
+}
+
+func TestFile_Ping(t *testing.T) {
+	s, err := NewFile(filepath.Join(t.TempDir(), "urls.db"))
+	require.NoError(t, err)
+	assert.NoError(t, s.Ping(context.Background()))
+	require.NoError(t, s.Close())
+	assert.Error(t, s.Ping(context.Background()))
+}
+
+func TestFile_Keys(t *testing.T) {
+	s := newTestFile(t)
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	second := storage.APIKey{ID: "key2", Hash: "hash2", OwnerID: "bob", CreatedAt: base.Add(time.Minute)}
+	first := storage.APIKey{ID: "key1", Hash: "hash1", OwnerID: "alice", Name: "ci", CreatedAt: base}
+	assert.NoError(t, s.SaveKey(context.Background(), second))
+	assert.NoError(t, s.SaveKey(context.Background(), first))
+
+	key, err := s.KeyByHash(context.Background(), "hash1")
+	assert.NoError(t, err)
+	assert.Equal(t, first, key)
+	_, err = s.KeyByHash(context.Background(), "unknown")
+	assert.ErrorIs(t, err, storage.ErrKeyNotFound)
+
+	keys, err := s.ListKeys(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.APIKey{first, second}, keys)
+
+	assert.NoError(t, s.RevokeKey(context.Background(), "key1", base.Add(time.Hour)))
+	assert.NoError(t, s.RevokeKey(context.Background(), "key1", base.Add(2*time.Hour)))
+	key, err = s.KeyByHash(context.Background(), "hash1")
+	assert.NoError(t, err)
+	assert.Equal(t, base.Add(time.Hour), key.RevokedAt)
+	assert.ErrorIs(t, s.RevokeKey(context.Background(), "unknown", base), storage.ErrKeyNotFound)
+}
+
+func TestFile_List(t *testing.T) {
+	s := newTestFile(t)
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	first := storage.URL{ShortURL: "a1", OriginalURL: "https://example.com/docs", CreatedAt: base, OwnerID: "alice"}
+	second := storage.URL{ShortURL: "a2", OriginalURL: "https://go.dev/doc", CreatedAt: base.Add(time.Minute), OwnerID: "alice"}
+	third := storage.URL{ShortURL: "a3", OriginalURL: "https://example.com/blog", CreatedAt: base.Add(2 * time.Minute), OwnerID: "alice"}
+	foreign := storage.URL{ShortURL: "b1", OriginalURL: "https://example.com/bob", CreatedAt: base, OwnerID: "bob"}
+	for _, url := range []storage.URL{second, foreign, third, first} {
+		_, err := s.Save(context.Background(), url)
+		require.NoError(t, err)
+	}
+
+	page, err := s.List(context.Background(), storage.ListQuery{OwnerID: "alice", Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.URL{third, second}, page)
+
+	cursor := storage.CursorOf(second)
+	page, err = s.List(context.Background(), storage.ListQuery{OwnerID: "alice", After: &cursor})
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.URL{first}, page)
+
+	page, err = s.List(context.Background(), storage.ListQuery{OwnerID: "alice", Domain: "example.com", Order: storage.OldestFirst})
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.URL{first, third}, page)
+
+	count, err := s.Count(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), count)
+}
@@ -1,50 +1,411 @@
 package memory
 
 import (
-	"errors"
+	"context"
+	"slices"
+	"sort"
 	"sync"
+	"time"
+
+	"url-shortener/internal/storage"
 )
 
 // Memory представляет потокобезопасное in-memory хранилище URL
 type Memory struct {
-	shortToOriginal map[string]string
-	originalToShort map[string]string
-	mu              sync.RWMutex
+	shortToOriginal map[string]storage.URL
+	originalToShort map[string]string // storage.OriginalKey -> короткий URL
+	// byOwner — позиции ссылок каждого владельца в порядке создания, индекс для List
+	byOwner map[string][]storage.ListCursor
+	mu      sync.RWMutex
+
+	// переходы хранятся под отдельной блокировкой, чтобы запись статистики не тормозила редиректы
+	clicks   map[string][]storage.Click
+	clicksMu sync.RWMutex
+
+	// ключи API по хешу; на диск, как и переходы, не сохраняются
+	keys   map[string]storage.APIKey
+	keysMu sync.RWMutex
+
+	// журнал изменений ссылок, nil если хранилище открыто без сохранения на диск
+	wal     *wal
+	pending []walRecord // изменения текущей операции, ещё не записанные в журнал
+	undo    []walRecord // обратные изменения для отката текущей операции, если журнал не записан
+	deleted []string    // короткие URL, удалённые текущей операцией; их переходы удаляются при подтверждении
+	persist *persister
 }
 
 // NewMemory создает новое in-memory хранилище URL
 func NewMemory() *Memory {
 	return &Memory{
-		shortToOriginal: make(map[string]string),
+		shortToOriginal: make(map[string]storage.URL),
 		originalToShort: make(map[string]string),
+		byOwner:         make(map[string][]storage.ListCursor),
+		clicks:          make(map[string][]storage.Click),
+		keys:            make(map[string]storage.APIKey),
+	}
+}
+
+// Save сохраняет ссылку, возвращает существующий короткий URL если оригинальный уже сохранен тем же владельцем.
+// Истёкшие, но ещё не удалённые ссылки не мешают сохранению
+func (s *Memory) Save(_ context.Context, url storage.URL) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved, err := s.save(url, time.Now())
+	if err := s.commit(); err != nil {
+		return "", err
 	}
+	return saved.ShortURL, err
 }
 
-// Save сохраняет пару URL, возвращает существующий короткий URL если оригинальный уже сохранен
-func (s *Memory) Save(shortURL, originalURL string) (string, error) {
+// SaveMany сохраняет пакет ссылок под одной блокировкой
+func (s *Memory) SaveMany(_ context.Context, urls []storage.URL) ([]storage.BatchResult, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, exists := s.shortToOriginal[shortURL]; exists {
-		return "", errors.New("short URL already exists")
+	now := time.Now()
+	results := make([]storage.BatchResult, len(urls))
+	for i, url := range urls {
+		results[i].URL, results[i].Err = s.save(url, now)
 	}
-	if actualShortURL, exists := s.originalToShort[originalURL]; exists {
-		return actualShortURL, nil
+	if err := s.commit(); err != nil {
+		return nil, err
 	}
+	return results, nil
+}
+
+// Get возвращает ссылку по её короткой версии
+func (s *Memory) Get(_ context.Context, shortURL string) (storage.URL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	s.shortToOriginal[shortURL] = originalURL
-	s.originalToShort[originalURL] = shortURL
-	return shortURL, nil
+	return s.get(shortURL, time.Now())
 }
 
-// Get возвращает оригинальный URL по его короткой версии
-func (s *Memory) Get(shortURL string) (string, error) {
+// GetMany возвращает пакет ссылок по их коротким версиям
+func (s *Memory) GetMany(_ context.Context, shortURLs []string) ([]storage.BatchResult, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	originalURL, exists := s.shortToOriginal[shortURL]
+	now := time.Now()
+	results := make([]storage.BatchResult, len(shortURLs))
+	for i, shortURL := range shortURLs {
+		results[i].URL, results[i].Err = s.get(shortURL, now)
+	}
+	return results, nil
+}
+
+// save сохраняет ссылку и возвращает её или уже существующую ссылку владельца с тем же оригинальным URL.
+// Вызывающий должен удерживать блокировку на запись
+func (s *Memory) save(url storage.URL, now time.Time) (storage.URL, error) {
+	if existing, exists := s.shortToOriginal[url.ShortURL]; exists {
+		if !existing.Expired(now) {
+			return storage.URL{}, storage.ErrShortURLConflict
+		}
+		s.remove(existing)
+	}
+	if actualShortURL, exists := s.originalToShort[storage.OriginalKey(url.OwnerID, url.OriginalURL)]; exists {
+		existing := s.shortToOriginal[actualShortURL]
+		if !existing.Expired(now) {
+			return existing, nil
+		}
+		s.remove(existing)
+	}
+
+	s.put(url)
+	return url, nil
+}
+
+// get возвращает ссылку по короткой версии. Вызывающий должен удерживать блокировку
+func (s *Memory) get(shortURL string, now time.Time) (storage.URL, error) {
+	url, exists := s.shortToOriginal[shortURL]
+	if !exists {
+		return storage.URL{}, storage.ErrNotFound
+	}
+	if url.Expired(now) {
+		return url, storage.ErrExpired
+	}
+	return url, nil
+}
+
+// Delete удаляет ссылку по её короткой версии
+func (s *Memory) Delete(_ context.Context, shortURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	url, exists := s.shortToOriginal[shortURL]
+	if !exists {
+		return storage.ErrNotFound
+	}
+	s.remove(url)
+	return s.commit()
+}
+
+// Update меняет оригинальный URL короткой ссылки. Истёкшая ссылка владельца с новым оригинальным URL
+// не мешает изменению и удаляется
+func (s *Memory) Update(_ context.Context, shortURL, newOriginalURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	url, exists := s.shortToOriginal[shortURL]
 	if !exists {
-		return "", errors.New("short URL not found")
+		return storage.ErrNotFound
+	}
+	if url.OriginalURL == newOriginalURL {
+		return nil
+	}
+	if existingShortURL, exists := s.originalToShort[storage.OriginalKey(url.OwnerID, newOriginalURL)]; exists {
+		existing := s.shortToOriginal[existingShortURL]
+		if !existing.Expired(time.Now()) {
+			return storage.ErrOriginalURLExists
+		}
+		s.remove(existing)
+	}
+
+	s.delete(url)
+	url.OriginalURL = newOriginalURL
+	s.put(url)
+	return s.commit()
+}
+
+// DeleteExpired удаляет ссылки, срок действия которых истёк к моменту now
+func (s *Memory) DeleteExpired(_ context.Context, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for _, url := range s.shortToOriginal {
+		if url.Expired(now) {
+			s.remove(url)
+			deleted++
+		}
+	}
+	if err := s.commit(); err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// Count возвращает число хранимых ссылок
+func (s *Memory) Count(_ context.Context) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return int64(len(s.shortToOriginal)), nil
+}
+
+// List возвращает страницу ссылок владельца. Границы выборки находятся двоичным поиском по индексу byOwner,
+// поэтому перебираются только ссылки владельца в заданном интервале
+func (s *Memory) List(_ context.Context, query storage.ListQuery) ([]storage.URL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	cursors := s.byOwner[query.OwnerID]
+	lo, hi := 0, len(cursors)
+	if !query.CreatedFrom.IsZero() {
+		lo = sort.Search(len(cursors), func(i int) bool { return !cursors[i].CreatedAt.Before(query.CreatedFrom) })
+	}
+	if !query.CreatedTo.IsZero() {
+		hi = sort.Search(len(cursors), func(i int) bool { return !cursors[i].CreatedAt.Before(query.CreatedTo) })
+	}
+	if after := query.After; after != nil {
+		if query.Order == storage.OldestFirst {
+			lo = max(lo, sort.Search(len(cursors), func(i int) bool { return after.Before(cursors[i]) }))
+		} else {
+			hi = min(hi, sort.Search(len(cursors), func(i int) bool { return !cursors[i].Before(*after) }))
+		}
+	}
+
+	var urls []storage.URL
+	for n := 0; lo+n < hi && (query.Limit <= 0 || len(urls) < query.Limit); n++ {
+		i := lo + n
+		if query.Order != storage.OldestFirst {
+			i = hi - 1 - n
+		}
+		if url := s.shortToOriginal[cursors[i].ShortURL]; query.Matches(url, now) {
+			urls = append(urls, url)
+		}
+	}
+	return urls, nil
+}
+
+// SaveClicks сохраняет пачку переходов
+func (s *Memory) SaveClicks(_ context.Context, clicks []storage.Click) error {
+	s.clicksMu.Lock()
+	defer s.clicksMu.Unlock()
+
+	for _, click := range clicks {
+		s.clicks[click.ShortURL] = append(s.clicks[click.ShortURL], click)
+	}
+	return nil
+}
+
+// ClickStats возвращает статистику переходов, сгруппированную по интервалам
+func (s *Memory) ClickStats(_ context.Context, query storage.ClickStatsQuery) (storage.ClickStats, error) {
+	s.clicksMu.RLock()
+	defer s.clicksMu.RUnlock()
+
+	var stats storage.ClickStats
+	visitors := make(map[string]struct{})
+	buckets := make(map[time.Time]int64)
+	for _, click := range s.clicks[query.ShortURL] {
+		if !query.From.IsZero() && click.ClickedAt.Before(query.From) {
+			continue
+		}
+		if !query.To.IsZero() && !click.ClickedAt.Before(query.To) {
+			continue
+		}
+		stats.TotalClicks++
+		visitors[click.IPHash] = struct{}{}
+		buckets[storage.BucketStart(click.ClickedAt, query.Bucket)]++
+	}
+	stats.UniqueVisitors = int64(len(visitors))
+	for start, clicks := range buckets {
+		stats.Buckets = append(stats.Buckets, storage.ClickBucket{Start: start, Clicks: clicks})
+	}
+	sort.Slice(stats.Buckets, func(i, j int) bool {
+		return stats.Buckets[i].Start.Before(stats.Buckets[j].Start)
+	})
+	return stats, nil
+}
+
+// SaveKey сохраняет ключ API
+func (s *Memory) SaveKey(_ context.Context, key storage.APIKey) error {
+	s.keysMu.Lock()
+	defer s.keysMu.Unlock()
+
+	s.keys[key.Hash] = key
+	return nil
+}
+
+// KeyByHash возвращает ключ API по хешу
+func (s *Memory) KeyByHash(_ context.Context, hash string) (storage.APIKey, error) {
+	s.keysMu.RLock()
+	defer s.keysMu.RUnlock()
+
+	key, ok := s.keys[hash]
+	if !ok {
+		return storage.APIKey{}, storage.ErrKeyNotFound
+	}
+	return key, nil
+}
+
+// ListKeys возвращает ключи API в порядке создания
+func (s *Memory) ListKeys(_ context.Context) ([]storage.APIKey, error) {
+	s.keysMu.RLock()
+	defer s.keysMu.RUnlock()
+
+	keys := make([]storage.APIKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+	storage.SortKeys(keys)
+	return keys, nil
+}
+
+// RevokeKey отзывает ключ API. Повторный отзыв не меняет время отзыва
+func (s *Memory) RevokeKey(_ context.Context, id string, now time.Time) error {
+	s.keysMu.Lock()
+	defer s.keysMu.Unlock()
+
+	for hash, key := range s.keys {
+		if key.ID != id {
+			continue
+		}
+		if !key.Revoked() {
+			key.RevokedAt = now
+			s.keys[hash] = key
+		}
+		return nil
+	}
+	return storage.ErrKeyNotFound
+}
+
+// put добавляет ссылку в индексы и в изменения текущей операции, вызывается под блокировкой
+func (s *Memory) put(url storage.URL) {
+	s.index(url)
+	if s.wal != nil {
+		s.pending = append(s.pending, walRecord{Op: opPut, URL: url})
+		s.undo = append(s.undo, walRecord{Op: opDelete, URL: url})
+	}
+}
+
+// delete удаляет ссылку из индексов и добавляет удаление в изменения текущей операции, вызывается под блокировкой
+func (s *Memory) delete(url storage.URL) {
+	s.unindex(url)
+	if s.wal != nil {
+		s.pending = append(s.pending, walRecord{Op: opDelete, URL: storage.URL{ShortURL: url.ShortURL}})
+		s.undo = append(s.undo, walRecord{Op: opPut, URL: url})
+	}
+}
+
+// remove удаляет ссылку, как delete, и отмечает её переходы для удаления при подтверждении операции,
+// чтобы новая ссылка с тем же кодом не унаследовала статистику. Вызывается под блокировкой
+func (s *Memory) remove(url storage.URL) {
+	s.delete(url)
+	s.deleted = append(s.deleted, url.ShortURL)
+}
+
+// index добавляет ссылку в индексы, вызывается под блокировкой
+func (s *Memory) index(url storage.URL) {
+	s.shortToOriginal[url.ShortURL] = url
+	s.originalToShort[storage.OriginalKey(url.OwnerID, url.OriginalURL)] = url.ShortURL
+	cursors := s.byOwner[url.OwnerID]
+	cursor := storage.CursorOf(url)
+	i := sort.Search(len(cursors), func(i int) bool { return !cursors[i].Before(cursor) })
+	s.byOwner[url.OwnerID] = slices.Insert(cursors, i, cursor)
+}
+
+// unindex удаляет ссылку из индексов, вызывается под блокировкой
+func (s *Memory) unindex(url storage.URL) {
+	delete(s.shortToOriginal, url.ShortURL)
+	delete(s.originalToShort, storage.OriginalKey(url.OwnerID, url.OriginalURL))
+	cursors := s.byOwner[url.OwnerID]
+	cursor := storage.CursorOf(url)
+	if i := sort.Search(len(cursors), func(i int) bool { return !cursors[i].Before(cursor) }); i < len(cursors) && cursors[i] == cursor {
+		cursors = slices.Delete(cursors, i, i+1)
+	}
+	if len(cursors) == 0 {
+		delete(s.byOwner, url.OwnerID)
+	} else {
+		s.byOwner[url.OwnerID] = cursors
+	}
+}
+
+// commit записывает изменения текущей операции в журнал до ответа вызывающему. Если журнал не записан,
+// изменения откатываются: иначе ссылка отдавалась бы до перезапуска и пропала бы после него.
+// После подтверждения удаляются переходы ссылок, отмеченных remove.
+// Вызывается под блокировкой на запись, поэтому неподтверждённые изменения никто не видит
+func (s *Memory) commit() error {
+	var err error
+	if s.wal != nil && len(s.pending) > 0 {
+		err = s.wal.append(s.pending)
+	}
+	if err != nil {
+		for i := len(s.undo) - 1; i >= 0; i-- {
+			if record := s.undo[i]; record.Op == opPut {
+				s.index(record.URL)
+			} else {
+				s.unindex(record.URL)
+			}
+		}
+	} else {
+		s.deleteClicks(s.deleted)
+	}
+	s.pending = s.pending[:0]
+	s.undo = s.undo[:0]
+	s.deleted = s.deleted[:0]
+	return err
+}
+
+// deleteClicks удаляет переходы ссылок shortURLs
+func (s *Memory) deleteClicks(shortURLs []string) {
+	if len(shortURLs) == 0 {
+		return
+	}
+	s.clicksMu.Lock()
+	defer s.clicksMu.Unlock()
+	for _, shortURL := range shortURLs {
+		delete(s.clicks, shortURL)
 	}
-	return originalURL, nil
 }
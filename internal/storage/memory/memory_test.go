@@ -1,9 +1,12 @@
 package memory
 
 import (
-	"errors"
+	"context"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
+	"url-shortener/internal/storage"
 )
 
 // Тест для метода Save
@@ -29,20 +32,50 @@ func TestMemory_Save(t *testing.T) {
 			shortURL:    "xyz789",
 			originalURL: "https://example.com",
 			setup: func(m *Memory) {
-				m.Save("abc123", "https://example.com") //nolint:errcheck
+				m.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"}) //nolint:errcheck
 			},
 			expectedShort: "abc123",
 			expectedErr:   nil,
 		},
+		{
+			name:        "Тот же originalURL другого владельца",
+			shortURL:    "xyz789",
+			originalURL: "https://example.com",
+			setup: func(m *Memory) {
+				m.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com", OwnerID: "alice"}) //nolint:errcheck
+			},
+			expectedShort: "xyz789",
+			expectedErr:   nil,
+		},
 		{
 			name:        "Ошибка из-за дублирования shortURL",
 			shortURL:    "abc123",
 			originalURL: "https://newexample.com",
 			setup: func(m *Memory) {
-				m.Save("abc123", "https://example.com") //nolint:errcheck
+				m.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"}) //nolint:errcheck
 			},
 			expectedShort: "",
-			expectedErr:   errors.New("short URL already exists"),
+			expectedErr:   storage.ErrShortURLConflict,
+		},
+		{
+			name:        "Замена истёкшей ссылки на тот же originalURL",
+			shortURL:    "xyz789",
+			originalURL: "https://example.com",
+			setup: func(m *Memory) {
+				m.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com", ExpiresAt: time.Now().Add(-time.Minute)}) //nolint:errcheck
+			},
+			expectedShort: "xyz789",
+			expectedErr:   nil,
+		},
+		{
+			name:        "Переиспользование shortURL истёкшей ссылки",
+			shortURL:    "abc123",
+			originalURL: "https://newexample.com",
+			setup: func(m *Memory) {
+				m.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com", ExpiresAt: time.Now().Add(-time.Minute)}) //nolint:errcheck
+			},
+			expectedShort: "abc123",
+			expectedErr:   nil,
 		},
 	}
 
@@ -51,7 +84,7 @@ func TestMemory_Save(t *testing.T) {
 			mem := NewMemory()
 			tt.setup(mem)
 
-			shortURL, err := mem.Save(tt.shortURL, tt.originalURL)
+			shortURL, err := mem.Save(context.Background(), storage.URL{ShortURL: tt.shortURL, OriginalURL: tt.originalURL})
 
 			if tt.expectedErr != nil {
 				assert.Error(t, err)
@@ -62,9 +95,9 @@ func TestMemory_Save(t *testing.T) {
 				assert.Equal(t, tt.expectedShort, shortURL)
 
 				// Проверяем, что данные действительно сохранены
-				originalURL, getErr := mem.Get(shortURL)
+				url, getErr := mem.Get(context.Background(), shortURL)
 				assert.NoError(t, getErr)
-				assert.Equal(t, tt.originalURL, originalURL)
+				assert.Equal(t, tt.originalURL, url.OriginalURL)
 			}
 		})
 	}
@@ -83,7 +116,7 @@ func TestMemory_Get(t *testing.T) {
 			name:     "Успешное получение URL",
 			shortURL: "abc123",
 			setup: func(m *Memory) {
-				m.Save("abc123", "https://example.com") //nolint:errcheck
+				m.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"}) //nolint:errcheck
 			},
 			expectedURL: "https://example.com",
 			expectedErr: nil,
@@ -93,7 +126,16 @@ func TestMemory_Get(t *testing.T) {
 			shortURL:    "xyz789",
 			setup:       func(m *Memory) {},
 			expectedURL: "",
-			expectedErr: errors.New("short URL not found"),
+			expectedErr: storage.ErrNotFound,
+		},
+		{
+			name:     "Срок действия URL истёк",
+			shortURL: "abc123",
+			setup: func(m *Memory) {
+				m.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com", ExpiresAt: time.Now().Add(-time.Minute)}) //nolint:errcheck
+			},
+			expectedURL: "https://example.com",
+			expectedErr: storage.ErrExpired,
 		},
 	}
 
@@ -102,16 +144,362 @@ func TestMemory_Get(t *testing.T) {
 			mem := NewMemory()
 			tt.setup(mem)
 
-			originalURL, err := mem.Get(tt.shortURL)
+			url, err := mem.Get(context.Background(), tt.shortURL)
 
 			if tt.expectedErr != nil {
 				assert.Error(t, err)
 				assert.Equal(t, tt.expectedErr.Error(), err.Error())
-				assert.Empty(t, originalURL)
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, tt.expectedURL, originalURL)
 			}
+			assert.Equal(t, tt.expectedURL, url.OriginalURL)
 		})
 	}
 }
+
+// Тест для метода DeleteExpired
+func TestMemory_DeleteExpired(t *testing.T) {
+	mem := NewMemory()
+	now := time.Now()
+	mem.Save(context.Background(), storage.URL{ShortURL: "expired", OriginalURL: "https://expired.com", ExpiresAt: now.Add(-time.Minute)}) //nolint:errcheck
+	mem.Save(context.Background(), storage.URL{ShortURL: "active", OriginalURL: "https://active.com", ExpiresAt: now.Add(time.Hour)})      //nolint:errcheck
+	mem.Save(context.Background(), storage.URL{ShortURL: "forever", OriginalURL: "https://forever.com"})                                   //nolint:errcheck
+
+	deleted, err := mem.DeleteExpired(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	_, err = mem.Get(context.Background(), "expired")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, exists := mem.originalToShort[storage.OriginalKey("", "https://expired.com")]
+	assert.False(t, exists)
+
+	_, err = mem.Get(context.Background(), "active")
+	assert.NoError(t, err)
+	_, err = mem.Get(context.Background(), "forever")
+	assert.NoError(t, err)
+}
+
+// Тест удаления переходов вместе со ссылкой
+func TestMemory_DeleteClicks(t *testing.T) {
+	s := NewMemory()
+	now := time.Now()
+	s.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"})                                    //nolint:errcheck
+	s.Save(context.Background(), storage.URL{ShortURL: "expired", OriginalURL: "https://expired.com", ExpiresAt: now.Add(-time.Minute)}) //nolint:errcheck
+	s.Save(context.Background(), storage.URL{ShortURL: "stale", OriginalURL: "https://stale.com", ExpiresAt: now.Add(-time.Minute)})     //nolint:errcheck
+	err := s.SaveClicks(context.Background(), []storage.Click{
+		{ShortURL: "abc123", ClickedAt: now, IPHash: "a"},
+		{ShortURL: "expired", ClickedAt: now, IPHash: "a"},
+		{ShortURL: "stale", ClickedAt: now, IPHash: "a"},
+	})
+	require.NoError(t, err)
+	clicks := func(shortURL string) int64 {
+		stats, err := s.ClickStats(context.Background(), storage.ClickStatsQuery{ShortURL: shortURL, Bucket: time.Hour})
+		require.NoError(t, err)
+		return stats.TotalClicks
+	}
+
+	// Изменение ссылки сохраняет её переходы, удаление — удаляет
+	require.NoError(t, s.Update(context.Background(), "abc123", "https://example.com/new"))
+	assert.Equal(t, int64(1), clicks("abc123"))
+	require.NoError(t, s.Delete(context.Background(), "abc123"))
+	assert.Zero(t, clicks("abc123"))
+
+	// Новая ссылка на месте истёкшей не наследует её переходы
+	_, err = s.Save(context.Background(), storage.URL{ShortURL: "stale", OriginalURL: "https://fresh.com"})
+	require.NoError(t, err)
+	assert.Zero(t, clicks("stale"))
+
+	_, err = s.DeleteExpired(context.Background(), now)
+	require.NoError(t, err)
+	assert.Zero(t, clicks("expired"))
+}
+
+// Тест для методов SaveClicks и ClickStats
+func TestMemory_ClickStats(t *testing.T) {
+	mem := NewMemory()
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	err := mem.SaveClicks(context.Background(), []storage.Click{
+		{ShortURL: "abc123", ClickedAt: base, IPHash: "a"},
+		{ShortURL: "abc123", ClickedAt: base.Add(10 * time.Minute), IPHash: "a"},
+		{ShortURL: "abc123", ClickedAt: base.Add(90 * time.Minute), IPHash: "b"},
+		{ShortURL: "other", ClickedAt: base, IPHash: "c"},
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		query    storage.ClickStatsQuery
+		expected storage.ClickStats
+	}{
+		{
+			name:  "Статистика по часам",
+			query: storage.ClickStatsQuery{ShortURL: "abc123", Bucket: time.Hour},
+			expected: storage.ClickStats{
+				TotalClicks:    3,
+				UniqueVisitors: 2,
+				Buckets: []storage.ClickBucket{
+					{Start: base, Clicks: 2},
+					{Start: base.Add(time.Hour), Clicks: 1},
+				},
+			},
+		},
+		{
+			name: "Статистика за период",
+			query: storage.ClickStatsQuery{
+				ShortURL: "abc123",
+				From:     base.Add(5 * time.Minute),
+				To:       base.Add(time.Hour),
+				Bucket:   time.Hour,
+			},
+			expected: storage.ClickStats{
+				TotalClicks:    1,
+				UniqueVisitors: 1,
+				Buckets:        []storage.ClickBucket{{Start: base, Clicks: 1}},
+			},
+		},
+		{
+			name:     "Нет переходов",
+			query:    storage.ClickStatsQuery{ShortURL: "xyz789", Bucket: time.Hour},
+			expected: storage.ClickStats{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := mem.ClickStats(context.Background(), tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, stats)
+		})
+	}
+}
+
+// Тест для метода Delete
+func TestMemory_Delete(t *testing.T) {
+	mem := NewMemory()
+	mem.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"}) //nolint:errcheck
+
+	assert.NoError(t, mem.Delete(context.Background(), "abc123"))
+	assert.ErrorIs(t, mem.Delete(context.Background(), "abc123"), storage.ErrNotFound)
+
+	// После удаления оригинальный URL можно сократить заново
+	shortURL, err := mem.Save(context.Background(), storage.URL{ShortURL: "xyz789", OriginalURL: "https://example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, "xyz789", shortURL)
+}
+
+// Тест для метода Update
+func TestMemory_Update(t *testing.T) {
+	tests := []struct {
+		name        string
+		shortURL    string
+		newURL      string
+		expectedErr error
+	}{
+		{
+			name:        "Успешное изменение URL",
+			shortURL:    "abc123",
+			newURL:      "https://example.com/new",
+			expectedErr: nil,
+		},
+		{
+			name:        "Изменение на тот же URL",
+			shortURL:    "abc123",
+			newURL:      "https://example.com",
+			expectedErr: nil,
+		},
+		{
+			name:        "URL не найден",
+			shortURL:    "xyz789",
+			newURL:      "https://example.com/new",
+			expectedErr: storage.ErrNotFound,
+		},
+		{
+			name:        "Новый URL уже сокращён",
+			shortURL:    "abc123",
+			newURL:      "https://other.com",
+			expectedErr: storage.ErrOriginalURLExists,
+		},
+		{
+			name:        "Новый URL сокращён истёкшей ссылкой",
+			shortURL:    "abc123",
+			newURL:      "https://expired.com",
+			expectedErr: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mem := NewMemory()
+			mem.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"})                                          //nolint:errcheck
+			mem.Save(context.Background(), storage.URL{ShortURL: "def456", OriginalURL: "https://other.com"})                                            //nolint:errcheck
+			mem.Save(context.Background(), storage.URL{ShortURL: "ghi789", OriginalURL: "https://expired.com", ExpiresAt: time.Now().Add(-time.Minute)}) //nolint:errcheck
+
+			err := mem.Update(context.Background(), tt.shortURL, tt.newURL)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			url, err := mem.Get(context.Background(), tt.shortURL)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.newURL, url.OriginalURL)
+			assert.Equal(t, tt.shortURL, mem.originalToShort[storage.OriginalKey("", tt.newURL)])
+			if tt.newURL != "https://example.com" {
+				assert.NotContains(t, mem.originalToShort, storage.OriginalKey("", "https://example.com"))
+			}
+			if tt.newURL == "https://expired.com" {
+				assert.NotContains(t, mem.shortToOriginal, "ghi789")
+			}
+		})
+	}
+}
+
+func TestMemory_SaveMany(t *testing.T) {
+	mem := NewMemory()
+	mem.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"}) //nolint:errcheck
+
+	results, err := mem.SaveMany(context.Background(), []storage.URL{
+		{ShortURL: "def456", OriginalURL: "https://example.com/new"},
+		{ShortURL: "ghi789", OriginalURL: "https://example.com"},
+		{ShortURL: "abc123", OriginalURL: "https://example.com/other"},
+		{ShortURL: "jkl012", OriginalURL: "https://example.com/new"},
+	})
+	assert.NoError(t, err)
+	if !assert.Len(t, results, 4) {
+		return
+	}
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "def456", results[0].URL.ShortURL)
+	// Уже сокращённый URL возвращает существующую ссылку
+	assert.NoError(t, results[1].Err)
+	assert.Equal(t, "abc123", results[1].URL.ShortURL)
+	assert.ErrorIs(t, results[2].Err, storage.ErrShortURLConflict)
+	// Повтор внутри пакета тоже возвращает ссылку, сохранённую раньше
+	assert.NoError(t, results[3].Err)
+	assert.Equal(t, "def456", results[3].URL.ShortURL)
+}
+
+func TestMemory_GetMany(t *testing.T) {
+	mem := NewMemory()
+	mem.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"})                                          //nolint:errcheck
+	mem.Save(context.Background(), storage.URL{ShortURL: "def456", OriginalURL: "https://expired.com", ExpiresAt: time.Now().Add(-time.Minute)}) //nolint:errcheck
+
+	results, err := mem.GetMany(context.Background(), []string{"abc123", "def456", "xyz789"})
+	assert.NoError(t, err)
+	if !assert.Len(t, results, 3) {
+		return
+	}
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "https://example.com", results[0].URL.OriginalURL)
+	assert.ErrorIs(t, results[1].Err, storage.ErrExpired)
+	assert.ErrorIs(t, results[2].Err, storage.ErrNotFound)
+}
+
+// Тест для методов ключей API
+func TestMemory_Keys(t *testing.T) {
+	mem := NewMemory()
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	second := storage.APIKey{ID: "key2", Hash: "hash2", OwnerID: "bob", CreatedAt: base.Add(time.Minute)}
+	first := storage.APIKey{ID: "key1", Hash: "hash1", OwnerID: "alice", Name: "ci", CreatedAt: base}
+	assert.NoError(t, mem.SaveKey(context.Background(), second))
+	assert.NoError(t, mem.SaveKey(context.Background(), first))
+
+	key, err := mem.KeyByHash(context.Background(), "hash1")
+	assert.NoError(t, err)
+	assert.Equal(t, first, key)
+	_, err = mem.KeyByHash(context.Background(), "unknown")
+	assert.ErrorIs(t, err, storage.ErrKeyNotFound)
+
+	keys, err := mem.ListKeys(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.APIKey{first, second}, keys)
+
+	assert.NoError(t, mem.RevokeKey(context.Background(), "key1", base.Add(time.Hour)))
+	assert.NoError(t, mem.RevokeKey(context.Background(), "key1", base.Add(2*time.Hour)))
+	key, err = mem.KeyByHash(context.Background(), "hash1")
+	assert.NoError(t, err)
+	assert.Equal(t, base.Add(time.Hour), key.RevokedAt)
+	assert.ErrorIs(t, mem.RevokeKey(context.Background(), "unknown", base), storage.ErrKeyNotFound)
+}
+
+func TestMemory_List(t *testing.T) {
+	mem := NewMemory()
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	urls := []storage.URL{
+		{ShortURL: "a1", OriginalURL: "https://example.com/Docs", CreatedAt: base, OwnerID: "alice"},
+		{ShortURL: "a2", OriginalURL: "https://go.dev/doc", CreatedAt: base.Add(time.Minute), OwnerID: "alice"},
+		{ShortURL: "a3", OriginalURL: "https://example.com:8080/blog", CreatedAt: base.Add(time.Minute), OwnerID: "alice"},
+		{ShortURL: "a4", OriginalURL: "https://example.com/old", CreatedAt: base.Add(2 * time.Minute), OwnerID: "alice", ExpiresAt: base},
+		{ShortURL: "b1", OriginalURL: "https://example.com/bob", CreatedAt: base.Add(time.Minute), OwnerID: "bob"},
+	}
+	for _, url := range urls {
+		_, err := mem.Save(context.Background(), url)
+		assert.NoError(t, err)
+	}
+
+	tests := []struct {
+		name     string
+		query    storage.ListQuery
+		expected []string
+	}{
+		{
+			name:     "Сначала новые",
+			query:    storage.ListQuery{OwnerID: "alice"},
+			expected: []string{"a3", "a2", "a1"},
+		},
+		{
+			name:     "Сначала старые с ограничением",
+			query:    storage.ListQuery{OwnerID: "alice", Order: storage.OldestFirst, Limit: 2},
+			expected: []string{"a1", "a2"},
+		},
+		{
+			name:     "Продолжение после курсора",
+			query:    storage.ListQuery{OwnerID: "alice", After: &storage.ListCursor{CreatedAt: base.Add(time.Minute), ShortURL: "a3"}},
+			expected: []string{"a2", "a1"},
+		},
+		{
+			name:     "Продолжение после курсора сначала старые",
+			query:    storage.ListQuery{OwnerID: "alice", Order: storage.OldestFirst, After: &storage.ListCursor{CreatedAt: base.Add(time.Minute), ShortURL: "a2"}},
+			expected: []string{"a3"},
+		},
+		{
+			name:     "Интервал создания",
+			query:    storage.ListQuery{OwnerID: "alice", CreatedFrom: base.Add(time.Minute), CreatedTo: base.Add(2 * time.Minute)},
+			expected: []string{"a3", "a2"},
+		},
+		{
+			name:     "Домен и подстрока",
+			query:    storage.ListQuery{OwnerID: "alice", Domain: "example.com", Contains: "docs"},
+			expected: []string{"a1"},
+		},
+		{
+			name:     "Чужие ссылки не видны",
+			query:    storage.ListQuery{OwnerID: "carol"},
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := mem.List(context.Background(), tt.query)
+			assert.NoError(t, err)
+			shortURLs := []string{}
+			for _, url := range page {
+				shortURLs = append(shortURLs, url.ShortURL)
+			}
+			assert.Equal(t, tt.expected, shortURLs)
+		})
+	}
+
+	// Удалённая ссылка пропадает из выборки
+	assert.NoError(t, mem.Delete(context.Background(), "a2"))
+	page, err := mem.List(context.Background(), storage.ListQuery{OwnerID: "alice"})
+	assert.NoError(t, err)
+	assert.Len(t, page, 2)
+
+	count, err := mem.Count(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), count)
+}
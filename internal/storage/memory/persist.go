@@ -0,0 +1,310 @@
+package memory
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"url-shortener/internal/storage"
+)
+
+// FsyncPolicy определяет, когда записи журнала сбрасываются на диск
+type FsyncPolicy string
+
+const (
+	// FsyncAlways — fsync перед ответом на каждое изменение, подтверждённые ссылки не теряются
+	FsyncAlways FsyncPolicy = "always"
+	// FsyncInterval — fsync раз в PersistConfig.FsyncInterval, при сбое ОС теряются изменения за последний интервал
+	FsyncInterval FsyncPolicy = "interval"
+	// FsyncNever — сброс на диск остаётся операционной системе
+	FsyncNever FsyncPolicy = "never"
+)
+
+const (
+	walPrefix      = "wal-"
+	walSuffix      = ".log"
+	snapshotPrefix = "snapshot-"
+	snapshotSuffix = ".json"
+)
+
+// PersistConfig содержит параметры сохранения in-memory хранилища на диск
+type PersistConfig struct {
+	Dir              string // каталог для журнала и снимков
+	Fsync            FsyncPolicy
+	FsyncInterval    time.Duration // период fsync при политике FsyncInterval
+	SnapshotInterval time.Duration // период снимков, 0 отключает периодические снимки
+}
+
+// persister хранит состояние сохранения на диск. Файлы журнала и снимков нумеруются:
+// снимок N содержит ссылки на момент создания журнала N, поэтому восстановление
+// читает последний снимок и журналы с номером не меньше N
+type persister struct {
+	dir        string
+	seq        uint64     // номер текущего файла журнала
+	snapshotMu sync.Mutex // снимки не выполняются одновременно
+	stop       chan struct{}
+	wg         sync.WaitGroup
+}
+
+// snapshotData — содержимое файла снимка
+type snapshotData struct {
+	URLs []storage.URL `json:"urls"`
+}
+
+// Open создает in-memory хранилище, изменения ссылок которого сохраняются в журнал в каталоге cfg.Dir.
+// При открытии ссылки восстанавливаются из последнего снимка и журнала. Переходы на диск не сохраняются
+func Open(cfg PersistConfig) (*Memory, error) {
+	switch cfg.Fsync {
+	case FsyncAlways, FsyncInterval, FsyncNever:
+	default:
+		return nil, fmt.Errorf("unknown fsync policy %q", cfg.Fsync)
+	}
+	if cfg.Fsync == FsyncInterval && cfg.FsyncInterval <= 0 {
+		return nil, fmt.Errorf("fsync interval must be positive, got %s", cfg.FsyncInterval)
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := NewMemory()
+	seq, err := s.recover(cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+	s.wal, err = openWAL(filepath.Join(cfg.Dir, fileName(walPrefix, seq, walSuffix)), cfg.Fsync)
+	if err != nil {
+		return nil, err
+	}
+	s.persist = &persister{dir: cfg.Dir, seq: seq, stop: make(chan struct{})}
+
+	if cfg.Fsync == FsyncInterval {
+		s.every(cfg.FsyncInterval, s.wal.sync)
+	}
+	if cfg.SnapshotInterval > 0 {
+		s.every(cfg.SnapshotInterval, s.snapshot)
+	}
+	return s, nil
+}
+
+// Close останавливает фоновые fsync и снимки и закрывает журнал.
+// Для хранилища без сохранения на диск ничего не делает
+func (s *Memory) Close() error {
+	if s.persist == nil {
+		return nil
+	}
+	close(s.persist.stop)
+	s.persist.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.wal.close()
+}
+
+// every вызывает fn с периодом interval до закрытия хранилища
+func (s *Memory) every(interval time.Duration, fn func() error) {
+	s.persist.wg.Add(1)
+	go func() {
+		defer s.persist.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.persist.stop:
+				return
+			case <-ticker.C:
+				if err := fn(); err != nil {
+					slog.Error("Memory storage persistence failed", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// snapshot переключает журнал на новый файл, записывает снимок ссылок на этот момент
+// и удаляет журналы и снимки, которые больше не нужны для восстановления
+func (s *Memory) snapshot() error {
+	p := s.persist
+	p.snapshotMu.Lock()
+	defer p.snapshotMu.Unlock()
+
+	s.mu.Lock()
+	seq := p.seq + 1
+	if err := s.wal.rotate(filepath.Join(p.dir, fileName(walPrefix, seq, walSuffix))); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	p.seq = seq
+	urls := make([]storage.URL, 0, len(s.shortToOriginal))
+	for _, url := range s.shortToOriginal {
+		urls = append(urls, url)
+	}
+	s.mu.Unlock()
+
+	if err := writeSnapshot(filepath.Join(p.dir, fileName(snapshotPrefix, seq, snapshotSuffix)), urls); err != nil {
+		return err
+	}
+	for _, kind := range [][2]string{{walPrefix, walSuffix}, {snapshotPrefix, snapshotSuffix}} {
+		seqs, err := listFiles(p.dir, kind[0], kind[1])
+		if err != nil {
+			return err
+		}
+		for _, old := range seqs {
+			if old >= seq {
+				break
+			}
+			if err := os.Remove(filepath.Join(p.dir, fileName(kind[0], old, kind[1]))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// recover загружает последний снимок и применяет журналы после него.
+// Возвращает номер журнала, в который нужно продолжать запись
+func (s *Memory) recover(dir string) (uint64, error) {
+	// недописанные снимки остаются во временных файлах
+	tmps, err := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	if err != nil {
+		return 0, err
+	}
+	for _, tmp := range tmps {
+		if err := os.Remove(tmp); err != nil {
+			return 0, err
+		}
+	}
+
+	seq := uint64(1)
+	snapshots, err := listFiles(dir, snapshotPrefix, snapshotSuffix)
+	if err != nil {
+		return 0, err
+	}
+	if len(snapshots) > 0 {
+		seq = snapshots[len(snapshots)-1]
+		if err := s.loadSnapshot(filepath.Join(dir, fileName(snapshotPrefix, seq, snapshotSuffix))); err != nil {
+			return 0, err
+		}
+	}
+
+	wals, err := listFiles(dir, walPrefix, walSuffix)
+	if err != nil {
+		return 0, err
+	}
+	for _, walSeq := range wals {
+		if walSeq < seq {
+			continue
+		}
+		path := filepath.Join(dir, fileName(walPrefix, walSeq, walSuffix))
+		size, err := replayWAL(path, s.apply)
+		if err != nil {
+			return 0, err
+		}
+		// отбрасываем оборванную запись, чтобы новые записи шли сразу за последней корректной
+		if err := os.Truncate(path, size); err != nil {
+			return 0, err
+		}
+		seq = walSeq
+	}
+	return seq, nil
+}
+
+// loadSnapshot загружает ссылки из файла снимка
+func (s *Memory) loadSnapshot(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close() //nolint:errcheck
+
+	var data snapshotData
+	if err := json.NewDecoder(bufio.NewReader(file)).Decode(&data); err != nil {
+		return fmt.Errorf("read snapshot %s: %w", path, err)
+	}
+	for _, url := range data.URLs {
+		s.put(url)
+	}
+	return nil
+}
+
+// apply применяет запись журнала при восстановлении
+func (s *Memory) apply(record walRecord) {
+	switch record.Op {
+	case opPut:
+		s.put(record.URL)
+	case opDelete:
+		if url, exists := s.shortToOriginal[record.URL.ShortURL]; exists {
+			s.delete(url)
+		}
+	}
+}
+
+// writeSnapshot атомарно записывает снимок: во временный файл, затем fsync и переименование
+func writeSnapshot(path string, urls []storage.URL) error {
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	if err := json.NewEncoder(w).Encode(snapshotData{URLs: urls}); err != nil {
+		file.Close() //nolint:errcheck
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		file.Close() //nolint:errcheck
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close() //nolint:errcheck
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// syncDir сбрасывает на диск содержимое каталога, чтобы переименование пережило сбой
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close() //nolint:errcheck
+	return d.Sync()
+}
+
+// listFiles возвращает отсортированные номера файлов вида prefix<номер>suffix в каталоге dir
+func listFiles(dir, prefix, suffix string) ([]uint64, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, prefix+"*"+suffix))
+	if err != nil {
+		return nil, err
+	}
+	var seqs []uint64
+	for _, path := range paths {
+		name := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), prefix), suffix)
+		seq, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	return seqs, nil
+}
+
+// fileName возвращает имя файла журнала или снимка с номером seq
+func fileName(prefix string, seq uint64, suffix string) string {
+	return fmt.Sprintf("%s%020d%s", prefix, seq, suffix)
+}
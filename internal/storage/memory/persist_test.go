@@ -0,0 +1,225 @@
+package memory
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"url-shortener/internal/storage"
+)
+
+func openTestMemory(t *testing.T, dir string) *Memory {
+	t.Helper()
+	s, err := Open(PersistConfig{Dir: dir, Fsync: FsyncAlways})
+	require.NoError(t, err)
+	return s
+}
+
+// fillTestMemory выполняет все виды изменений, которые должны пережить перезапуск
+func fillTestMemory(t *testing.T, s *Memory) {
+	t.Helper()
+	ctx := context.Background()
+	_, err := s.Save(ctx, storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com", RedirectStatus: 301})
+	require.NoError(t, err)
+	_, err = s.SaveMany(ctx, []storage.URL{
+		{ShortURL: "def456", OriginalURL: "https://other.com"},
+		{ShortURL: "ghi789", OriginalURL: "https://deleted.com"},
+		{ShortURL: "old", OriginalURL: "https://expired.com", ExpiresAt: time.Now().Add(-time.Minute)},
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.Update(ctx, "def456", "https://other.com/new"))
+	require.NoError(t, s.Delete(ctx, "ghi789"))
+	_, err = s.DeleteExpired(ctx, time.Now())
+	require.NoError(t, err)
+}
+
+// assertTestMemory проверяет состояние после fillTestMemory
+func assertTestMemory(t *testing.T, s *Memory) {
+	t.Helper()
+	ctx := context.Background()
+	url, err := s.Get(ctx, "abc123")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com", url.OriginalURL)
+	assert.Equal(t, int32(301), url.RedirectStatus)
+
+	url, err = s.Get(ctx, "def456")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://other.com/new", url.OriginalURL)
+
+	_, err = s.Get(ctx, "ghi789")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, err = s.Get(ctx, "old")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	// Индекс оригинальных URL восстановлен вместе со ссылками
+	shortURL, err := s.Save(ctx, storage.URL{ShortURL: "new", OriginalURL: "https://example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", shortURL)
+	shortURL, err = s.Save(ctx, storage.URL{ShortURL: "new", OriginalURL: "https://other.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, "new", shortURL)
+}
+
+func TestMemory_PersistReplay(t *testing.T) {
+	tests := []struct {
+		name     string
+		snapshot bool
+	}{
+		{name: "Восстановление из журнала", snapshot: false},
+		{name: "Восстановление из снимка", snapshot: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s := openTestMemory(t, dir)
+			fillTestMemory(t, s)
+			if tt.snapshot {
+				require.NoError(t, s.snapshot())
+			}
+			require.NoError(t, s.Close())
+
+			s = openTestMemory(t, dir)
+			defer s.Close() //nolint:errcheck
+			assertTestMemory(t, s)
+		})
+	}
+}
+
+func TestMemory_SnapshotCompaction(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestMemory(t, dir)
+	fillTestMemory(t, s)
+	require.NoError(t, s.snapshot())
+	// Изменение после снимка попадает в новый журнал
+	_, err := s.Save(context.Background(), storage.URL{ShortURL: "after", OriginalURL: "https://after.com"})
+	require.NoError(t, err)
+	require.NoError(t, s.snapshot())
+	require.NoError(t, s.Close())
+
+	wals, err := listFiles(dir, walPrefix, walSuffix)
+	require.NoError(t, err)
+	snapshots, err := listFiles(dir, snapshotPrefix, snapshotSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{3}, wals)
+	assert.Equal(t, []uint64{3}, snapshots)
+
+	s = openTestMemory(t, dir)
+	defer s.Close() //nolint:errcheck
+	assertTestMemory(t, s)
+	_, err = s.Get(context.Background(), "after")
+	assert.NoError(t, err)
+}
+
+func TestMemory_PersistTornRecord(t *testing.T) {
+	tests := []struct {
+		name        string
+		corrupt     func(data []byte) []byte
+		expectedErr error
+	}{
+		{
+			name:    "Оборван заголовок последней записи",
+			corrupt: func(data []byte) []byte { return append(data, 0x10, 0x00) },
+		},
+		{
+			name: "Оборваны данные последней записи",
+			corrupt: func(data []byte) []byte {
+				return append(data, 0x40, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04, '{', '"')
+			},
+		},
+		{
+			name: "Испорчена контрольная сумма последней записи",
+			corrupt: func(data []byte) []byte {
+				data[len(data)-2] ^= 0xff
+				return data
+			},
+		},
+		{
+			name: "Испорчена запись в середине журнала",
+			corrupt: func(data []byte) []byte {
+				data[walHeaderSize+2] ^= 0xff
+				return data
+			},
+			expectedErr: ErrCorruptLog,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s := openTestMemory(t, dir)
+			fillTestMemory(t, s)
+			_, err := s.Save(context.Background(), storage.URL{ShortURL: "last", OriginalURL: "https://last.com"})
+			require.NoError(t, err)
+			require.NoError(t, s.Close())
+
+			path := filepath.Join(dir, fileName(walPrefix, 1, walSuffix))
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(path, tt.corrupt(data), 0o600))
+
+			s, err = Open(PersistConfig{Dir: dir, Fsync: FsyncAlways})
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assertTestMemory(t, s)
+
+			// После восстановления журнал продолжает работать
+			_, err = s.Save(context.Background(), storage.URL{ShortURL: "again", OriginalURL: "https://again.com"})
+			require.NoError(t, err)
+			require.NoError(t, s.Close())
+			s = openTestMemory(t, dir)
+			defer s.Close() //nolint:errcheck
+			_, err = s.Get(context.Background(), "again")
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestMemory_WALFailureRollsBack(t *testing.T) {
+	ctx := context.Background()
+	s := openTestMemory(t, t.TempDir())
+	_, err := s.Save(ctx, storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"})
+	require.NoError(t, err)
+	// Запись в закрытый файл журнала завершается ошибкой
+	require.NoError(t, s.wal.file.Close())
+
+	_, err = s.Save(ctx, storage.URL{ShortURL: "new", OriginalURL: "https://new.com"})
+	assert.Error(t, err)
+	_, err = s.SaveMany(ctx, []storage.URL{{ShortURL: "batch", OriginalURL: "https://batch.com"}})
+	assert.Error(t, err)
+	assert.Error(t, s.Update(ctx, "abc123", "https://updated.com"))
+	assert.Error(t, s.Delete(ctx, "abc123"))
+
+	// Неподтверждённые журналом изменения не видны
+	_, err = s.Get(ctx, "new")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	_, err = s.Get(ctx, "batch")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	url, err := s.Get(ctx, "abc123")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com", url.OriginalURL)
+	urls, err := s.List(ctx, storage.ListQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, urls, 1)
+	count, err := s.Count(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	// Индекс оригинальных URL тоже откатился
+	shortURL, err := s.Save(ctx, storage.URL{ShortURL: "other", OriginalURL: "https://example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", shortURL)
+}
+
+func TestOpen_InvalidConfig(t *testing.T) {
+	_, err := Open(PersistConfig{Dir: t.TempDir(), Fsync: "sometimes"})
+	assert.Error(t, err)
+	_, err = Open(PersistConfig{Dir: t.TempDir(), Fsync: FsyncInterval})
+	assert.Error(t, err)
+}
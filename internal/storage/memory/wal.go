@@ -0,0 +1,195 @@
+package memory
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+
+	"url-shortener/internal/storage"
+)
+
+// Операции журнала
+const (
+	opPut    = "put"
+	opDelete = "del"
+)
+
+// walHeaderSize — длина и контрольная сумма CRC32 перед каждой записью журнала
+const walHeaderSize = 8
+
+// ErrCorruptLog возвращается когда повреждена запись в середине журнала.
+// Оборванная последняя запись ошибкой не считается и отбрасывается при восстановлении
+var ErrCorruptLog = errors.New("write-ahead log is corrupted")
+
+// walRecord — одно изменение ссылок. Для удаления заполняется только URL.ShortURL
+type walRecord struct {
+	Op  string      `json:"op"`
+	URL storage.URL `json:"url"`
+}
+
+// wal — файл журнала, в который дописываются изменения ссылок
+type wal struct {
+	mu    sync.Mutex
+	file  *os.File
+	fsync FsyncPolicy
+	dirty bool // есть записи, ещё не сброшенные на диск через fsync
+}
+
+// openWAL открывает файл журнала на дозапись, создавая его при необходимости
+func openWAL(path string, fsync FsyncPolicy) (*wal, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &wal{file: file, fsync: fsync}, nil
+}
+
+// append дописывает записи одним вызовом write и при политике FsyncAlways дожидается fsync.
+// При ошибке журнал обрезается до прежнего размера, чтобы оборванные записи не попали в восстановление
+// и не оказались перед следующими записями
+func (w *wal) append(records []walRecord) error {
+	var buf bytes.Buffer
+	for _, record := range records {
+		if err := encodeRecord(&buf, record); err != nil {
+			return err
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	size, err := w.file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if _, err := w.file.Write(buf.Bytes()); err != nil {
+		w.file.Truncate(size) //nolint:errcheck
+		return err
+	}
+	if w.fsync == FsyncAlways {
+		if err := w.file.Sync(); err != nil {
+			w.file.Truncate(size) //nolint:errcheck
+			return err
+		}
+		return nil
+	}
+	w.dirty = true
+	return nil
+}
+
+// sync сбрасывает накопленные записи на диск
+func (w *wal) sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.syncLocked()
+}
+
+func (w *wal) syncLocked() error {
+	if !w.dirty {
+		return nil
+	}
+	w.dirty = false
+	return w.file.Sync()
+}
+
+// rotate сбрасывает текущий файл на диск и переключает журнал на новый файл path
+func (w *wal) rotate(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.dirty = true
+	if err := w.syncLocked(); err != nil {
+		file.Close() //nolint:errcheck
+		return err
+	}
+	if err := w.file.Close(); err != nil {
+		file.Close() //nolint:errcheck
+		return err
+	}
+	w.file = file
+	return nil
+}
+
+// close сбрасывает журнал на диск и закрывает файл
+func (w *wal) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.dirty = true
+	if err := w.syncLocked(); err != nil {
+		w.file.Close() //nolint:errcheck
+		return err
+	}
+	return w.file.Close()
+}
+
+// encodeRecord пишет запись в формате: длина (4 байта), CRC32 данных (4 байта), данные в JSON
+func encodeRecord(w io.Writer, record walRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	var header [walHeaderSize]byte
+	binary.LittleEndian.PutUint32(header[:4], uint32(len(data)))
+	binary.LittleEndian.PutUint32(header[4:], crc32.ChecksumIEEE(data))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// replayWAL применяет записи журнала по порядку и возвращает размер его корректной части.
+// Оборванная или повреждённая последняя запись — след сбоя во время записи, она отбрасывается
+func replayWAL(path string, apply func(walRecord)) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close() //nolint:errcheck
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	r := bufio.NewReader(file)
+	var offset int64
+	for {
+		var header [walHeaderSize]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return offset, nil
+			}
+			return 0, err
+		}
+		size := int64(binary.LittleEndian.Uint32(header[:4]))
+		end := offset + walHeaderSize + size
+		if end > info.Size() {
+			// запись оборвана на середине
+			return offset, nil
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return 0, err
+		}
+
+		var record walRecord
+		if crc32.ChecksumIEEE(data) != binary.LittleEndian.Uint32(header[4:]) || json.Unmarshal(data, &record) != nil {
+			if end == info.Size() {
+				return offset, nil
+			}
+			return 0, fmt.Errorf("%w: %s at offset %d", ErrCorruptLog, path, offset)
+		}
+		apply(record)
+		offset = end
+	}
+}
@@ -4,11 +4,44 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
 	"strings"
+	"time"
+	"url-shortener/internal/logging"
 	"url-shortener/internal/storage"
+	"url-shortener/internal/tracing"
 )
 
+const (
+	// uniqueViolation — код ошибки PostgreSQL при нарушении ограничения уникальности
+	uniqueViolation = "23505"
+	// batchChunkSize ограничивает число строк в одном запросе пакетных операций,
+	// чтобы не превысить лимит параметров PostgreSQL
+	batchChunkSize = 1000
+)
+
+// tracerName — имя трассировщика операций с PostgreSQL
+const tracerName = "url-shortener/internal/storage/postgres"
+
+// urlColumns — столбцы, из которых собирается storage.URL в пакетных выборках
+var urlColumns = []string{"short_url", "original_url", "expires_at", "redirect_status", "created_at", "owner_id"}
+
+// hostSQL извлекает хост из original_url так же, как storage.URLHost. Выражение совпадает
+// с выражением индекса urls_owner_host_idx, иначе индекс не используется
+const hostSQL = `trim(both '[]' from substring(original_url from '^[^:]+://(?:[^@/?#]*@)?(\[[^]]*\]|[^/?#:]*)'))`
+
+// likeEscaper экранирует спецсимволы шаблона LIKE
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// keyColumns — столбцы, из которых собирается storage.APIKey
+var keyColumns = []string{"id", "key_hash", "owner_id", "name", "created_at", "revoked_at"}
+
 // Postgres реализует хранилище URL на базе PostgreSQL
 type Postgres struct {
 	db *sql.DB
@@ -19,46 +52,708 @@ func NewPostgres(db *sql.DB) *Postgres {
 	return &Postgres{db: db}
 }
 
-// Save сохраняет пару URL в БД, возвращает существующий shortURL если originalURL уже есть
-func (s *Postgres) Save(shortURL, originalURL string) (string, error) {
-	query := squirrel.StatementBuilder.
+// Ping проверяет подключение к базе данных
+func (s *Postgres) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Save сохраняет ссылку в БД, возвращает существующий shortURL если originalURL уже есть у того же владельца.
+// Истёкшие, но ещё не удалённые ссылки с тем же коротким кодом или тем же оригинальным URL владельца
+// удаляются в одной транзакции с вставкой новой
+func (s *Postgres) Save(ctx context.Context, url storage.URL) (_ string, err error) {
+	ctx, span := startSpan(ctx, "Save", tracing.ShortCodeKey.String(url.ShortURL))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	replaced, err := deleteURLs(ctx, tx, squirrel.And{
+		squirrel.Or{
+			squirrel.Eq{"short_url": url.ShortURL},
+			squirrel.Eq{"owner_id": url.OwnerID, "original_url": url.OriginalURL},
+		},
+		squirrel.LtOrEq{"expires_at": time.Now()},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(replaced) > 0 {
+		logging.FromContext(ctx).Debug("Replacing expired short URL", "short_code", url.ShortURL, "replaced", replaced)
+	}
+
+	insertQuery := squirrel.StatementBuilder.
 		PlaceholderFormat(squirrel.Dollar).
 		Insert("urls").
-		Columns("short_url", "original_url").
-		Values(shortURL, originalURL)
+		Columns(urlColumns...).
+		Values(url.ShortURL, url.OriginalURL, nullTime(url.ExpiresAt), url.RedirectStatus, url.CreatedAt, url.OwnerID).
+		Suffix("ON CONFLICT DO NOTHING")
 
-	_, err := query.RunWith(s.db).ExecContext(context.Background())
-	if err == nil {
-		return shortURL, nil
+	res, err := insertQuery.RunWith(tx).ExecContext(ctx)
+	if err != nil {
+		return "", mapError(err)
 	}
-	if strings.Contains(err.Error(), "urls_original_url_key") {
-		query := squirrel.StatementBuilder.
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return "", err
+	}
+	shortURL := url.ShortURL
+	if inserted == 0 {
+		// Строка пропущена: либо у владельца уже есть ссылка на originalURL, либо занят короткий код
+		existingQuery := squirrel.StatementBuilder.
 			PlaceholderFormat(squirrel.Dollar).
 			Select("short_url").
 			From("urls").
-			Where(squirrel.Eq{"original_url": originalURL})
+			Where(squirrel.Eq{"owner_id": url.OwnerID, "original_url": url.OriginalURL})
 
-		err = query.RunWith(s.db).QueryRowContext(context.Background()).Scan(&shortURL)
-		if err == nil {
-			return shortURL, nil
+		err = existingQuery.RunWith(tx).QueryRowContext(ctx).Scan(&shortURL)
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", storage.ErrShortURLConflict
 		}
+		if err != nil {
+			return "", err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
 	}
-	return "", err
+	return shortURL, nil
 }
 
-// Get возвращает оригинальный URL по его короткой версии из БД
-func (s *Postgres) Get(shortURL string) (string, error) {
-	var originalURL string
+// Get возвращает ссылку по её короткой версии из БД
+func (s *Postgres) Get(ctx context.Context, shortURL string) (_ storage.URL, err error) {
+	ctx, span := startSpan(ctx, "Get", tracing.ShortCodeKey.String(shortURL))
+	defer func() { tracing.End(span, err) }()
+
+	url := storage.URL{ShortURL: shortURL}
+	var expiresAt sql.NullTime
 	query := squirrel.StatementBuilder.
 		PlaceholderFormat(squirrel.Dollar).
-		Select("original_url").
+		Select("original_url", "expires_at", "redirect_status", "created_at", "owner_id").
 		From("urls").
 		Where(squirrel.Eq{"short_url": shortURL})
 
-	row := query.RunWith(s.db).QueryRowContext(context.Background())
-	err := row.Scan(&originalURL)
+	row := query.RunWith(s.db).QueryRowContext(ctx)
+	err = row.Scan(&url.OriginalURL, &expiresAt, &url.RedirectStatus, &url.CreatedAt, &url.OwnerID)
 	if errors.Is(err, sql.ErrNoRows) {
-		return "", storage.ErrNotFound
+		return storage.URL{}, storage.ErrNotFound
+	}
+	if err != nil {
+		return storage.URL{}, err
+	}
+	url.ExpiresAt = expiresAt.Time
+	if url.Expired(time.Now()) {
+		return url, storage.ErrExpired
+	}
+	return url, nil
+}
+
+// SaveMany сохраняет пакет ссылок в одной транзакции многострочными INSERT
+func (s *Postgres) SaveMany(ctx context.Context, urls []storage.URL) (_ []storage.BatchResult, err error) {
+	ctx, span := startSpan(ctx, "SaveMany", semconv.DBOperationBatchSize(len(urls)))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	results := make([]storage.BatchResult, 0, len(urls))
+	for start := 0; start < len(urls); start += batchChunkSize {
+		chunkResults, err := saveChunk(ctx, tx, urls[start:min(start+batchChunkSize, len(urls))])
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, chunkResults...)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// saveChunk сохраняет часть пакета. Истёкшие ссылки владельцев с теми же оригинальными URL удаляются,
+// конфликтующие строки пропускаются, и для них ищутся существующие ссылки владельца с тем же оригинальным URL
+func saveChunk(ctx context.Context, tx *sql.Tx, urls []storage.URL) ([]storage.BatchResult, error) {
+	_, err := deleteURLs(ctx, tx, squirrel.And{ownerOriginalIn(urls), squirrel.LtOrEq{"expires_at": time.Now()}})
+	if err != nil {
+		return nil, err
+	}
+
+	insertQuery := squirrel.StatementBuilder.
+		PlaceholderFormat(squirrel.Dollar).
+		Insert("urls").
+		Columns(urlColumns...).
+		Suffix("ON CONFLICT DO NOTHING RETURNING short_url, original_url")
+	for _, url := range urls {
+		insertQuery = insertQuery.Values(url.ShortURL, url.OriginalURL, nullTime(url.ExpiresAt), url.RedirectStatus, url.CreatedAt, url.OwnerID)
+	}
+
+	rows, err := insertQuery.RunWith(tx).QueryContext(ctx)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	inserted := make(map[string]string, len(urls)) // короткий URL -> оригинальный URL
+	for rows.Next() {
+		var shortURL, originalURL string
+		if err := rows.Scan(&shortURL, &originalURL); err != nil {
+			return nil, err
+		}
+		inserted[shortURL] = originalURL
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	results := make([]storage.BatchResult, len(urls))
+	var skipped []storage.URL
+	for i, url := range urls {
+		if inserted[url.ShortURL] == url.OriginalURL {
+			results[i].URL = url
+			continue
+		}
+		skipped = append(skipped, url)
+	}
+	if len(skipped) == 0 {
+		return results, nil
+	}
+
+	existingQuery := squirrel.StatementBuilder.
+		PlaceholderFormat(squirrel.Dollar).
+		Select(urlColumns...).
+		From("urls").
+		Where(ownerOriginalIn(skipped))
+
+	existing, err := queryURLs(ctx, tx, existingQuery)
+	if err != nil {
+		return nil, err
+	}
+	byOriginal := make(map[string]storage.URL, len(existing)) // storage.OriginalKey -> ссылка
+	for _, url := range existing {
+		byOriginal[storage.OriginalKey(url.OwnerID, url.OriginalURL)] = url
+	}
+	for i, url := range urls {
+		if inserted[url.ShortURL] == url.OriginalURL {
+			continue
+		}
+		if existingURL, ok := byOriginal[storage.OriginalKey(url.OwnerID, url.OriginalURL)]; ok {
+			results[i].URL = existingURL
+		} else {
+			results[i].Err = storage.ErrShortURLConflict
+		}
+	}
+	return results, nil
+}
+
+// ownerOriginalIn — условие на пары владельца и оригинального URL ссылок urls. Пары передаются
+// двумя массивами, поэтому число параметров запроса не зависит от размера пакета
+func ownerOriginalIn(urls []storage.URL) squirrel.Sqlizer {
+	owners := make([]string, len(urls))
+	originals := make([]string, len(urls))
+	for i, url := range urls {
+		owners[i] = url.OwnerID
+		originals[i] = url.OriginalURL
 	}
-	return originalURL, err
+	return squirrel.Expr("(owner_id, original_url) IN (SELECT * FROM unnest(?::text[], ?::text[]))",
+		pq.Array(owners), pq.Array(originals))
+}
+
+// GetMany возвращает пакет ссылок, выбирая их запросами с IN по частям пакета
+func (s *Postgres) GetMany(ctx context.Context, shortURLs []string) (_ []storage.BatchResult, err error) {
+	ctx, span := startSpan(ctx, "GetMany", semconv.DBOperationBatchSize(len(shortURLs)))
+	defer func() { tracing.End(span, err) }()
+
+	found := make(map[string]storage.URL, len(shortURLs))
+	for start := 0; start < len(shortURLs); start += batchChunkSize {
+		query := squirrel.StatementBuilder.
+			PlaceholderFormat(squirrel.Dollar).
+			Select(urlColumns...).
+			From("urls").
+			Where(squirrel.Eq{"short_url": shortURLs[start:min(start+batchChunkSize, len(shortURLs))]})
+
+		urls, err := queryURLs(ctx, s.db, query)
+		if err != nil {
+			return nil, err
+		}
+		for _, url := range urls {
+			found[url.ShortURL] = url
+		}
+	}
+
+	now := time.Now()
+	results := make([]storage.BatchResult, len(shortURLs))
+	for i, shortURL := range shortURLs {
+		url, ok := found[shortURL]
+		switch {
+		case !ok:
+			results[i].Err = storage.ErrNotFound
+		case url.Expired(now):
+			results[i].Err = storage.ErrExpired
+		default:
+			results[i].URL = url
+		}
+	}
+	return results, nil
+}
+
+// Delete удаляет ссылку по её короткой версии из БД вместе с её переходами
+func (s *Postgres) Delete(ctx context.Context, shortURL string) (err error) {
+	ctx, span := startSpan(ctx, "Delete", tracing.ShortCodeKey.String(shortURL))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	deleted, err := deleteURLs(ctx, tx, squirrel.Eq{"short_url": shortURL})
+	if err != nil {
+		return err
+	}
+	if len(deleted) == 0 {
+		return storage.ErrNotFound
+	}
+	return tx.Commit()
+}
+
+// Update меняет оригинальный URL короткой ссылки в БД. Истёкшая ссылка того же владельца с новым
+// оригинальным URL удаляется в той же транзакции
+func (s *Postgres) Update(ctx context.Context, shortURL, newOriginalURL string) (err error) {
+	ctx, span := startSpan(ctx, "Update", tracing.ShortCodeKey.String(shortURL))
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	_, err = deleteURLs(ctx, tx, squirrel.And{
+		squirrel.Eq{"original_url": newOriginalURL},
+		squirrel.Expr("owner_id = (SELECT owner_id FROM urls WHERE short_url = ?)", shortURL),
+		squirrel.NotEq{"short_url": shortURL},
+		squirrel.LtOrEq{"expires_at": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+
+	query := squirrel.StatementBuilder.
+		PlaceholderFormat(squirrel.Dollar).
+		Update("urls").
+		Set("original_url", newOriginalURL).
+		Where(squirrel.Eq{"short_url": shortURL})
+
+	res, err := query.RunWith(tx).ExecContext(ctx)
+	if err != nil {
+		return mapError(err)
+	}
+	if err := checkAffected(res); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteExpired удаляет из БД ссылки, срок действия которых истёк к моменту now, вместе с их переходами
+func (s *Postgres) DeleteExpired(ctx context.Context, now time.Time) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "DeleteExpired")
+	defer func() { tracing.End(span, err) }()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	deleted, err := deleteURLs(ctx, tx, squirrel.LtOrEq{"expires_at": now})
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int64(len(deleted)), nil
+}
+
+// deleteURLs удаляет ссылки, подходящие под условие where, вместе с их переходами, чтобы новая ссылка
+// с тем же кодом не унаследовала статистику. Возвращает короткие URL удалённых ссылок
+func deleteURLs(ctx context.Context, tx *sql.Tx, where squirrel.Sqlizer) ([]string, error) {
+	deleteQuery := squirrel.StatementBuilder.
+		PlaceholderFormat(squirrel.Dollar).
+		Delete("urls").
+		Where(where).
+		Suffix("RETURNING short_url")
+
+	rows, err := deleteQuery.RunWith(tx).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var deleted []string
+	for rows.Next() {
+		var shortURL string
+		if err := rows.Scan(&shortURL); err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, shortURL)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(deleted) == 0 {
+		return nil, nil
+	}
+
+	clicksQuery := squirrel.StatementBuilder.
+		PlaceholderFormat(squirrel.Dollar).
+		Delete("clicks").
+		Where(squirrel.Expr("short_url = ANY(?)", pq.Array(deleted)))
+
+	if _, err := clicksQuery.RunWith(tx).ExecContext(ctx); err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
+
+// Count возвращает число строк таблицы urls
+func (s *Postgres) Count(ctx context.Context) (_ int64, err error) {
+	ctx, span := startSpan(ctx, "Count")
+	defer func() { tracing.End(span, err) }()
+
+	query := squirrel.StatementBuilder.
+		PlaceholderFormat(squirrel.Dollar).
+		Select("COUNT(*)").
+		From("urls")
+
+	var count int64
+	err = query.RunWith(s.db).QueryRowContext(ctx).Scan(&count)
+	return count, err
+}
+
+// List возвращает страницу ссылок владельца. Страницы выбираются по ключу (created_at, short_url)
+// с использованием индексов из миграции 00010_add_list_indexes
+func (s *Postgres) List(ctx context.Context, query storage.ListQuery) (_ []storage.URL, err error) {
+	ctx, span := startSpan(ctx, "List")
+	defer func() { tracing.End(span, err) }()
+
+	where := squirrel.And{
+		squirrel.Eq{"owner_id": query.OwnerID},
+		squirrel.Or{squirrel.Eq{"expires_at": nil}, squirrel.Gt{"expires_at": time.Now()}},
+	}
+	if !query.CreatedFrom.IsZero() {
+		where = append(where, squirrel.GtOrEq{"created_at": query.CreatedFrom})
+	}
+	if !query.CreatedTo.IsZero() {
+		where = append(where, squirrel.Lt{"created_at": query.CreatedTo})
+	}
+	if query.Domain != "" {
+		where = append(where, squirrel.Expr(hostSQL+" = ?", query.Domain))
+	}
+	if query.Contains != "" {
+		where = append(where, squirrel.Expr("original_url ILIKE ?", "%"+likeEscaper.Replace(query.Contains)+"%"))
+	}
+
+	order := "DESC"
+	if query.Order == storage.OldestFirst {
+		order = "ASC"
+	}
+	if query.After != nil {
+		op := "<"
+		if query.Order == storage.OldestFirst {
+			op = ">"
+		}
+		where = append(where, squirrel.Expr("(created_at, short_url) "+op+" (?, ?)", query.After.CreatedAt, query.After.ShortURL))
+	}
+
+	selectQuery := squirrel.StatementBuilder.
+		PlaceholderFormat(squirrel.Dollar).
+		Select(urlColumns...).
+		From("urls").
+		Where(where).
+		OrderBy("created_at "+order, "short_url "+order)
+	if query.Limit > 0 {
+		selectQuery = selectQuery.Limit(uint64(query.Limit))
+	}
+	return queryURLs(ctx, s.db, selectQuery)
+}
+
+// SaveClicks сохраняет пачку переходов одним многострочным INSERT
+func (s *Postgres) SaveClicks(ctx context.Context, clicks []storage.Click) (err error) {
+	if len(clicks) == 0 {
+		return nil
+	}
+	ctx, span := startSpan(ctx, "SaveClicks", semconv.DBOperationBatchSize(len(clicks)))
+	defer func() { tracing.End(span, err) }()
+
+	query := squirrel.StatementBuilder.
+		PlaceholderFormat(squirrel.Dollar).
+		Insert("clicks").
+		Columns("short_url", "clicked_at", "referrer", "user_agent", "ip_hash")
+	for _, click := range clicks {
+		query = query.Values(click.ShortURL, click.ClickedAt, click.Referrer, click.UserAgent, click.IPHash)
+	}
+
+	_, err = query.RunWith(s.db).ExecContext(ctx)
+	return err
+}
+
+// ClickStats возвращает статистику переходов, сгруппированную по интервалам
+func (s *Postgres) ClickStats(ctx context.Context, query storage.ClickStatsQuery) (_ storage.ClickStats, err error) {
+	ctx, span := startSpan(ctx, "ClickStats", tracing.ShortCodeKey.String(query.ShortURL))
+	defer func() { tracing.End(span, err) }()
+
+	where := squirrel.And{squirrel.Eq{"short_url": query.ShortURL}}
+	if !query.From.IsZero() {
+		where = append(where, squirrel.GtOrEq{"clicked_at": query.From})
+	}
+	if !query.To.IsZero() {
+		where = append(where, squirrel.Lt{"clicked_at": query.To})
+	}
+
+	var stats storage.ClickStats
+	totalsQuery := squirrel.StatementBuilder.
+		PlaceholderFormat(squirrel.Dollar).
+		Select("COUNT(*)", "COUNT(DISTINCT ip_hash)").
+		From("clicks").
+		Where(where)
+
+	err = totalsQuery.RunWith(s.db).QueryRowContext(ctx).Scan(&stats.TotalClicks, &stats.UniqueVisitors)
+	if err != nil {
+		return storage.ClickStats{}, err
+	}
+
+	bucketSeconds := int64(query.Bucket / time.Second)
+	bucketsQuery := squirrel.StatementBuilder.
+		PlaceholderFormat(squirrel.Dollar).
+		Select().
+		Column(squirrel.Expr("to_timestamp(floor(extract(epoch FROM clicked_at) / ?) * ?) AS bucket", bucketSeconds, bucketSeconds)).
+		Column("COUNT(*)").
+		From("clicks").
+		Where(where).
+		GroupBy("bucket").
+		OrderBy("bucket")
+
+	rows, err := bucketsQuery.RunWith(s.db).QueryContext(ctx)
+	if err != nil {
+		return storage.ClickStats{}, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	for rows.Next() {
+		var bucket storage.ClickBucket
+		if err := rows.Scan(&bucket.Start, &bucket.Clicks); err != nil {
+			return storage.ClickStats{}, err
+		}
+		bucket.Start = bucket.Start.UTC()
+		stats.Buckets = append(stats.Buckets, bucket)
+	}
+	return stats, rows.Err()
+}
+
+// SaveKey сохраняет ключ API в таблицу api_keys
+func (s *Postgres) SaveKey(ctx context.Context, key storage.APIKey) (err error) {
+	ctx, span := startSpan(ctx, "SaveKey")
+	defer func() { tracing.End(span, err) }()
+
+	query := squirrel.StatementBuilder.
+		PlaceholderFormat(squirrel.Dollar).
+		Insert("api_keys").
+		Columns(keyColumns...).
+		Values(key.ID, key.Hash, key.OwnerID, key.Name, key.CreatedAt, nullTime(key.RevokedAt))
+
+	_, err = query.RunWith(s.db).ExecContext(ctx)
+	return err
+}
+
+// KeyByHash возвращает ключ API по хешу
+func (s *Postgres) KeyByHash(ctx context.Context, hash string) (_ storage.APIKey, err error) {
+	ctx, span := startSpan(ctx, "KeyByHash")
+	defer func() { tracing.End(span, err) }()
+
+	query := squirrel.StatementBuilder.
+		PlaceholderFormat(squirrel.Dollar).
+		Select(keyColumns...).
+		From("api_keys").
+		Where(squirrel.Eq{"key_hash": hash})
+
+	keys, err := queryKeys(ctx, s.db, query)
+	if err != nil {
+		return storage.APIKey{}, err
+	}
+	if len(keys) == 0 {
+		return storage.APIKey{}, storage.ErrKeyNotFound
+	}
+	return keys[0], nil
+}
+
+// ListKeys возвращает ключи API в порядке создания
+func (s *Postgres) ListKeys(ctx context.Context) (_ []storage.APIKey, err error) {
+	ctx, span := startSpan(ctx, "ListKeys")
+	defer func() { tracing.End(span, err) }()
+
+	query := squirrel.StatementBuilder.
+		PlaceholderFormat(squirrel.Dollar).
+		Select(keyColumns...).
+		From("api_keys").
+		OrderBy("created_at", "id")
+
+	return queryKeys(ctx, s.db, query)
+}
+
+// RevokeKey отзывает ключ API. Повторный отзыв не меняет время отзыва
+func (s *Postgres) RevokeKey(ctx context.Context, id string, now time.Time) (err error) {
+	ctx, span := startSpan(ctx, "RevokeKey")
+	defer func() { tracing.End(span, err) }()
+
+	query := squirrel.StatementBuilder.
+		PlaceholderFormat(squirrel.Dollar).
+		Update("api_keys").
+		Set("revoked_at", squirrel.Expr("COALESCE(revoked_at, ?)", now)).
+		Where(squirrel.Eq{"id": id})
+
+	res, err := query.RunWith(s.db).ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+	if err := checkAffected(res); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return storage.ErrKeyNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// refillSQL вычисляет число токенов корзины rate_limits к моменту первого параметра.
+// Параметры: момент, скорость пополнения в секунду, ёмкость корзины
+const refillSQL = "LEAST(rate_limits.tokens + GREATEST(EXTRACT(EPOCH FROM ?::timestamptz - rate_limits.updated_at)::double precision, 0) * ?, ?)"
+
+// TakeTokens атомарно списывает n токенов из корзины key одним INSERT ... ON CONFLICT.
+// Если токенов не хватает, строка не меняется, а время ожидания считается по её текущему состоянию
+func (s *Postgres) TakeTokens(ctx context.Context, key string, bucket storage.TokenBucket, n int, now time.Time) (_ bool, _ time.Duration, err error) {
+	ctx, span := startSpan(ctx, "TakeTokens")
+	defer func() { tracing.End(span, err) }()
+
+	need := bucket.Need(n)
+	query := squirrel.StatementBuilder.
+		PlaceholderFormat(squirrel.Dollar).
+		Insert("rate_limits").
+		Columns("key", "tokens", "updated_at").
+		Values(key, float64(bucket.Burst-n), now).
+		Suffix("ON CONFLICT (key) DO UPDATE SET tokens = "+refillSQL+" - ?, updated_at = ? WHERE "+refillSQL+" >= ? RETURNING tokens",
+			now, bucket.Rate, bucket.Burst, n, now, now, bucket.Rate, bucket.Burst, need)
+
+	var tokens float64
+	err = query.RunWith(s.db).QueryRowContext(ctx).Scan(&tokens)
+	if err == nil {
+		return true, 0, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return false, 0, err
+	}
+
+	var updatedAt time.Time
+	selectQuery := squirrel.StatementBuilder.
+		PlaceholderFormat(squirrel.Dollar).
+		Select("tokens", "updated_at").
+		From("rate_limits").
+		Where(squirrel.Eq{"key": key})
+	if err = selectQuery.RunWith(s.db).QueryRowContext(ctx).Scan(&tokens, &updatedAt); err != nil {
+		return false, 0, err
+	}
+	return false, bucket.Wait(bucket.Refill(tokens, updatedAt, now), need), nil
+}
+
+// startSpan начинает span операции с БД с атрибутами хранилища
+func startSpan(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs,
+		semconv.DBSystemNamePostgreSQL,
+		semconv.DBOperationName(operation),
+		tracing.StorageBackendKey.String("postgres"),
+	)
+	return otel.Tracer(tracerName).Start(ctx, "postgres."+operation, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// queryURLs выполняет выборку столбцов urlColumns и собирает из строк ссылки
+func queryURLs(ctx context.Context, runner squirrel.BaseRunner, query squirrel.SelectBuilder) ([]storage.URL, error) {
+	rows, err := query.RunWith(runner).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var urls []storage.URL
+	for rows.Next() {
+		var url storage.URL
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&url.ShortURL, &url.OriginalURL, &expiresAt, &url.RedirectStatus, &url.CreatedAt, &url.OwnerID); err != nil {
+			return nil, err
+		}
+		url.ExpiresAt = expiresAt.Time
+		urls = append(urls, url)
+	}
+	return urls, rows.Err()
+}
+
+// queryKeys выполняет выборку столбцов keyColumns и собирает из строк ключи API
+func queryKeys(ctx context.Context, runner squirrel.BaseRunner, query squirrel.SelectBuilder) ([]storage.APIKey, error) {
+	rows, err := query.RunWith(runner).QueryContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var keys []storage.APIKey
+	for rows.Next() {
+		var key storage.APIKey
+		var revokedAt sql.NullTime
+		if err := rows.Scan(&key.ID, &key.Hash, &key.OwnerID, &key.Name, &key.CreatedAt, &revokedAt); err != nil {
+			return nil, err
+		}
+		key.RevokedAt = revokedAt.Time
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// mapError преобразует ошибки PostgreSQL в типизированные ошибки хранилища
+func mapError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch {
+	case pqErr.Code == uniqueViolation && pqErr.Constraint == "urls_pkey":
+		return storage.ErrShortURLConflict
+	case pqErr.Code == uniqueViolation && pqErr.Constraint == "urls_owner_original_url_key":
+		return storage.ErrOriginalURLExists
+	case pqErr.Code.Class() == "22", pqErr.Code.Class() == "23" && pqErr.Code != uniqueViolation:
+		// 22 — некорректные данные (например, слишком длинная строка), 23 — нарушение ограничений
+		return fmt.Errorf("%w: %s", storage.ErrInvalid, pqErr.Message)
+	}
+	return err
+}
+
+// checkAffected возвращает ErrNotFound, если запрос не затронул ни одной строки
+func checkAffected(res sql.Result) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// nullTime преобразует нулевое время в NULL
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
 }
@@ -1,14 +1,18 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"errors"
+	"fmt"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"regexp"
 	"testing"
+	"time"
 	"url-shortener/internal/storage"
 )
 
@@ -21,7 +25,56 @@ func convertArgs(args []interface{}) []driver.Value {
 	return driverArgs
 }
 
+// expectDeleteURLs ожидает удаление ссылок по условию where и, если они нашлись, удаление их переходов
+func expectDeleteURLs(mock sqlmock.Sqlmock, where squirrel.Sqlizer, args []driver.Value, deleted ...string) {
+	query, _, _ := squirrel.Delete("urls").
+		Where(where).
+		Suffix("RETURNING short_url").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	rows := sqlmock.NewRows([]string{"short_url"})
+	for _, shortURL := range deleted {
+		rows.AddRow(shortURL)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(args...).WillReturnRows(rows)
+	if len(deleted) == 0 {
+		return
+	}
+	clicksQuery, _, _ := squirrel.Delete("clicks").
+		Where(squirrel.Expr("short_url = ANY(?)", pq.Array(deleted))).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	mock.ExpectExec(regexp.QuoteMeta(clicksQuery)).
+		WithArgs(pq.Array(deleted)).
+		WillReturnResult(sqlmock.NewResult(0, int64(len(deleted))))
+}
+
 func TestPostgres_Save(t *testing.T) {
+	// expectDelete ожидает удаление истёкших ссылок с тем же коротким кодом или оригинальным URL
+	expectDelete := func(mock sqlmock.Sqlmock, shortURL, originalURL string, deleted ...string) {
+		where := squirrel.And{
+			squirrel.Or{
+				squirrel.Eq{"short_url": shortURL},
+				squirrel.Eq{"owner_id": "", "original_url": originalURL},
+			},
+			squirrel.LtOrEq{"expires_at": time.Now()},
+		}
+		expectDeleteURLs(mock, where, []driver.Value{shortURL, originalURL, "", sqlmock.AnyArg()}, deleted...)
+	}
+	expectInsert := func(mock sqlmock.Sqlmock, shortURL, originalURL string) *sqlmock.ExpectedExec {
+		query, args, _ := squirrel.Insert("urls").
+			Columns("short_url", "original_url", "expires_at", "redirect_status", "created_at", "owner_id").
+			Values(shortURL, originalURL, nil, int32(0), time.Time{}, "").
+			Suffix("ON CONFLICT DO NOTHING").
+			PlaceholderFormat(squirrel.Dollar).ToSql()
+		return mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(convertArgs(args)...)
+	}
+	expectExisting := func(mock sqlmock.Sqlmock, originalURL string) *sqlmock.ExpectedQuery {
+		query, args, _ := squirrel.Select("short_url").
+			From("urls").
+			Where(squirrel.Eq{"owner_id": "", "original_url": originalURL}).
+			PlaceholderFormat(squirrel.Dollar).ToSql()
+		return mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(convertArgs(args)...)
+	}
+
 	tests := []struct {
 		name          string
 		shortURL      string
@@ -35,13 +88,10 @@ func TestPostgres_Save(t *testing.T) {
 			shortURL:    "abc123",
 			originalURL: "https://example.com",
 			setup: func(mock sqlmock.Sqlmock) {
-				query, args, _ := squirrel.Insert("urls").
-					Columns("short_url", "original_url").
-					Values("abc123", "https://example.com").
-					PlaceholderFormat(squirrel.Dollar).ToSql()
-				mock.ExpectExec(regexp.QuoteMeta(query)).
-					WithArgs(convertArgs(args)...).
-					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectBegin()
+				expectDelete(mock, "abc123", "https://example.com")
+				expectInsert(mock, "abc123", "https://example.com").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
 			},
 			expectedShort: "abc123",
 			expectedErr:   nil,
@@ -51,41 +101,69 @@ func TestPostgres_Save(t *testing.T) {
 			shortURL:    "xyz789",
 			originalURL: "https://example.com",
 			setup: func(mock sqlmock.Sqlmock) {
-				query, args, _ := squirrel.Insert("urls").
-					Columns("short_url", "original_url").
-					Values("xyz789", "https://example.com").
-					PlaceholderFormat(squirrel.Dollar).ToSql()
-				mock.ExpectExec(regexp.QuoteMeta(query)).
-					WithArgs(convertArgs(args)...).
-					WillReturnError(errors.New("urls_original_url_key"))
-
-				selectQuery := squirrel.Select("short_url").
-					From("urls").
-					Where(squirrel.Eq{"original_url": "https://example.com"})
-				selectSQL, selectArgs, _ := selectQuery.ToSql()
-				mock.ExpectQuery(selectSQL).
-					WithArgs(convertArgs(selectArgs)...).
-					WillReturnRows(sqlmock.NewRows([]string{"short_url"}).AddRow("abc123"))
+				mock.ExpectBegin()
+				expectDelete(mock, "xyz789", "https://example.com")
+				expectInsert(mock, "xyz789", "https://example.com").WillReturnResult(sqlmock.NewResult(0, 0))
+				expectExisting(mock, "https://example.com").WillReturnRows(sqlmock.NewRows([]string{"short_url"}).AddRow("abc123"))
+				mock.ExpectCommit()
 			},
 			expectedShort: "abc123",
 			expectedErr:   nil,
 		},
+		{
+			name:        "Замена истёкшей ссылки на тот же originalURL или с тем же коротким URL",
+			shortURL:    "xyz789",
+			originalURL: "https://example.com",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				expectDelete(mock, "xyz789", "https://example.com", "abc123")
+				expectInsert(mock, "xyz789", "https://example.com").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			expectedShort: "xyz789",
+			expectedErr:   nil,
+		},
 		{
 			name:        "Ошибка при сохранении",
 			shortURL:    "def456",
 			originalURL: "https://newexample.com",
 			setup: func(mock sqlmock.Sqlmock) {
-				query, args, _ := squirrel.Insert("urls").
-					Columns("short_url", "original_url").
-					Values("def456", "https://newexample.com").
-					PlaceholderFormat(squirrel.Dollar).ToSql()
-				mock.ExpectExec(regexp.QuoteMeta(query)).
-					WithArgs(convertArgs(args)...).
-					WillReturnError(errors.New("database error"))
+				mock.ExpectBegin()
+				expectDelete(mock, "def456", "https://newexample.com")
+				expectInsert(mock, "def456", "https://newexample.com").WillReturnError(errors.New("database error"))
+				mock.ExpectRollback()
 			},
 			expectedShort: "",
 			expectedErr:   errors.New("database error"),
 		},
+		{
+			name:        "Короткий URL уже занят",
+			shortURL:    "abc123",
+			originalURL: "https://newexample.com",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				expectDelete(mock, "abc123", "https://newexample.com")
+				expectInsert(mock, "abc123", "https://newexample.com").WillReturnResult(sqlmock.NewResult(0, 0))
+				expectExisting(mock, "https://newexample.com").WillReturnRows(sqlmock.NewRows([]string{"short_url"}))
+				mock.ExpectRollback()
+			},
+			expectedShort: "",
+			expectedErr:   storage.ErrShortURLConflict,
+		},
+		{
+			name:        "Слишком длинный короткий URL",
+			shortURL:    "abc123",
+			originalURL: "https://newexample.com",
+			setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				expectDelete(mock, "abc123", "https://newexample.com")
+				expectInsert(mock, "abc123", "https://newexample.com").
+					WillReturnError(&pq.Error{Code: "22001", Message: "value too long for type character varying(64)"})
+				mock.ExpectRollback()
+			},
+			expectedShort: "",
+			expectedErr:   fmt.Errorf("%w: value too long for type character varying(64)", storage.ErrInvalid),
+		},
 	}
 
 	for _, tt := range tests {
@@ -97,7 +175,7 @@ func TestPostgres_Save(t *testing.T) {
 			tt.setup(mock)
 
 			pg := NewPostgres(db)
-			shortURL, err := pg.Save(tt.shortURL, tt.originalURL)
+			shortURL, err := pg.Save(context.Background(), storage.URL{ShortURL: tt.shortURL, OriginalURL: tt.originalURL})
 
 			if tt.expectedErr != nil {
 				assert.Error(t, err)
@@ -125,21 +203,36 @@ func TestPostgres_Get(t *testing.T) {
 			name:     "Успешное получение URL",
 			shortURL: "abc123",
 			setup: func(mock sqlmock.Sqlmock) {
-				query, args, _ := squirrel.Select("original_url").
+				query, args, _ := squirrel.Select("original_url", "expires_at", "redirect_status", "created_at", "owner_id").
 					From("urls").
 					Where(squirrel.Eq{"short_url": "abc123"}).ToSql()
 				mock.ExpectQuery(query).
 					WithArgs(convertArgs(args)...).
-					WillReturnRows(sqlmock.NewRows([]string{"original_url"}).AddRow("https://example.com"))
+					WillReturnRows(sqlmock.NewRows([]string{"original_url", "expires_at", "redirect_status", "created_at", "owner_id"}).AddRow("https://example.com", nil, 301, time.Now(), ""))
 			},
 			expectedURL: "https://example.com",
 			expectedErr: nil,
 		},
+		{
+			name:     "Срок действия URL истёк",
+			shortURL: "abc123",
+			setup: func(mock sqlmock.Sqlmock) {
+				query, args, _ := squirrel.Select("original_url", "expires_at", "redirect_status", "created_at", "owner_id").
+					From("urls").
+					Where(squirrel.Eq{"short_url": "abc123"}).ToSql()
+				mock.ExpectQuery(query).
+					WithArgs(convertArgs(args)...).
+					WillReturnRows(sqlmock.NewRows([]string{"original_url", "expires_at", "redirect_status", "created_at", "owner_id"}).
+						AddRow("https://example.com", time.Now().Add(-time.Minute), 0, time.Now().Add(-time.Hour), "owner"))
+			},
+			expectedURL: "https://example.com",
+			expectedErr: storage.ErrExpired,
+		},
 		{
 			name:     "URL не найден",
 			shortURL: "xyz789",
 			setup: func(mock sqlmock.Sqlmock) {
-				query, args, _ := squirrel.Select("original_url").
+				query, args, _ := squirrel.Select("original_url", "expires_at", "redirect_status", "created_at", "owner_id").
 					From("urls").
 					Where(squirrel.Eq{"short_url": "xyz789"}).ToSql()
 				mock.ExpectQuery(query).
@@ -153,7 +246,7 @@ func TestPostgres_Get(t *testing.T) {
 			name:     "Ошибка базы данных",
 			shortURL: "def456",
 			setup: func(mock sqlmock.Sqlmock) {
-				query, args, _ := squirrel.Select("original_url").
+				query, args, _ := squirrel.Select("original_url", "expires_at", "redirect_status", "created_at", "owner_id").
 					From("urls").
 					Where(squirrel.Eq{"short_url": "def456"}).ToSql()
 				mock.ExpectQuery(query).
@@ -174,18 +267,540 @@ func TestPostgres_Get(t *testing.T) {
 			tt.setup(mock)
 
 			pg := NewPostgres(db)
-			originalURL, err := pg.Get(tt.shortURL)
+			url, err := pg.Get(context.Background(), tt.shortURL)
 
 			if tt.expectedErr != nil {
 				assert.Error(t, err)
 				assert.Equal(t, tt.expectedErr.Error(), err.Error())
-				assert.Empty(t, originalURL)
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, tt.expectedURL, originalURL)
 			}
+			assert.Equal(t, tt.expectedURL, url.OriginalURL)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgres_DeleteExpired(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close() //nolint:errcheck
+
+	now := time.Now()
+	mock.ExpectBegin()
+	expectDeleteURLs(mock, squirrel.LtOrEq{"expires_at": now}, []driver.Value{now}, "abc123", "def456", "ghi789")
+	mock.ExpectCommit()
+
+	pg := NewPostgres(db)
+	deleted, err := pg.DeleteExpired(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgres_SaveClicks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close() //nolint:errcheck
+
+	clickedAt := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	clicks := []storage.Click{
+		{ShortURL: "abc123", ClickedAt: clickedAt, Referrer: "https://ref.example", UserAgent: "curl/8.0", IPHash: "a"},
+		{ShortURL: "abc123", ClickedAt: clickedAt, IPHash: "b"},
+	}
+	query, args, _ := squirrel.Insert("clicks").
+		Columns("short_url", "clicked_at", "referrer", "user_agent", "ip_hash").
+		Values("abc123", clickedAt, "https://ref.example", "curl/8.0", "a").
+		Values("abc123", clickedAt, "", "", "b").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	mock.ExpectExec(regexp.QuoteMeta(query)).
+		WithArgs(convertArgs(args)...).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	pg := NewPostgres(db)
+	assert.NoError(t, pg.SaveClicks(context.Background(), clicks))
+	assert.NoError(t, pg.SaveClicks(context.Background(), nil))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgres_ClickStats(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close() //nolint:errcheck
+
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	where := squirrel.And{
+		squirrel.Eq{"short_url": "abc123"},
+		squirrel.GtOrEq{"clicked_at": base},
+	}
+	totalsSQL, totalsArgs, _ := squirrel.Select("COUNT(*)", "COUNT(DISTINCT ip_hash)").
+		From("clicks").
+		Where(where).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	mock.ExpectQuery(regexp.QuoteMeta(totalsSQL)).
+		WithArgs(convertArgs(totalsArgs)...).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "count"}).AddRow(3, 2))
+
+	bucketsSQL, bucketsArgs, _ := squirrel.Select().
+		Column(squirrel.Expr("to_timestamp(floor(extract(epoch FROM clicked_at) / ?) * ?) AS bucket", int64(3600), int64(3600))).
+		Column("COUNT(*)").
+		From("clicks").
+		Where(where).
+		GroupBy("bucket").
+		OrderBy("bucket").
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	mock.ExpectQuery(regexp.QuoteMeta(bucketsSQL)).
+		WithArgs(convertArgs(bucketsArgs)...).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "count"}).
+			AddRow(base, 2).
+			AddRow(base.Add(time.Hour), 1))
+
+	pg := NewPostgres(db)
+	stats, err := pg.ClickStats(context.Background(), storage.ClickStatsQuery{ShortURL: "abc123", From: base, Bucket: time.Hour})
+	assert.NoError(t, err)
+	assert.Equal(t, storage.ClickStats{
+		TotalClicks:    3,
+		UniqueVisitors: 2,
+		Buckets: []storage.ClickBucket{
+			{Start: base, Clicks: 2},
+			{Start: base.Add(time.Hour), Clicks: 1},
+		},
+	}, stats)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgres_Delete(t *testing.T) {
+	tests := []struct {
+		name        string
+		deleted     []string
+		expectedErr error
+	}{
+		{
+			name:        "Успешное удаление URL вместе с переходами",
+			deleted:     []string{"abc123"},
+			expectedErr: nil,
+		},
+		{
+			name:        "URL не найден",
+			deleted:     nil,
+			expectedErr: storage.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close() //nolint:errcheck
+
+			mock.ExpectBegin()
+			expectDeleteURLs(mock, squirrel.Eq{"short_url": "abc123"}, []driver.Value{"abc123"}, tt.deleted...)
+			if tt.expectedErr == nil {
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			pg := NewPostgres(db)
+			err = pg.Delete(context.Background(), "abc123")
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgres_Update(t *testing.T) {
+	tests := []struct {
+		name        string
+		replaced    []string
+		setup       func(*sqlmock.ExpectedExec)
+		expectedErr error
+	}{
+		{
+			name: "Успешное изменение URL",
+			setup: func(e *sqlmock.ExpectedExec) {
+				e.WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedErr: nil,
+		},
+		{
+			name:     "Замена истёкшей ссылки с новым URL",
+			replaced: []string{"xyz789"},
+			setup: func(e *sqlmock.ExpectedExec) {
+				e.WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedErr: nil,
+		},
+		{
+			name: "URL не найден",
+			setup: func(e *sqlmock.ExpectedExec) {
+				e.WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedErr: storage.ErrNotFound,
+		},
+		{
+			name: "Новый URL уже сокращён",
+			setup: func(e *sqlmock.ExpectedExec) {
+				e.WillReturnError(&pq.Error{Code: "23505", Constraint: "urls_owner_original_url_key"})
+			},
+			expectedErr: storage.ErrOriginalURLExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close() //nolint:errcheck
+
+			mock.ExpectBegin()
+			expectDeleteURLs(mock, squirrel.And{
+				squirrel.Eq{"original_url": "https://example.com/new"},
+				squirrel.Expr("owner_id = (SELECT owner_id FROM urls WHERE short_url = ?)", "abc123"),
+				squirrel.NotEq{"short_url": "abc123"},
+				squirrel.LtOrEq{"expires_at": time.Now()},
+			}, []driver.Value{"https://example.com/new", "abc123", "abc123", sqlmock.AnyArg()}, tt.replaced...)
+			query, args, _ := squirrel.Update("urls").
+				Set("original_url", "https://example.com/new").
+				Where(squirrel.Eq{"short_url": "abc123"}).
+				PlaceholderFormat(squirrel.Dollar).ToSql()
+			tt.setup(mock.ExpectExec(regexp.QuoteMeta(query)).WithArgs(convertArgs(args)...))
+			if tt.expectedErr == nil {
+				mock.ExpectCommit()
+			} else {
+				mock.ExpectRollback()
+			}
+
+			pg := NewPostgres(db)
+			err = pg.Update(context.Background(), "abc123", "https://example.com/new")
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgres_SaveMany(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close() //nolint:errcheck
+
+	createdAt := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	urls := []storage.URL{
+		{ShortURL: "abc123", OriginalURL: "https://a.com", CreatedAt: createdAt},
+		{ShortURL: "def456", OriginalURL: "https://b.com", CreatedAt: createdAt},
+		{ShortURL: "ghi789", OriginalURL: "https://c.com", CreatedAt: createdAt},
+	}
+
+	mock.ExpectBegin()
+	expectDeleteURLs(mock, squirrel.And{ownerOriginalIn(urls), squirrel.LtOrEq{"expires_at": time.Now()}},
+		[]driver.Value{pq.Array([]string{"", "", ""}), pq.Array([]string{"https://a.com", "https://b.com", "https://c.com"}), sqlmock.AnyArg()})
+
+	insert := squirrel.Insert("urls").
+		Columns("short_url", "original_url", "expires_at", "redirect_status", "created_at", "owner_id").
+		Suffix("ON CONFLICT DO NOTHING RETURNING short_url, original_url").
+		PlaceholderFormat(squirrel.Dollar)
+	for _, url := range urls {
+		insert = insert.Values(url.ShortURL, url.OriginalURL, nil, int32(0), createdAt, "")
+	}
+	insertSQL, insertArgs, _ := insert.ToSql()
+	// def456 пропущен из-за уже сокращённого https://b.com, ghi789 — из-за занятого короткого URL
+	mock.ExpectQuery(regexp.QuoteMeta(insertSQL)).
+		WithArgs(convertArgs(insertArgs)...).
+		WillReturnRows(sqlmock.NewRows([]string{"short_url", "original_url"}).AddRow("abc123", "https://a.com"))
+
+	selectSQL, selectArgs, _ := squirrel.Select("short_url", "original_url", "expires_at", "redirect_status", "created_at", "owner_id").
+		From("urls").
+		Where(ownerOriginalIn(urls[1:])).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	mock.ExpectQuery(regexp.QuoteMeta(selectSQL)).
+		WithArgs(convertArgs(selectArgs)...).
+		WillReturnRows(sqlmock.NewRows([]string{"short_url", "original_url", "expires_at", "redirect_status", "created_at", "owner_id"}).
+			AddRow("xyz789", "https://b.com", nil, 0, createdAt, ""))
+	mock.ExpectCommit()
+
+	pg := NewPostgres(db)
+	results, err := pg.SaveMany(context.Background(), urls)
+	assert.NoError(t, err)
+	if assert.Len(t, results, 3) {
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, "abc123", results[0].URL.ShortURL)
+		assert.NoError(t, results[1].Err)
+		assert.Equal(t, "xyz789", results[1].URL.ShortURL)
+		assert.ErrorIs(t, results[2].Err, storage.ErrShortURLConflict)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgres_SaveManyRollback(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close() //nolint:errcheck
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("DELETE FROM urls").WillReturnError(errors.New("database error"))
+	mock.ExpectRollback()
+
+	pg := NewPostgres(db)
+	results, err := pg.SaveMany(context.Background(), []storage.URL{{ShortURL: "abc123", OriginalURL: "https://a.com"}})
+	assert.EqualError(t, err, "database error")
+	assert.Nil(t, results)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgres_GetMany(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close() //nolint:errcheck
+
+	query, args, _ := squirrel.Select("short_url", "original_url", "expires_at", "redirect_status", "created_at", "owner_id").
+		From("urls").
+		Where(squirrel.Eq{"short_url": []string{"abc123", "def456", "xyz789"}}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WithArgs(convertArgs(args)...).
+		WillReturnRows(sqlmock.NewRows([]string{"short_url", "original_url", "expires_at", "redirect_status", "created_at", "owner_id"}).
+			AddRow("abc123", "https://example.com", nil, 301, time.Now(), "").
+			AddRow("def456", "https://expired.com", time.Now().Add(-time.Minute), 0, time.Now().Add(-time.Hour), ""))
+
+	pg := NewPostgres(db)
+	results, err := pg.GetMany(context.Background(), []string{"abc123", "def456", "xyz789"})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 3) {
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, "https://example.com", results[0].URL.OriginalURL)
+		assert.Equal(t, int32(301), results[0].URL.RedirectStatus)
+		assert.ErrorIs(t, results[1].Err, storage.ErrExpired)
+		assert.ErrorIs(t, results[2].Err, storage.ErrNotFound)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgres_TakeTokens(t *testing.T) {
+	bucket := storage.TokenBucket{Rate: 2, Burst: 10}
+	now := time.Now()
+	selectQuery, _, _ := squirrel.Select("tokens", "updated_at").
+		From("rate_limits").
+		Where(squirrel.Eq{"key": "create:ip:10.0.0.1"}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	tests := []struct {
+		name               string
+		setupMock          func(mock sqlmock.Sqlmock)
+		expectedOK         bool
+		expectedRetryAfter time.Duration
+	}{
+		{
+			name: "Токены списаны",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO rate_limits (key,tokens,updated_at) VALUES ($1,$2,$3) ON CONFLICT (key) DO UPDATE")).
+					WithArgs("create:ip:10.0.0.1", 9.0, now, now, bucket.Rate, bucket.Burst, 1, now, now, bucket.Rate, bucket.Burst, 1.0).
+					WillReturnRows(sqlmock.NewRows([]string{"tokens"}).AddRow(9.0))
+			},
+			expectedOK: true,
+		},
+		{
+			name: "Токенов не хватает",
+			setupMock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO rate_limits")).
+					WillReturnRows(sqlmock.NewRows([]string{"tokens"}))
+				mock.ExpectQuery(regexp.QuoteMeta(selectQuery)).
+					WithArgs("create:ip:10.0.0.1").
+					WillReturnRows(sqlmock.NewRows([]string{"tokens", "updated_at"}).AddRow(0.5, now.Add(-100*time.Millisecond)))
+			},
+			expectedOK:         false,
+			expectedRetryAfter: 150 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close() //nolint:errcheck
+			tt.setupMock(mock)
+
+			pg := NewPostgres(db)
+			ok, retryAfter, err := pg.TakeTokens(context.Background(), "create:ip:10.0.0.1", bucket, 1, now)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedOK, ok)
+			assert.InDelta(t, tt.expectedRetryAfter, retryAfter, float64(time.Millisecond))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgres_KeyByHash(t *testing.T) {
+	createdAt := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	query, _, _ := squirrel.Select("id", "key_hash", "owner_id", "name", "created_at", "revoked_at").
+		From("api_keys").
+		Where(squirrel.Eq{"key_hash": "hash1"}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+	columns := []string{"id", "key_hash", "owner_id", "name", "created_at", "revoked_at"}
+
+	tests := []struct {
+		name        string
+		rows        *sqlmock.Rows
+		expectedKey storage.APIKey
+		expectedErr error
+	}{
+		{
+			name:        "Ключ найден",
+			rows:        sqlmock.NewRows(columns).AddRow("key1", "hash1", "alice", "ci", createdAt, nil),
+			expectedKey: storage.APIKey{ID: "key1", Hash: "hash1", OwnerID: "alice", Name: "ci", CreatedAt: createdAt},
+		},
+		{
+			name:        "Ключ не найден",
+			rows:        sqlmock.NewRows(columns),
+			expectedErr: storage.ErrKeyNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close() //nolint:errcheck
+			mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("hash1").WillReturnRows(tt.rows)
+
+			pg := NewPostgres(db)
+			key, err := pg.KeyByHash(context.Background(), "hash1")
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Equal(t, tt.expectedKey, key)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgres_RevokeKey(t *testing.T) {
+	now := time.Now()
+	query, _, _ := squirrel.Update("api_keys").
+		Set("revoked_at", squirrel.Expr("COALESCE(revoked_at, ?)", now)).
+		Where(squirrel.Eq{"id": "key1"}).
+		PlaceholderFormat(squirrel.Dollar).ToSql()
+
+	tests := []struct {
+		name        string
+		affected    int64
+		expectedErr error
+	}{
+		{name: "Ключ отозван", affected: 1},
+		{name: "Ключ не найден", affected: 0, expectedErr: storage.ErrKeyNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close() //nolint:errcheck
+			mock.ExpectExec(regexp.QuoteMeta(query)).
+				WithArgs(now, "key1").
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
 
+			pg := NewPostgres(db)
+			assert.ErrorIs(t, pg.RevokeKey(context.Background(), "key1", now), tt.expectedErr)
 			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
+
+func TestPostgres_List(t *testing.T) {
+	created := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	after := storage.ListCursor{CreatedAt: created, ShortURL: "abc123"}
+
+	tests := []struct {
+		name     string
+		query    storage.ListQuery
+		where    squirrel.And
+		order    string
+		expected []driver.Value
+	}{
+		{
+			name:     "Первая страница",
+			query:    storage.ListQuery{OwnerID: "alice", Limit: 2},
+			order:    "DESC",
+			expected: []driver.Value{"alice", sqlmock.AnyArg()},
+		},
+		{
+			name: "Фильтры и курсор",
+			query: storage.ListQuery{
+				OwnerID:     "alice",
+				CreatedFrom: created.Add(-time.Hour),
+				Domain:      "example.com",
+				Contains:    "50%_off",
+				Order:       storage.OldestFirst,
+				After:       &after,
+				Limit:       2,
+			},
+			where: squirrel.And{
+				squirrel.GtOrEq{"created_at": created.Add(-time.Hour)},
+				squirrel.Expr(hostSQL+" = ?", "example.com"),
+				squirrel.Expr("original_url ILIKE ?", `%50\%\_off%`),
+				squirrel.Expr("(created_at, short_url) > (?, ?)", created, "abc123"),
+			},
+			order: "ASC",
+			expected: []driver.Value{
+				"alice", sqlmock.AnyArg(), created.Add(-time.Hour), "example.com", `%50\%\_off%`, created, "abc123",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close() //nolint:errcheck
+
+			where := append(squirrel.And{
+				squirrel.Eq{"owner_id": "alice"},
+				squirrel.Or{squirrel.Eq{"expires_at": nil}, squirrel.Gt{"expires_at": time.Now()}},
+			}, tt.where...)
+			query, _, _ := squirrel.Select(urlColumns...).
+				From("urls").
+				Where(where).
+				OrderBy("created_at "+tt.order, "short_url "+tt.order).
+				Limit(2).
+				PlaceholderFormat(squirrel.Dollar).ToSql()
+			mock.ExpectQuery(regexp.QuoteMeta(query)).
+				WithArgs(tt.expected...).
+				WillReturnRows(sqlmock.NewRows(urlColumns).
+					AddRow("def456", "https://example.com/50%_off", nil, 302, created.Add(time.Minute), "alice"))
+
+			pg := NewPostgres(db)
+			urls, err := pg.List(context.Background(), tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, []storage.URL{{
+				ShortURL:       "def456",
+				OriginalURL:    "https://example.com/50%_off",
+				RedirectStatus: 302,
+				CreatedAt:      created.Add(time.Minute),
+				OwnerID:        "alice",
+			}}, urls)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgres_Count(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close() //nolint:errcheck
+
+	query, _, _ := squirrel.Select("COUNT(*)").From("urls").PlaceholderFormat(squirrel.Dollar).ToSql()
+	mock.ExpectQuery(regexp.QuoteMeta(query)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+	pg := NewPostgres(db)
+	count, err := pg.Count(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,709 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"url-shortener/internal/storage"
+)
+
+// Ключи Redis. Скрипты ниже используют те же имена
+const (
+	urlKeyPrefix      = "url:"            // хэш ссылки по короткому URL
+	originalKeyPrefix = "owner-original:" // короткий URL по storage.OriginalKey
+	expiryKey         = "url-expiry"
+	countKey          = "url-count"      // число хэшей ссылок url:*
+	ownerURLsPrefix   = "owner-urls:"    // множество ссылок владельца, см. listMember
+	clicksKeyPrefix   = "clicks:"        // список переходов в JSON
+	rateLimitPrefix   = "ratelimit:"     // хэш корзины токенов: tokens и updated_at в мс
+	keysKey           = "api-keys"       // хэш ключей API: хеш ключа -> storage.APIKey в JSON
+	schemaVersionKey  = "schema-version" // число выполненных шагов migrations
+
+	// legacyOriginalKeyPrefix — индекс оригинальных URL без владельца из прежних версий
+	legacyOriginalKeyPrefix = "original:"
+)
+
+// deleteExpiredBatch — число истёкших ссылок, удаляемых одним вызовом скрипта
+const deleteExpiredBatch = 1000
+
+// listScanCount — подсказка COUNT для SCAN при переборе всех ссылок
+const listScanCount = 1000
+
+// listChunkSize — число ссылок, читаемых List из множества владельца за один запрос
+const listChunkSize = 100
+
+// createdAtLayout — формат времени создания в хэше ссылки: UTC с наносекундами фиксированной ширины,
+// поэтому строки сравниваются в том же порядке, что и моменты времени
+const createdAtLayout = "2006-01-02T15:04:05.000000000Z"
+
+// luaHelpers — общие функции скриптов: ключ индекса оригинальных URL (как storage.OriginalKey),
+// элемент множества ссылок владельца (как listMember), проверка срока действия и удаление ссылки
+// вместе с индексами и переходами
+const luaHelpers = `
+local function originalKey(owner, original)
+	return 'owner-original:' .. #owner .. ':' .. owner .. ':' .. original
+end
+
+local function listMember(createdAt, short)
+	return createdAt .. '|' .. short
+end
+
+local function expired(key, now)
+	local expiresAt = tonumber(redis.call('HGET', key, 'expires_at') or '0')
+	return expiresAt > 0 and expiresAt <= now
+end
+
+local function remove(short)
+	local key = 'url:' .. short
+	local fields = redis.call('HMGET', key, 'original_url', 'owner_id', 'created_at')
+	if redis.call('DEL', key) == 1 then
+		redis.call('DECR', 'url-count')
+	end
+	redis.call('ZREM', 'url-expiry', short)
+	redis.call('DEL', 'clicks:' .. short)
+	if fields[1] then
+		local owner = fields[2] or ''
+		local index = originalKey(owner, fields[1])
+		if redis.call('GET', index) == short then
+			redis.call('DEL', index)
+		end
+		redis.call('ZREM', 'owner-urls:' .. owner, listMember(fields[3] or '', short))
+	end
+end
+`
+
+// saveScript атомарно сохраняет пакет ссылок с той же семантикой, что и memory.Memory:
+// оригинальный URL сокращается один раз для каждого владельца.
+// ARGV[1] — текущее время в мс, далее по 6 аргументов на ссылку: короткий URL, оригинальный URL,
+// срок действия в мс (0 — бессрочно), статус редиректа, время создания в createdAtLayout, владелец.
+// Для каждой ссылки возвращает {'saved'}, {'existing', короткий URL, поля хэша} или {'conflict'}
+var saveScript = redis.NewScript(luaHelpers + `
+local now = tonumber(ARGV[1])
+local results = {}
+for i = 2, #ARGV, 6 do
+	local short, original, expiresAt, owner = ARGV[i], ARGV[i + 1], ARGV[i + 2], ARGV[i + 5]
+	local key = 'url:' .. short
+	local index = originalKey(owner, original)
+	local result
+	if redis.call('EXISTS', key) == 1 and not expired(key, now) then
+		result = {'conflict'}
+	else
+		remove(short)
+		local existing = redis.call('GET', index)
+		if existing and redis.call('EXISTS', 'url:' .. existing) == 1 and not expired('url:' .. existing, now) then
+			result = {'existing', existing, redis.call('HGETALL', 'url:' .. existing)}
+		else
+			if existing then
+				remove(existing)
+				redis.call('DEL', index)
+			end
+			redis.call('HSET', key, 'original_url', original, 'expires_at', expiresAt,
+				'redirect_status', ARGV[i + 3], 'created_at', ARGV[i + 4], 'owner_id', owner)
+			redis.call('INCR', 'url-count')
+			redis.call('SET', index, short)
+			redis.call('ZADD', 'owner-urls:' .. owner, 0, listMember(ARGV[i + 4], short))
+			if tonumber(expiresAt) > 0 then
+				redis.call('ZADD', 'url-expiry', expiresAt, short)
+			end
+			result = {'saved'}
+		end
+	end
+	results[#results + 1] = result
+end
+return results
+`)
+
+// deleteScript удаляет ссылку ARGV[1], возвращает 0 если ссылки нет
+var deleteScript = redis.NewScript(luaHelpers + `
+if redis.call('EXISTS', 'url:' .. ARGV[1]) == 0 then
+	return 0
+end
+remove(ARGV[1])
+return 1
+`)
+
+// updateScript меняет оригинальный URL ссылки ARGV[1] на ARGV[2]. Истёкшая к моменту ARGV[3] в мс ссылка
+// владельца с новым оригинальным URL удаляется
+var updateScript = redis.NewScript(luaHelpers + `
+local key = 'url:' .. ARGV[1]
+local fields = redis.call('HMGET', key, 'original_url', 'owner_id')
+local original, owner = fields[1], fields[2] or ''
+if not original then
+	return 'not_found'
+end
+if original == ARGV[2] then
+	return 'ok'
+end
+local existing = redis.call('GET', originalKey(owner, ARGV[2]))
+if existing then
+	if redis.call('EXISTS', 'url:' .. existing) == 1 and not expired('url:' .. existing, tonumber(ARGV[3])) then
+		return 'original_exists'
+	end
+	remove(existing)
+	redis.call('DEL', originalKey(owner, ARGV[2]))
+end
+redis.call('DEL', originalKey(owner, original))
+redis.call('SET', originalKey(owner, ARGV[2]), ARGV[1])
+redis.call('HSET', key, 'original_url', ARGV[2])
+return 'ok'
+`)
+
+// deleteExpiredScript удаляет до ARGV[2] ссылок, истёкших к моменту ARGV[1] в мс, и возвращает их число
+var deleteExpiredScript = redis.NewScript(luaHelpers + `
+local shorts = redis.call('ZRANGEBYSCORE', 'url-expiry', '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, short in ipairs(shorts) do
+	remove(short)
+end
+return #shorts
+`)
+
+// takeTokensScript списывает ARGV[4] токенов из корзины ARGV[1] со скоростью пополнения ARGV[2]
+// в секунду и ёмкостью ARGV[3] к моменту ARGV[5] в мс. Возвращает 0, если токены списаны,
+// иначе время ожидания в мс. Корзина удаляется, когда она снова заполнилась бы до краёв
+var takeTokensScript = redis.NewScript(`
+local key = 'ratelimit:' .. ARGV[1]
+local rate, burst, n, now = tonumber(ARGV[2]), tonumber(ARGV[3]), tonumber(ARGV[4]), tonumber(ARGV[5])
+local need = math.min(n, burst)
+local tokens = burst
+local bucket = redis.call('HMGET', key, 'tokens', 'updated_at')
+if bucket[1] then
+	tokens = math.min(tonumber(bucket[1]) + math.max(now - tonumber(bucket[2]), 0) / 1000 * rate, burst)
+end
+if tokens < need then
+	return math.ceil((need - tokens) / rate * 1000)
+end
+tokens = tokens - n
+redis.call('HSET', key, 'tokens', tostring(tokens), 'updated_at', ARGV[5])
+redis.call('PEXPIRE', key, math.ceil((burst - tokens) / rate * 1000))
+return 0
+`)
+
+// Redis реализует хранилище URL в Redis. Изменения выполняются Lua-скриптами, поэтому гарантия
+// одного короткого URL на оригинальный URL владельца сохраняется при нескольких экземплярах сервиса.
+// Скрипты обращаются к ключам, не переданным в KEYS, поэтому Redis Cluster не поддерживается
+type Redis struct {
+	client *redis.Client
+}
+
+// NewRedis создает новое Redis-хранилище
+func NewRedis(client *redis.Client) *Redis {
+	return &Redis{client: client}
+}
+
+// Ping проверяет подключение к Redis
+func (s *Redis) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+// migrations — шаги перестройки индексов по хэшам ссылок для данных прежних версий.
+// Номер шага в списке плюс один — версия схемы после него
+var migrations = []func(*Redis, context.Context) error{
+	(*Redis).migrateOwnerOriginals,
+	(*Redis).migrateOwnerURLs,
+	(*Redis).migrateURLCount,
+}
+
+// Migrate выполняет шаги migrations, которых ещё не было в этой базе, и запоминает версию схемы.
+// Шаги идемпотентны, поэтому одновременный запуск нескольких экземпляров сервиса безопасен
+func (s *Redis) Migrate(ctx context.Context) error {
+	version, err := s.client.Get(ctx, schemaVersionKey).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	for ; version < len(migrations); version++ {
+		if err := migrations[version](s, ctx); err != nil {
+			return fmt.Errorf("migrate to version %d: %w", version+1, err)
+		}
+		if err := s.client.Set(ctx, schemaVersionKey, version+1, 0).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateOwnerOriginals строит индекс оригинальных URL по владельцам и удаляет индекс без владельца
+func (s *Redis) migrateOwnerOriginals(ctx context.Context) error {
+	iter := s.client.Scan(ctx, 0, urlKeyPrefix+"*", listScanCount).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		fields, err := s.client.HMGet(ctx, key, "original_url", "owner_id").Result()
+		if err != nil {
+			return err
+		}
+		original, _ := fields[0].(string)
+		owner, _ := fields[1].(string)
+		if original == "" {
+			continue
+		}
+		index := originalKeyPrefix + storage.OriginalKey(owner, original)
+		if err := s.client.SetNX(ctx, index, strings.TrimPrefix(key, urlKeyPrefix), 0).Err(); err != nil {
+			return err
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	iter = s.client.Scan(ctx, 0, legacyOriginalKeyPrefix+"*", listScanCount).Iterator()
+	for iter.Next(ctx) {
+		if err := s.client.Del(ctx, iter.Val()).Err(); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+// migrateOwnerURLs переводит время создания ссылок в createdAtLayout и строит множества ссылок владельцев
+func (s *Redis) migrateOwnerURLs(ctx context.Context) error {
+	iter := s.client.Scan(ctx, 0, urlKeyPrefix+"*", listScanCount).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		hash, err := s.client.HGetAll(ctx, key).Result()
+		if err != nil {
+			return err
+		}
+		if len(hash) == 0 {
+			continue
+		}
+		url, err := parseURL(strings.TrimPrefix(key, urlKeyPrefix), hash)
+		if err != nil {
+			return err
+		}
+		createdAt := url.CreatedAt.UTC().Format(createdAtLayout)
+		_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, key, "created_at", createdAt)
+			pipe.ZAdd(ctx, ownerURLsPrefix+url.OwnerID, redis.Z{Member: listMember(createdAt, url.ShortURL)})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+// migrateURLCount заводит счётчик ссылок по числу ключей url:*. Ссылки, сохранённые другими
+// экземплярами во время перебора, могут сдвинуть счётчик на несколько единиц, что для метрик допустимо
+func (s *Redis) migrateURLCount(ctx context.Context) error {
+	var count int64
+	iter := s.client.Scan(ctx, 0, urlKeyPrefix+"*", listScanCount).Iterator()
+	for iter.Next(ctx) {
+		count++
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	return s.client.Set(ctx, countKey, count, 0).Err()
+}
+
+// Save сохраняет ссылку, возвращает существующий короткий URL если оригинальный уже сохранен тем же владельцем.
+// Истёкшие, но ещё не удалённые ссылки не мешают сохранению
+func (s *Redis) Save(ctx context.Context, url storage.URL) (string, error) {
+	results, err := s.SaveMany(ctx, []storage.URL{url})
+	if err != nil {
+		return "", err
+	}
+	if results[0].Err != nil {
+		return "", results[0].Err
+	}
+	return results[0].URL.ShortURL, nil
+}
+
+// SaveMany атомарно сохраняет пакет ссылок одним вызовом скрипта
+func (s *Redis) SaveMany(ctx context.Context, urls []storage.URL) ([]storage.BatchResult, error) {
+	if len(urls) == 0 {
+		return nil, nil
+	}
+	args := make([]interface{}, 0, 1+6*len(urls))
+	args = append(args, time.Now().UnixMilli())
+	for _, url := range urls {
+		args = append(args, url.ShortURL, url.OriginalURL, expiresAtMillis(url.ExpiresAt),
+			url.RedirectStatus, url.CreatedAt.UTC().Format(createdAtLayout), url.OwnerID)
+	}
+
+	reply, err := saveScript.Run(ctx, s.client, nil, args...).Slice()
+	if err != nil {
+		return nil, err
+	}
+	if len(reply) != len(urls) {
+		return nil, fmt.Errorf("save script returned %d results for %d URLs", len(reply), len(urls))
+	}
+	results := make([]storage.BatchResult, len(urls))
+	for i, item := range reply {
+		fields, _ := item.([]interface{})
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("unexpected save script result %v", item)
+		}
+		switch fields[0] {
+		case "saved":
+			results[i].URL = urls[i]
+			results[i].URL.ExpiresAt = fromMillis(expiresAtMillis(urls[i].ExpiresAt))
+		case "existing":
+			shortURL, _ := fields[1].(string)
+			hash, _ := fields[2].([]interface{})
+			results[i].URL, err = parseURL(shortURL, pairsToMap(hash))
+			if err != nil {
+				return nil, err
+			}
+		case "conflict":
+			results[i].Err = storage.ErrShortURLConflict
+		default:
+			return nil, fmt.Errorf("unexpected save script result %v", fields[0])
+		}
+	}
+	return results, nil
+}
+
+// Get возвращает ссылку по её короткой версии
+func (s *Redis) Get(ctx context.Context, shortURL string) (storage.URL, error) {
+	hash, err := s.client.HGetAll(ctx, urlKeyPrefix+shortURL).Result()
+	if err != nil {
+		return storage.URL{}, err
+	}
+	return urlFromHash(shortURL, hash, time.Now())
+}
+
+// GetMany возвращает пакет ссылок по их коротким версиям за один проход по сети
+func (s *Redis) GetMany(ctx context.Context, shortURLs []string) ([]storage.BatchResult, error) {
+	cmds := make([]*redis.MapStringStringCmd, len(shortURLs))
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, shortURL := range shortURLs {
+			cmds[i] = pipe.HGetAll(ctx, urlKeyPrefix+shortURL)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	results := make([]storage.BatchResult, len(shortURLs))
+	for i, cmd := range cmds {
+		results[i].URL, results[i].Err = urlFromHash(shortURLs[i], cmd.Val(), now)
+	}
+	return results, nil
+}
+
+// Delete удаляет ссылку по её короткой версии
+func (s *Redis) Delete(ctx context.Context, shortURL string) error {
+	deleted, err := deleteScript.Run(ctx, s.client, nil, shortURL).Int()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+// Update меняет оригинальный URL короткой ссылки. Истёкшая ссылка владельца с новым оригинальным URL
+// не мешает изменению и удаляется
+func (s *Redis) Update(ctx context.Context, shortURL, newOriginalURL string) error {
+	result, err := updateScript.Run(ctx, s.client, nil, shortURL, newOriginalURL, time.Now().UnixMilli()).Text()
+	if err != nil {
+		return err
+	}
+	switch result {
+	case "ok":
+		return nil
+	case "not_found":
+		return storage.ErrNotFound
+	case "original_exists":
+		return storage.ErrOriginalURLExists
+	default:
+		return fmt.Errorf("unexpected update script result %q", result)
+	}
+}
+
+// DeleteExpired удаляет ссылки, срок действия которых истёк к моменту now
+func (s *Redis) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	var total int64
+	for {
+		deleted, err := deleteExpiredScript.Run(ctx, s.client, nil, now.UnixMilli(), deleteExpiredBatch).Int64()
+		if err != nil {
+			return total, err
+		}
+		total += deleted
+		if deleted < deleteExpiredBatch {
+			return total, nil
+		}
+	}
+}
+
+// Count возвращает число хранимых ссылок из счётчика, который ведут скрипты сохранения и удаления
+func (s *Redis) Count(ctx context.Context) (int64, error) {
+	count, err := s.client.Get(ctx, countKey).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return count, err
+}
+
+// List возвращает страницу ссылок владельца. Ссылки читаются частями из множества владельца
+// в порядке выборки, начиная с границ по времени создания и позиции After, до query.Limit подходящих
+func (s *Redis) List(ctx context.Context, query storage.ListQuery) ([]storage.URL, error) {
+	lo, hi := "-", "+"
+	if !query.CreatedFrom.IsZero() {
+		lo = "[" + query.CreatedFrom.UTC().Format(createdAtLayout)
+	}
+	if !query.CreatedTo.IsZero() {
+		hi = "(" + query.CreatedTo.UTC().Format(createdAtLayout)
+	}
+	if after := query.After; after != nil {
+		cursor := "(" + listMember(after.CreatedAt.UTC().Format(createdAtLayout), after.ShortURL)
+		if query.Order == storage.OldestFirst {
+			if lo == "-" || cursor[1:] > lo[1:] {
+				lo = cursor
+			}
+		} else if hi == "+" || cursor[1:] < hi[1:] {
+			hi = cursor
+		}
+	}
+
+	key := ownerURLsPrefix + query.OwnerID
+	now := time.Now()
+	var urls []storage.URL
+	for {
+		bounds := &redis.ZRangeBy{Min: lo, Max: hi, Count: listChunkSize}
+		var members []string
+		var err error
+		if query.Order == storage.OldestFirst {
+			members, err = s.client.ZRangeByLex(ctx, key, bounds).Result()
+		} else {
+			members, err = s.client.ZRevRangeByLex(ctx, key, bounds).Result()
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		shortURLs := make([]string, len(members))
+		for i, member := range members {
+			_, shortURLs[i], _ = strings.Cut(member, "|")
+		}
+		results, err := s.GetMany(ctx, shortURLs)
+		if err != nil {
+			return nil, err
+		}
+		for _, res := range results {
+			if res.Err != nil && !errors.Is(res.Err, storage.ErrNotFound) && !errors.Is(res.Err, storage.ErrExpired) {
+				return nil, res.Err
+			}
+			if res.Err == nil && query.Matches(res.URL, now) {
+				urls = append(urls, res.URL)
+				if query.Limit > 0 && len(urls) == query.Limit {
+					return urls, nil
+				}
+			}
+		}
+
+		if len(members) < listChunkSize {
+			return urls, nil
+		}
+		if last := "(" + members[len(members)-1]; query.Order == storage.OldestFirst {
+			lo = last
+		} else {
+			hi = last
+		}
+	}
+}
+
+// TakeTokens атомарно списывает n токенов из корзины key скриптом takeTokensScript
+func (s *Redis) TakeTokens(ctx context.Context, key string, bucket storage.TokenBucket, n int, now time.Time) (bool, time.Duration, error) {
+	wait, err := takeTokensScript.Run(ctx, s.client, nil, key, bucket.Rate, bucket.Burst, n, now.UnixMilli()).Int64()
+	if err != nil {
+		return false, 0, err
+	}
+	return wait == 0, time.Duration(wait) * time.Millisecond, nil
+}
+
+// SaveKey сохраняет ключ API
+func (s *Redis) SaveKey(ctx context.Context, key storage.APIKey) error {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	return s.client.HSet(ctx, keysKey, key.Hash, data).Err()
+}
+
+// KeyByHash возвращает ключ API по хешу
+func (s *Redis) KeyByHash(ctx context.Context, hash string) (storage.APIKey, error) {
+	data, err := s.client.HGet(ctx, keysKey, hash).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return storage.APIKey{}, storage.ErrKeyNotFound
+	}
+	if err != nil {
+		return storage.APIKey{}, err
+	}
+	var key storage.APIKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return storage.APIKey{}, err
+	}
+	return key, nil
+}
+
+// ListKeys возвращает ключи API в порядке создания
+func (s *Redis) ListKeys(ctx context.Context) ([]storage.APIKey, error) {
+	items, err := s.client.HVals(ctx, keysKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]storage.APIKey, 0, len(items))
+	for _, item := range items {
+		var key storage.APIKey
+		if err := json.Unmarshal([]byte(item), &key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	storage.SortKeys(keys)
+	return keys, nil
+}
+
+// RevokeKey отзывает ключ API. Повторный отзыв не меняет время отзыва
+func (s *Redis) RevokeKey(ctx context.Context, id string, now time.Time) error {
+	keys, err := s.ListKeys(ctx)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if key.ID != id {
+			continue
+		}
+		if key.Revoked() {
+			return nil
+		}
+		key.RevokedAt = now
+		return s.SaveKey(ctx, key)
+	}
+	return storage.ErrKeyNotFound
+}
+
+// SaveClicks сохраняет пачку переходов за один проход по сети
+func (s *Redis) SaveClicks(ctx context.Context, clicks []storage.Click) error {
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, click := range clicks {
+			data, err := json.Marshal(click)
+			if err != nil {
+				return err
+			}
+			pipe.RPush(ctx, clicksKeyPrefix+click.ShortURL, data)
+		}
+		return nil
+	})
+	return err
+}
+
+// ClickStats возвращает статистику переходов, сгруппированную по интервалам
+func (s *Redis) ClickStats(ctx context.Context, query storage.ClickStatsQuery) (storage.ClickStats, error) {
+	items, err := s.client.LRange(ctx, clicksKeyPrefix+query.ShortURL, 0, -1).Result()
+	if err != nil {
+		return storage.ClickStats{}, err
+	}
+
+	var stats storage.ClickStats
+	visitors := make(map[string]struct{})
+	buckets := make(map[time.Time]int64)
+	for _, item := range items {
+		var click storage.Click
+		if err := json.Unmarshal([]byte(item), &click); err != nil {
+			return storage.ClickStats{}, err
+		}
+		if !query.From.IsZero() && click.ClickedAt.Before(query.From) {
+			continue
+		}
+		if !query.To.IsZero() && !click.ClickedAt.Before(query.To) {
+			continue
+		}
+		stats.TotalClicks++
+		visitors[click.IPHash] = struct{}{}
+		buckets[storage.BucketStart(click.ClickedAt.UTC(), query.Bucket)]++
+	}
+	stats.UniqueVisitors = int64(len(visitors))
+	for start, clicks := range buckets {
+		stats.Buckets = append(stats.Buckets, storage.ClickBucket{Start: start, Clicks: clicks})
+	}
+	sort.Slice(stats.Buckets, func(i, j int) bool {
+		return stats.Buckets[i].Start.Before(stats.Buckets[j].Start)
+	})
+	return stats, nil
+}
+
+// urlFromHash возвращает ссылку из хэша Redis с учётом срока действия
+func urlFromHash(shortURL string, hash map[string]string, now time.Time) (storage.URL, error) {
+	if len(hash) == 0 {
+		return storage.URL{}, storage.ErrNotFound
+	}
+	url, err := parseURL(shortURL, hash)
+	if err != nil {
+		return storage.URL{}, err
+	}
+	if url.Expired(now) {
+		return url, storage.ErrExpired
+	}
+	return url, nil
+}
+
+// parseURL разбирает поля хэша ссылки
+func parseURL(shortURL string, hash map[string]string) (storage.URL, error) {
+	expiresAt, err := strconv.ParseInt(hash["expires_at"], 10, 64)
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("parse expires_at of %s: %w", shortURL, err)
+	}
+	redirectStatus, err := strconv.ParseInt(hash["redirect_status"], 10, 32)
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("parse redirect_status of %s: %w", shortURL, err)
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, hash["created_at"])
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("parse created_at of %s: %w", shortURL, err)
+	}
+	return storage.URL{
+		ShortURL:       shortURL,
+		OriginalURL:    hash["original_url"],
+		ExpiresAt:      fromMillis(expiresAt),
+		RedirectStatus: int32(redirectStatus),
+		CreatedAt:      createdAt,
+		OwnerID:        hash["owner_id"],
+	}, nil
+}
+
+// pairsToMap превращает ответ HGETALL из Lua-скрипта (плоский список ключей и значений) в map
+func pairsToMap(pairs []interface{}) map[string]string {
+	hash := make(map[string]string, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		key, _ := pairs[i].(string)
+		value, _ := pairs[i+1].(string)
+		hash[key] = value
+	}
+	return hash
+}
+
+// listMember возвращает элемент множества ссылок владельца. Все элементы множества имеют нулевой вес
+// и упорядочены лексикографически, то есть по времени создания, а при равном времени — по короткому URL
+func listMember(createdAt, shortURL string) string {
+	return createdAt + "|" + shortURL
+}
+
+// expiresAtMillis возвращает срок действия в мс, 0 для бессрочной ссылки
+func expiresAtMillis(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+// fromMillis — обратное преобразование к expiresAtMillis
+func fromMillis(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
@@ -0,0 +1,420 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"url-shortener/internal/storage"
+)
+
+func newTestRedis(t *testing.T) (*Redis, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() }) //nolint:errcheck
+	return NewRedis(client), mr
+}
+
+// Тест для метода Save
+func TestRedis_Save(t *testing.T) {
+	tests := []struct {
+		name          string
+		url           storage.URL
+		setup         func(s *Redis)
+		expectedShort string
+		expectedErr   error
+	}{
+		{
+			name:          "Успешное сохранение нового URL",
+			url:           storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"},
+			setup:         func(s *Redis) {},
+			expectedShort: "abc123",
+		},
+		{
+			name: "Повторное использование существующего originalURL",
+			url:  storage.URL{ShortURL: "xyz789", OriginalURL: "https://example.com"},
+			setup: func(s *Redis) {
+				s.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"}) //nolint:errcheck
+			},
+			expectedShort: "abc123",
+		},
+		{
+			name: "Тот же originalURL другого владельца",
+			url:  storage.URL{ShortURL: "xyz789", OriginalURL: "https://example.com", OwnerID: "bob"},
+			setup: func(s *Redis) {
+				s.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com", OwnerID: "alice"}) //nolint:errcheck
+			},
+			expectedShort: "xyz789",
+		},
+		{
+			name: "Ошибка из-за дублирования shortURL",
+			url:  storage.URL{ShortURL: "abc123", OriginalURL: "https://newexample.com"},
+			setup: func(s *Redis) {
+				s.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"}) //nolint:errcheck
+			},
+			expectedErr: storage.ErrShortURLConflict,
+		},
+		{
+			name: "Замена истёкшей ссылки на тот же originalURL",
+			url:  storage.URL{ShortURL: "xyz789", OriginalURL: "https://example.com"},
+			setup: func(s *Redis) {
+				s.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com", ExpiresAt: time.Now().Add(-time.Minute)}) //nolint:errcheck
+			},
+			expectedShort: "xyz789",
+		},
+		{
+			name: "Истёкшая ссылка освобождает короткий URL",
+			url:  storage.URL{ShortURL: "abc123", OriginalURL: "https://newexample.com"},
+			setup: func(s *Redis) {
+				s.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com", ExpiresAt: time.Now().Add(-time.Minute)}) //nolint:errcheck
+			},
+			expectedShort: "abc123",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestRedis(t)
+			tt.setup(s)
+
+			shortURL, err := s.Save(context.Background(), tt.url)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Empty(t, shortURL)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedShort, shortURL)
+		})
+	}
+}
+
+func TestRedis_Get(t *testing.T) {
+	s, _ := newTestRedis(t)
+	createdAt := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	s.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com", RedirectStatus: 301, CreatedAt: createdAt, ExpiresAt: expiresAt, OwnerID: "alice"}) //nolint:errcheck
+	s.Save(context.Background(), storage.URL{ShortURL: "def456", OriginalURL: "https://expired.com", ExpiresAt: time.Now().Add(-time.Minute)})                                           //nolint:errcheck
+
+	url, err := s.Get(context.Background(), "abc123")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com", url.OriginalURL)
+	assert.Equal(t, int32(301), url.RedirectStatus)
+	assert.True(t, createdAt.Equal(url.CreatedAt))
+	assert.Equal(t, "alice", url.OwnerID)
+	assert.True(t, expiresAt.Equal(url.ExpiresAt))
+
+	url, err = s.Get(context.Background(), "def456")
+	assert.ErrorIs(t, err, storage.ErrExpired)
+	assert.Equal(t, "https://expired.com", url.OriginalURL)
+	_, err = s.Get(context.Background(), "xyz789")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestRedis_SaveManyGetMany(t *testing.T) {
+	s, _ := newTestRedis(t)
+	createdAt := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	s.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com", CreatedAt: createdAt}) //nolint:errcheck
+
+	results, err := s.SaveMany(context.Background(), []storage.URL{
+		{ShortURL: "def456", OriginalURL: "https://example.com/new"},
+		{ShortURL: "ghi789", OriginalURL: "https://example.com"},
+		{ShortURL: "abc123", OriginalURL: "https://example.com/other"},
+		// Дубликат внутри пакета получает короткий URL первой ссылки
+		{ShortURL: "jkl012", OriginalURL: "https://example.com/new"},
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 4) {
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, "def456", results[0].URL.ShortURL)
+		assert.Equal(t, "abc123", results[1].URL.ShortURL)
+		assert.True(t, createdAt.Equal(results[1].URL.CreatedAt))
+		assert.ErrorIs(t, results[2].Err, storage.ErrShortURLConflict)
+		assert.Equal(t, "def456", results[3].URL.ShortURL)
+	}
+
+	results, err = s.GetMany(context.Background(), []string{"def456", "xyz789"})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 2) {
+		assert.Equal(t, "https://example.com/new", results[0].URL.OriginalURL)
+		assert.ErrorIs(t, results[1].Err, storage.ErrNotFound)
+	}
+}
+
+func TestRedis_DeleteUpdate(t *testing.T) {
+	s, mr := newTestRedis(t)
+	s.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"}) //nolint:errcheck
+	s.Save(context.Background(), storage.URL{ShortURL: "def456", OriginalURL: "https://other.com"})   //nolint:errcheck
+
+	assert.ErrorIs(t, s.Update(context.Background(), "abc123", "https://other.com"), storage.ErrOriginalURLExists)
+	// Истёкшая ссылка на новый URL не мешает изменению и удаляется
+	s.Save(context.Background(), storage.URL{ShortURL: "stale", OriginalURL: "https://stale.com", ExpiresAt: time.Now().Add(-time.Minute)}) //nolint:errcheck
+	assert.NoError(t, s.Update(context.Background(), "def456", "https://stale.com"))
+	_, err := s.Get(context.Background(), "stale")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	url, err := s.Get(context.Background(), "def456")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://stale.com", url.OriginalURL)
+
+	assert.ErrorIs(t, s.Update(context.Background(), "xyz789", "https://example.com/new"), storage.ErrNotFound)
+	assert.NoError(t, s.Update(context.Background(), "abc123", "https://example.com/new"))
+	assert.False(t, mr.Exists(originalKeyPrefix+storage.OriginalKey("", "https://example.com")))
+
+	// Старый оригинальный URL освободился и может быть сокращён заново
+	shortURL, err := s.Save(context.Background(), storage.URL{ShortURL: "ghi789", OriginalURL: "https://example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, "ghi789", shortURL)
+
+	assert.NoError(t, s.Delete(context.Background(), "abc123"))
+	assert.ErrorIs(t, s.Delete(context.Background(), "abc123"), storage.ErrNotFound)
+	assert.False(t, mr.Exists(originalKeyPrefix+storage.OriginalKey("", "https://example.com/new")))
+}
+
+func TestRedis_Migrate(t *testing.T) {
+	s, mr := newTestRedis(t)
+	// Ссылка и индекс оригинальных URL в формате прежних версий, без версии схемы
+	mr.HSet(urlKeyPrefix+"abc123", "original_url", "https://example.com", "expires_at", "0",
+		"redirect_status", "0", "created_at", time.Now().Format(time.RFC3339Nano), "owner_id", "alice")
+	require.NoError(t, mr.Set(legacyOriginalKeyPrefix+"https://example.com", "abc123"))
+
+	require.NoError(t, s.Migrate(context.Background()))
+	assert.False(t, mr.Exists(legacyOriginalKeyPrefix+"https://example.com"))
+	version, err := mr.Get(schemaVersionKey)
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(len(migrations)), version)
+
+	shortURL, err := s.Save(context.Background(), storage.URL{ShortURL: "def456", OriginalURL: "https://example.com", OwnerID: "alice"})
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", shortURL)
+
+	count, err := s.Count(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// Ссылка прежней версии попала в множество владельца
+	page, err := s.List(context.Background(), storage.ListQuery{OwnerID: "alice"})
+	assert.NoError(t, err)
+	if assert.Len(t, page, 1) {
+		assert.Equal(t, "abc123", page[0].ShortURL)
+	}
+
+	// Повторный запуск ничего не меняет
+	assert.NoError(t, s.Migrate(context.Background()))
+}
+
+func TestRedis_DeleteExpired(t *testing.T) {
+	s, mr := newTestRedis(t)
+	now := time.Now()
+	s.Save(context.Background(), storage.URL{ShortURL: "expired", OriginalURL: "https://expired.com", ExpiresAt: now.Add(-time.Minute)}) //nolint:errcheck
+	s.Save(context.Background(), storage.URL{ShortURL: "active", OriginalURL: "https://active.com", ExpiresAt: now.Add(time.Hour)})      //nolint:errcheck
+	s.Save(context.Background(), storage.URL{ShortURL: "forever", OriginalURL: "https://forever.com"})                                   //nolint:errcheck
+
+	deleted, err := s.DeleteExpired(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	_, err = s.Get(context.Background(), "expired")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	assert.False(t, mr.Exists(originalKeyPrefix+storage.OriginalKey("", "https://expired.com")))
+	_, err = s.Get(context.Background(), "active")
+	assert.NoError(t, err)
+	members, err := mr.ZMembers(expiryKey)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"active"}, members)
+
+	// Счётчик ссылок уменьшается при удалении и не меняется при замене истёкшей ссылки
+	count, err := s.Count(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	s.Save(context.Background(), storage.URL{ShortURL: "old", OriginalURL: "https://old.com", ExpiresAt: now.Add(-time.Minute)}) //nolint:errcheck
+	s.Save(context.Background(), storage.URL{ShortURL: "new", OriginalURL: "https://old.com"})                                   //nolint:errcheck
+	s.Save(context.Background(), storage.URL{ShortURL: "again", OriginalURL: "https://old.com"})                                 //nolint:errcheck
+	count, err = s.Count(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestRedis_DeleteClicks(t *testing.T) {
+	s, _ := newTestRedis(t)
+	now := time.Now()
+	s.Save(context.Background(), storage.URL{ShortURL: "abc123", OriginalURL: "https://example.com"})                                    //nolint:errcheck
+	s.Save(context.Background(), storage.URL{ShortURL: "expired", OriginalURL: "https://expired.com", ExpiresAt: now.Add(-time.Minute)}) //nolint:errcheck
+	s.Save(context.Background(), storage.URL{ShortURL: "stale", OriginalURL: "https://stale.com", ExpiresAt: now.Add(-time.Minute)})     //nolint:errcheck
+	err := s.SaveClicks(context.Background(), []storage.Click{
+		{ShortURL: "abc123", ClickedAt: now, IPHash: "a"},
+		{ShortURL: "expired", ClickedAt: now, IPHash: "a"},
+		{ShortURL: "stale", ClickedAt: now, IPHash: "a"},
+	})
+	require.NoError(t, err)
+	clicks := func(shortURL string) int64 {
+		stats, err := s.ClickStats(context.Background(), storage.ClickStatsQuery{ShortURL: shortURL, Bucket: time.Hour})
+		require.NoError(t, err)
+		return stats.TotalClicks
+	}
+
+	// Изменение ссылки сохраняет её переходы, удаление — удаляет
+	require.NoError(t, s.Update(context.Background(), "abc123", "https://example.com/new"))
+	assert.Equal(t, int64(1), clicks("abc123"))
+	require.NoError(t, s.Delete(context.Background(), "abc123"))
+	assert.Zero(t, clicks("abc123"))
+
+	// Новая ссылка на месте истёкшей не наследует её переходы
+	_, err = s.Save(context.Background(), storage.URL{ShortURL: "stale", OriginalURL: "https://fresh.com"})
+	require.NoError(t, err)
+	assert.Zero(t, clicks("stale"))
+
+	_, err = s.DeleteExpired(context.Background(), now)
+	require.NoError(t, err)
+	assert.Zero(t, clicks("expired"))
+}
+
+func TestRedis_ClickStats(t *testing.T) {
+	s, _ := newTestRedis(t)
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	err := s.SaveClicks(context.Background(), []storage.Click{
+		{ShortURL: "abc123", ClickedAt: base, IPHash: "a"},
+		{ShortURL: "abc123", ClickedAt: base.Add(10 * time.Minute), IPHash: "a"},
+		{ShortURL: "abc123", ClickedAt: base.Add(90 * time.Minute), IPHash: "b"},
+		{ShortURL: "def456", ClickedAt: base, IPHash: "c"},
+	})
+	assert.NoError(t, err)
+
+	stats, err := s.ClickStats(context.Background(), storage.ClickStatsQuery{ShortURL: "abc123", Bucket: time.Hour})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), stats.TotalClicks)
+	assert.Equal(t, int64(2), stats.UniqueVisitors)
+	assert.Equal(t, []storage.ClickBucket{
+		{Start: base, Clicks: 2},
+		{Start: base.Add(time.Hour), Clicks: 1},
+	}, stats.Buckets)
+}
+
+func TestRedis_Ping(t *testing.T) {
+	s, mr := newTestRedis(t)
+	assert.NoError(t, s.Ping(context.Background()))
+	mr.Close()
+	assert.Error(t, s.Ping(context.Background()))
+}
+
+func TestRedis_TakeTokens(t *testing.T) {
+	s, mr := newTestRedis(t)
+	bucket := storage.TokenBucket{Rate: 2, Burst: 3}
+	now := time.Now()
+
+	for i := range 3 {
+		ok, _, err := s.TakeTokens(context.Background(), "create:ip:10.0.0.1", bucket, 1, now)
+		assert.NoError(t, err)
+		assert.True(t, ok, "запрос %d", i)
+	}
+	ok, retryAfter, err := s.TakeTokens(context.Background(), "create:ip:10.0.0.1", bucket, 1, now)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	ok, _, err = s.TakeTokens(context.Background(), "create:ip:10.0.0.1", bucket, 1, now.Add(500*time.Millisecond))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// Корзина удаляется, когда снова заполнилась бы до краёв
+	assert.True(t, mr.Exists(rateLimitPrefix+"create:ip:10.0.0.1"))
+	mr.FastForward(2 * time.Second)
+	assert.False(t, mr.Exists(rateLimitPrefix+"create:ip:10.0.0.1"))
+}
+
+func TestRedis_Keys(t *testing.T) {
+	s, _ := newTestRedis(t)
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	second := storage.APIKey{ID: "key2", Hash: "hash2", OwnerID: "bob", CreatedAt: base.Add(time.Minute)}
+	first := storage.APIKey{ID: "key1", Hash: "hash1", OwnerID: "alice", Name: "ci", CreatedAt: base}
+	assert.NoError(t, s.SaveKey(context.Background(), second))
+	assert.NoError(t, s.SaveKey(context.Background(), first))
+
+	key, err := s.KeyByHash(context.Background(), "hash1")
+	assert.NoError(t, err)
+	assert.Equal(t, first, key)
+	_, err = s.KeyByHash(context.Background(), "unknown")
+	assert.ErrorIs(t, err, storage.ErrKeyNotFound)
+
+	keys, err := s.ListKeys(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.APIKey{first, second}, keys)
+
+	assert.NoError(t, s.RevokeKey(context.Background(), "key1", base.Add(time.Hour)))
+	assert.NoError(t, s.RevokeKey(context.Background(), "key1", base.Add(2*time.Hour)))
+	key, err = s.KeyByHash(context.Background(), "hash1")
+	assert.NoError(t, err)
+	assert.Equal(t, base.Add(time.Hour), key.RevokedAt)
+	assert.ErrorIs(t, s.RevokeKey(context.Background(), "unknown", base), storage.ErrKeyNotFound)
+}
+
+func TestRedis_List(t *testing.T) {
+	s, mr := newTestRedis(t)
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	first := storage.URL{ShortURL: "a1", OriginalURL: "https://example.com/docs", CreatedAt: base, OwnerID: "alice"}
+	second := storage.URL{ShortURL: "a2", OriginalURL: "https://go.dev/doc", CreatedAt: base.Add(time.Minute), OwnerID: "alice"}
+	third := storage.URL{ShortURL: "a3", OriginalURL: "https://example.com/blog", CreatedAt: base.Add(2 * time.Minute), OwnerID: "alice"}
+	foreign := storage.URL{ShortURL: "b1", OriginalURL: "https://example.com/bob", CreatedAt: base, OwnerID: "bob"}
+	for _, url := range []storage.URL{second, foreign, third, first} {
+		_, err := s.Save(context.Background(), url)
+		assert.NoError(t, err)
+	}
+
+	page, err := s.List(context.Background(), storage.ListQuery{OwnerID: "alice", Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.URL{third, second}, page)
+
+	cursor := storage.CursorOf(second)
+	page, err = s.List(context.Background(), storage.ListQuery{OwnerID: "alice", After: &cursor})
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.URL{first}, page)
+
+	page, err = s.List(context.Background(), storage.ListQuery{OwnerID: "alice", Domain: "example.com", Order: storage.OldestFirst})
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.URL{first, third}, page)
+
+	page, err = s.List(context.Background(), storage.ListQuery{OwnerID: "alice", CreatedFrom: second.CreatedAt, CreatedTo: third.CreatedAt})
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.URL{second}, page)
+
+	// Удалённая ссылка уходит и из множества владельца
+	assert.NoError(t, s.Delete(context.Background(), "a2"))
+	members, err := mr.ZMembers(ownerURLsPrefix + "alice")
+	assert.NoError(t, err)
+	assert.Len(t, members, 2)
+
+	count, err := s.Count(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestRedis_ListChunks(t *testing.T) {
+	s, _ := newTestRedis(t)
+	base := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	var urls []storage.URL
+	for i := range 2*listChunkSize + 10 {
+		// Ссылки, созданные одновременно, упорядочиваются по короткому URL
+		url := storage.URL{ShortURL: fmt.Sprintf("c%03d", i), OriginalURL: fmt.Sprintf("https://example.com/%d", i),
+			CreatedAt: base.Add(time.Duration(i/2) * time.Millisecond), OwnerID: "alice"}
+		urls = append(urls, url)
+	}
+	_, err := s.SaveMany(context.Background(), urls)
+	require.NoError(t, err)
+
+	page, err := s.List(context.Background(), storage.ListQuery{OwnerID: "alice", Order: storage.OldestFirst})
+	assert.NoError(t, err)
+	assert.Equal(t, urls, page)
+
+	page, err = s.List(context.Background(), storage.ListQuery{OwnerID: "alice", Limit: listChunkSize + 5})
+	assert.NoError(t, err)
+	if assert.Len(t, page, listChunkSize+5) {
+		assert.Equal(t, urls[len(urls)-1], page[0])
+		assert.Equal(t, urls[len(urls)-listChunkSize-5], page[len(page)-1])
+	}
+}
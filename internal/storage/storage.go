@@ -1,15 +1,340 @@
 package storage
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
 
-// ErrNotFound возвращается когда URL не найден
-var ErrNotFound = errors.New("URL not found")
+var (
+	// ErrNotFound возвращается когда URL не найден
+	ErrNotFound = errors.New("URL not found")
+	// ErrShortURLConflict возвращается когда короткий URL уже занят другой ссылкой
+	ErrShortURLConflict = errors.New("short URL already exists")
+	// ErrInvalid возвращается когда хранилище отклоняет данные ссылки, например слишком длинный короткий URL
+	ErrInvalid = errors.New("invalid URL data")
+	// ErrExpired возвращается когда срок действия ссылки истёк
+	ErrExpired = errors.New("URL expired")
+	// ErrOriginalURLExists возвращается когда оригинальный URL уже привязан к другой короткой ссылке владельца
+	ErrOriginalURLExists = errors.New("original URL already has a short URL")
+	// ErrKeyNotFound возвращается когда ключ API не найден
+	ErrKeyNotFound = errors.New("API key not found")
+)
+
+// IsExpected сообщает, является ли ошибка штатным ответом хранилища (ссылка или ключ не найдены,
+// ссылка истекла, код занят или данные отклонены), а не сбоем
+func IsExpected(err error) bool {
+	return errors.Is(err, ErrNotFound) ||
+		errors.Is(err, ErrKeyNotFound) ||
+		errors.Is(err, ErrExpired) ||
+		errors.Is(err, ErrInvalid) ||
+		errors.Is(err, ErrShortURLConflict) ||
+		errors.Is(err, ErrOriginalURLExists)
+}
+
+// URL описывает сохранённую короткую ссылку
+type URL struct {
+	ShortURL    string
+	OriginalURL string
+	ExpiresAt   time.Time // нулевое значение означает бессрочную ссылку
+	// RedirectStatus — HTTP-статус редиректа, 0 означает статус по умолчанию
+	RedirectStatus int32
+	CreatedAt      time.Time
+	// OwnerID — владелец ключа API, создавшего ссылку; пустое значение у ссылок, созданных без ключа
+	OwnerID string
+}
+
+// Expired сообщает, истёк ли срок действия ссылки к моменту now
+func (u URL) Expired(now time.Time) bool {
+	return !u.ExpiresAt.IsZero() && !u.ExpiresAt.After(now)
+}
+
+// OriginalKey возвращает ключ индекса оригинальных URL: один оригинальный URL сокращается один раз
+// для каждого владельца. Длина владельца в начале ключа не даёт разным парам владельца и URL
+// склеиться в один ключ
+func OriginalKey(ownerID, originalURL string) string {
+	return strconv.Itoa(len(ownerID)) + ":" + ownerID + ":" + originalURL
+}
+
+// BatchResult содержит результат пакетной операции для одной ссылки.
+// Ошибка одной ссылки не прерывает обработку остальных
+type BatchResult struct {
+	URL URL
+	Err error
+}
 
 // Storage определяет интерфейс для работы с хранилищем URL
 type Storage interface {
-	// Save сохраняет пару URL, возвращает существующий shortURL если originalURL уже есть
-	Save(shortURL, originalURL string) (string, error)
+	// Save сохраняет ссылку, возвращает существующий shortURL если originalURL уже есть у того же владельца
+	Save(ctx context.Context, url URL) (string, error)
+
+	// Get возвращает ссылку по её короткой версии. Для истёкших ссылок возвращает ErrExpired
+	// вместе с данными ссылки, чтобы владелец мог управлять ею до удаления
+	Get(ctx context.Context, shortURL string) (URL, error)
+
+	// SaveMany сохраняет пакет ссылок с той же семантикой, что и Save.
+	// Результаты возвращаются в порядке urls, общая ошибка означает, что не сохранена ни одна ссылка
+	SaveMany(ctx context.Context, urls []URL) ([]BatchResult, error)
+
+	// GetMany возвращает ссылки по коротким версиям в порядке shortURLs
+	GetMany(ctx context.Context, shortURLs []string) ([]BatchResult, error)
+
+	// Delete удаляет ссылку по её короткой версии
+	Delete(ctx context.Context, shortURL string) error
+
+	// Update меняет оригинальный URL короткой ссылки, сохраняя уникальность оригинальных URL владельца.
+	// Истёкшая, но ещё не удалённая ссылка владельца с новым оригинальным URL удаляется, как при Save
+	Update(ctx context.Context, shortURL, newOriginalURL string) error
+
+	// DeleteExpired удаляет ссылки, срок действия которых истёк к моменту now
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+
+	// List возвращает до query.Limit действующих ссылок владельца query.OwnerID, подходящих
+	// под фильтры, в порядке query.Order
+	List(ctx context.Context, query ListQuery) ([]URL, error)
+
+	// Count возвращает число хранимых ссылок, включая истёкшие, но ещё не удалённые
+	Count(ctx context.Context) (int64, error)
+}
+
+// ListOrder — порядок ссылок в выборке List
+type ListOrder int
+
+const (
+	// NewestFirst — сначала недавно созданные ссылки
+	NewestFirst ListOrder = iota
+	// OldestFirst — сначала давно созданные ссылки
+	OldestFirst
+)
+
+// ListCursor — позиция в выборке List: время создания и короткий URL последней ссылки предыдущей страницы.
+// Ссылки, созданные одновременно, упорядочиваются по короткому URL
+type ListCursor struct {
+	CreatedAt time.Time
+	ShortURL  string
+}
+
+// CursorOf возвращает позицию ссылки в выборке
+func CursorOf(url URL) ListCursor {
+	return ListCursor{CreatedAt: url.CreatedAt, ShortURL: url.ShortURL}
+}
+
+// Before сообщает, создана ли ссылка позиции c раньше ссылки позиции other
+func (c ListCursor) Before(other ListCursor) bool {
+	if !c.CreatedAt.Equal(other.CreatedAt) {
+		return c.CreatedAt.Before(other.CreatedAt)
+	}
+	return c.ShortURL < other.ShortURL
+}
+
+// ListQuery задаёт выборку ссылок владельца. Нулевые значения фильтров выборку не ограничивают
+type ListQuery struct {
+	OwnerID     string
+	CreatedFrom time.Time // ссылки, созданные не раньше этого момента
+	CreatedTo   time.Time // ссылки, созданные раньше этого момента
+	Domain      string    // хост оригинального URL, как его возвращает URLHost
+	Contains    string    // подстрока оригинального URL без учёта регистра
+	Order       ListOrder
+	After       *ListCursor // позиция, после которой продолжается выборка
+	Limit       int
+}
+
+// Less сообщает, идёт ли позиция a в выборке раньше позиции b
+func (q ListQuery) Less(a, b ListCursor) bool {
+	if q.Order == OldestFirst {
+		return a.Before(b)
+	}
+	return b.Before(a)
+}
+
+// Matches сообщает, входит ли ссылка в выборку к моменту now: принадлежит владельцу, не истекла,
+// подходит под фильтры и лежит после позиции After
+func (q ListQuery) Matches(url URL, now time.Time) bool {
+	switch {
+	case url.OwnerID != q.OwnerID || url.Expired(now):
+		return false
+	case !q.CreatedFrom.IsZero() && url.CreatedAt.Before(q.CreatedFrom):
+		return false
+	case !q.CreatedTo.IsZero() && !url.CreatedAt.Before(q.CreatedTo):
+		return false
+	case q.Domain != "" && URLHost(url.OriginalURL) != q.Domain:
+		return false
+	case q.Contains != "" && !strings.Contains(strings.ToLower(url.OriginalURL), strings.ToLower(q.Contains)):
+		return false
+	}
+	return q.After == nil || q.Less(*q.After, CursorOf(url))
+}
+
+// Page отбирает из urls страницу выборки q к моменту now. Подходит хранилищам без индекса по владельцу,
+// которые перебирают все ссылки
+func Page(urls []URL, q ListQuery, now time.Time) []URL {
+	page := make([]URL, 0, len(urls))
+	for _, url := range urls {
+		if q.Matches(url, now) {
+			page = append(page, url)
+		}
+	}
+	sort.Slice(page, func(i, j int) bool {
+		return q.Less(CursorOf(page[i]), CursorOf(page[j]))
+	})
+	if q.Limit > 0 && len(page) > q.Limit {
+		page = page[:q.Limit]
+	}
+	return page
+}
+
+// URLHost возвращает хост оригинального URL без порта, пустую строку для некорректного URL
+func URLHost(originalURL string) string {
+	u, err := url.Parse(originalURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// Click описывает переход по короткой ссылке
+type Click struct {
+	ShortURL  string
+	ClickedAt time.Time
+	Referrer  string
+	UserAgent string
+	IPHash    string // хеш IP-адреса клиента, сам адрес не хранится
+}
+
+// ClickStatsQuery задаёт параметры выборки статистики переходов
+type ClickStatsQuery struct {
+	ShortURL string
+	From     time.Time // нулевое значение — без нижней границы
+	To       time.Time // нулевое значение — без верхней границы
+	Bucket   time.Duration
+}
+
+// ClickBucket содержит количество переходов за интервал, начинающийся в Start
+type ClickBucket struct {
+	Start  time.Time
+	Clicks int64
+}
+
+// ClickStats содержит агрегированную статистику переходов по короткой ссылке
+type ClickStats struct {
+	TotalClicks    int64
+	UniqueVisitors int64
+	Buckets        []ClickBucket
+}
+
+// ClickStorage определяет интерфейс для хранения переходов по ссылкам
+type ClickStorage interface {
+	// SaveClicks сохраняет пачку переходов
+	SaveClicks(ctx context.Context, clicks []Click) error
+
+	// ClickStats возвращает статистику переходов, сгруппированную по интервалам
+	ClickStats(ctx context.Context, query ClickStatsQuery) (ClickStats, error)
+}
+
+// APIKey описывает ключ API. Сам ключ не хранится, только его хеш
+type APIKey struct {
+	ID        string // открытый идентификатор для списка и отзыва ключей
+	Hash      string
+	OwnerID   string
+	Name      string // описание ключа, например назначение
+	CreatedAt time.Time
+	RevokedAt time.Time // нулевое значение — ключ действует
+}
+
+// Revoked сообщает, отозван ли ключ
+func (k APIKey) Revoked() bool {
+	return !k.RevokedAt.IsZero()
+}
+
+// SortKeys упорядочивает ключи по времени создания, ключи, созданные одновременно, — по идентификатору
+func SortKeys(keys []APIKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].ID < keys[j].ID
+	})
+}
+
+// KeyStorage определяет интерфейс для хранения ключей API
+type KeyStorage interface {
+	// SaveKey сохраняет новый ключ
+	SaveKey(ctx context.Context, key APIKey) error
+
+	// KeyByHash возвращает ключ по хешу, ErrKeyNotFound если ключа нет. Отозванные ключи тоже возвращаются
+	KeyByHash(ctx context.Context, hash string) (APIKey, error)
+
+	// ListKeys возвращает все ключи в порядке создания
+	ListKeys(ctx context.Context) ([]APIKey, error)
+
+	// RevokeKey отзывает ключ с идентификатором id в момент now, ErrKeyNotFound если ключа нет
+	RevokeKey(ctx context.Context, id string, now time.Time) error
+}
+
+// TokenBucket задаёт корзину токенов: в запасе до Burst токенов, пополнение со скоростью Rate в секунду
+type TokenBucket struct {
+	Rate  float64
+	Burst int
+}
+
+// Need возвращает число токенов, которое должно быть в корзине, чтобы списать n.
+// Запрос больше Burst требует полной корзины, а недостающие токены уходят в долг
+func (b TokenBucket) Need(n int) float64 {
+	return float64(min(n, b.Burst))
+}
+
+// Refill возвращает число токенов к моменту now в корзине, где в момент updated было tokens
+func (b TokenBucket) Refill(tokens float64, updated, now time.Time) float64 {
+	if elapsed := now.Sub(updated); elapsed > 0 {
+		tokens += elapsed.Seconds() * b.Rate
+	}
+	return min(tokens, float64(b.Burst))
+}
+
+// Wait возвращает время, за которое корзина с tokens токенами накопит need
+func (b TokenBucket) Wait(tokens, need float64) time.Duration {
+	if tokens >= need {
+		return 0
+	}
+	return time.Duration((need - tokens) / b.Rate * float64(time.Second))
+}
+
+// RateLimitStorage хранит корзины токенов ограничения частоты запросов, общие для всех экземпляров сервиса
+type RateLimitStorage interface {
+	// TakeTokens атомарно списывает n токенов из корзины key к моменту now. Если токенов не хватает,
+	// ничего не списывает и возвращает false и время, через которое запрос пройдёт
+	TakeTokens(ctx context.Context, key string, bucket TokenBucket, n int, now time.Time) (bool, time.Duration, error)
+}
+
+// BucketStart возвращает начало интервала длины bucket, в который попадает t
+func BucketStart(t time.Time, bucket time.Duration) time.Time {
+	seconds := int64(bucket / time.Second)
+	return time.Unix(t.Unix()/seconds*seconds, 0).UTC()
+}
+
+// RunReaper периодически удаляет истёкшие ссылки из хранилища до отмены контекста
+func RunReaper(ctx context.Context, s Storage, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-	// Get возвращает оригинальный URL по его короткой версии
-	Get(shortURL string) (string, error)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			deleted, err := s.DeleteExpired(ctx, now)
+			if err != nil {
+				slog.Error("Failed to delete expired URLs", "error", err)
+				continue
+			}
+			if deleted > 0 {
+				slog.Info("Deleted expired URLs", "count", deleted)
+			}
+		}
+	}
 }
@@ -0,0 +1,146 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+
+	"url-shortener/internal/storage"
+)
+
+const serviceName = "url-shortener"
+
+// Экспортёры span'ов
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterFile   = "file"
+	ExporterOTLP   = "otlp"
+)
+
+const (
+	// ShortCodeKey — атрибут с короткой ссылкой запроса
+	ShortCodeKey = attribute.Key("url_shortener.short_code")
+	// StorageBackendKey — атрибут с типом хранилища
+	StorageBackendKey = attribute.Key("url_shortener.storage.backend")
+)
+
+// Config задаёт экспорт трассировок
+type Config struct {
+	Exporter string // none, stdout, file или otlp
+	// FilePath — файл, в который дописываются span'ы в формате JSON при Exporter=file
+	FilePath string
+	// SampleRatio — доля трассировок, начатых сервисом; для входящих запросов решение берётся из traceparent
+	SampleRatio float64
+	// StorageBackend добавляется ко всем span'ам как атрибут ресурса
+	StorageBackend string
+}
+
+// Setup настраивает глобальный TracerProvider и W3C-распространение контекста (traceparent, baggage).
+// Возвращённая функция отправляет накопленные span'ы и закрывает экспортёр
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if cfg.Exporter == "" || cfg.Exporter == ExporterNone {
+		return func(context.Context) error { return nil }, nil
+	}
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return nil, fmt.Errorf("tracing sample ratio %v out of range [0, 1]", cfg.SampleRatio)
+	}
+
+	attrs := []attribute.KeyValue{semconv.ServiceName(serviceName)}
+	if cfg.StorageBackend != "" {
+		attrs = append(attrs, StorageBackendKey.String(cfg.StorageBackend))
+	}
+	res, err := resource.New(ctx, resource.WithAttributes(attrs...), resource.WithFromEnv(), resource.WithTelemetrySDK())
+	if err != nil {
+		return nil, fmt.Errorf("create tracing resource: %w", err)
+	}
+
+	var exporter sdktrace.SpanExporter
+	var file io.Closer
+	switch cfg.Exporter {
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		if cfg.FilePath == "" {
+			return nil, errors.New("tracing file path is empty")
+		}
+		var f *os.File
+		f, err = os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("open tracing file: %w", err)
+		}
+		file = f
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(f))
+	case ExporterOTLP:
+		// адрес и параметры коллектора задаются стандартными переменными OTEL_EXPORTER_OTLP_*
+		exporter, err = otlptracegrpc.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		if file != nil {
+			file.Close() //nolint:errcheck
+		}
+		return nil, fmt.Errorf("create tracing exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if file != nil {
+			err = errors.Join(err, file.Close())
+		}
+		return err
+	}, nil
+}
+
+// End завершает span, отмечая его ошибкой, если операция завершилась сбоем.
+// Штатные ответы хранилища, например ссылка не найдена, ошибкой не считаются
+func End(span trace.Span, err error) {
+	if err != nil && !storage.IsExpected(err) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// shortURLMessage — gRPC-запрос или ответ, содержащий короткую ссылку
+type shortURLMessage interface {
+	GetShortUrl() string
+}
+
+// UnaryServerInterceptor добавляет к span'у gRPC-вызова короткую ссылку из запроса,
+// а для созданных ссылок — из ответа
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		span := trace.SpanFromContext(ctx)
+		if m, ok := req.(shortURLMessage); ok && m.GetShortUrl() != "" {
+			span.SetAttributes(ShortCodeKey.String(m.GetShortUrl()))
+		}
+		resp, err := handler(ctx, req)
+		if m, ok := resp.(shortURLMessage); ok && m.GetShortUrl() != "" {
+			span.SetAttributes(ShortCodeKey.String(m.GetShortUrl()))
+		}
+		return resp, err
+	}
+}
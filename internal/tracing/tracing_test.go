@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+
+	"url-shortener/internal/storage"
+)
+
+func TestSetup_FileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.json")
+	shutdown, err := Setup(context.Background(), Config{
+		Exporter:       ExporterFile,
+		FilePath:       path,
+		SampleRatio:    1,
+		StorageBackend: "memory",
+	})
+	require.NoError(t, err)
+
+	// Ожидаемые ответы хранилища не отмечают span ошибкой
+	_, span := otel.Tracer("test").Start(context.Background(), "lookup", trace.WithAttributes(ShortCodeKey.String("abc123")))
+	End(span, storage.ErrNotFound)
+	_, span = otel.Tracer("test").Start(context.Background(), "save")
+	End(span, os.ErrDeadlineExceeded)
+	require.NoError(t, shutdown(context.Background()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"Name":"lookup"`)
+	assert.Contains(t, string(data), `"Key":"url_shortener.short_code","Value":{"Type":"STRING","Value":"abc123"}`)
+	assert.Contains(t, string(data), `"Key":"url_shortener.storage.backend","Value":{"Type":"STRING","Value":"memory"}`)
+	assert.Contains(t, string(data), `"Code":"Error","Description":"i/o timeout"`)
+	assert.Contains(t, string(data), `"Code":"Unset"`)
+}
+
+func TestSetup_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{name: "Неизвестный экспортёр", cfg: Config{Exporter: "jaeger", SampleRatio: 1}},
+		{name: "Не задан файл", cfg: Config{Exporter: ExporterFile, SampleRatio: 1}},
+		{name: "Доля трассировок больше 1", cfg: Config{Exporter: ExporterStdout, SampleRatio: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Setup(context.Background(), tt.cfg)
+			assert.Error(t, err)
+		})
+	}
+}
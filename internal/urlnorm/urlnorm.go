@@ -0,0 +1,150 @@
+package urlnorm
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// ErrInvalidURL возвращается, если оригинальный URL не прошёл проверку
+var ErrInvalidURL = errors.New("invalid URL")
+
+// defaultPorts содержит порты по умолчанию, которые удаляются из URL
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ftp":   "21",
+}
+
+// Config содержит правила проверки и нормализации URL
+type Config struct {
+	AllowedSchemes []string // допустимые схемы URL
+	TrackingParams []string // удаляемые параметры запроса; шаблон с "*" на конце задаёт префикс, например utm_*
+}
+
+// DefaultConfig возвращает правила по умолчанию: только http и https, параметры запроса не удаляются
+func DefaultConfig() Config {
+	return Config{AllowedSchemes: []string{"http", "https"}}
+}
+
+// Normalizer проверяет URL и приводит эквивалентные URL к одному виду,
+// чтобы дедупликация в хранилище срабатывала для них одинаково
+type Normalizer struct {
+	schemes        map[string]bool
+	trackingParams []string
+}
+
+// New создаёт нормализатор с заданными правилами
+func New(cfg Config) *Normalizer {
+	n := &Normalizer{
+		schemes:        make(map[string]bool, len(cfg.AllowedSchemes)),
+		trackingParams: cfg.TrackingParams,
+	}
+	for _, scheme := range cfg.AllowedSchemes {
+		n.schemes[strings.ToLower(scheme)] = true
+	}
+	return n
+}
+
+// Normalize проверяет схему и хост URL, переводит хост в punycode и нижний регистр,
+// удаляет порт по умолчанию и параметры отслеживания
+func (n *Normalizer) Normalize(rawURL string) (string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return "", fmt.Errorf("%w: empty URL", ErrInvalidURL)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidURL, err)
+	}
+	if u.Scheme == "" {
+		return "", fmt.Errorf("%w: URL must be absolute", ErrInvalidURL)
+	}
+	// url.Parse уже приводит схему к нижнему регистру
+	if !n.schemes[u.Scheme] {
+		return "", fmt.Errorf("%w: scheme %q is not allowed", ErrInvalidURL, u.Scheme)
+	}
+	if u.Opaque != "" || u.Hostname() == "" {
+		return "", fmt.Errorf("%w: URL must have a host", ErrInvalidURL)
+	}
+
+	host, err := normalizeHost(u.Hostname())
+	if err != nil {
+		return "", err
+	}
+	port := u.Port()
+	if port == defaultPorts[u.Scheme] {
+		port = ""
+	}
+	switch {
+	case port != "":
+		u.Host = net.JoinHostPort(host, port)
+	case strings.Contains(host, ":"):
+		u.Host = "[" + host + "]"
+	default:
+		u.Host = host
+	}
+
+	if len(n.trackingParams) > 0 && u.RawQuery != "" {
+		u.RawQuery = n.stripTrackingParams(u.RawQuery)
+	}
+	return u.String(), nil
+}
+
+// NormalizeHost приводит хост к виду, в котором он хранится в нормализованных URL.
+// Квадратные скобки IPv6-адреса отбрасываются
+func NormalizeHost(host string) (string, error) {
+	host = strings.TrimSpace(host)
+	if host == "" {
+		return "", fmt.Errorf("%w: empty host", ErrInvalidURL)
+	}
+	return normalizeHost(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"))
+}
+
+// normalizeHost приводит IP-адрес к каноническому виду, а доменное имя — к punycode в нижнем регистре
+func normalizeHost(host string) (string, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String(), nil
+	}
+	ascii, err := idna.Lookup.ToASCII(strings.ToLower(host))
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid host %q", ErrInvalidURL, host)
+	}
+	return ascii, nil
+}
+
+// stripTrackingParams удаляет параметры отслеживания, сохраняя порядок остальных параметров
+func (n *Normalizer) stripTrackingParams(rawQuery string) string {
+	params := strings.Split(rawQuery, "&")
+	kept := params[:0]
+	for _, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+		if !n.isTrackingParam(key) {
+			kept = append(kept, param)
+		}
+	}
+	return strings.Join(kept, "&")
+}
+
+// isTrackingParam сообщает, подходит ли параметр запроса под один из шаблонов отслеживания
+func (n *Normalizer) isTrackingParam(key string) bool {
+	key = strings.ToLower(key)
+	for _, pattern := range n.trackingParams {
+		pattern = strings.ToLower(pattern)
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == pattern {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package urlnorm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizer_Normalize(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         Config
+		rawURL      string
+		expectedURL string
+		expectedErr bool
+	}{
+		{
+			name:        "URL не меняется",
+			cfg:         DefaultConfig(),
+			rawURL:      "https://example.com/path?q=1#top",
+			expectedURL: "https://example.com/path?q=1#top",
+		},
+		{
+			name:        "Схема и хост в нижнем регистре",
+			cfg:         DefaultConfig(),
+			rawURL:      "HTTPS://Example.COM/Path",
+			expectedURL: "https://example.com/Path",
+		},
+		{
+			name:        "Удаление порта по умолчанию",
+			cfg:         DefaultConfig(),
+			rawURL:      "http://example.com:80/a",
+			expectedURL: "http://example.com/a",
+		},
+		{
+			name:        "Нестандартный порт сохраняется",
+			cfg:         DefaultConfig(),
+			rawURL:      "https://example.com:8443/a",
+			expectedURL: "https://example.com:8443/a",
+		},
+		{
+			name:        "IDN-хост в punycode",
+			cfg:         DefaultConfig(),
+			rawURL:      "https://Пример.рф/страница",
+			expectedURL: "https://xn--e1afmkfd.xn--p1ai/%D1%81%D1%82%D1%80%D0%B0%D0%BD%D0%B8%D1%86%D0%B0",
+		},
+		{
+			name:        "IPv6-адрес с портом по умолчанию",
+			cfg:         DefaultConfig(),
+			rawURL:      "https://[2001:DB8::1]:443/",
+			expectedURL: "https://[2001:db8::1]/",
+		},
+		{
+			name:        "Удаление параметров отслеживания",
+			cfg:         Config{AllowedSchemes: []string{"https"}, TrackingParams: []string{"utm_*", "fbclid"}},
+			rawURL:      "https://example.com/?b=2&utm_source=x&a=1&UTM_Medium=y&fbclid=z",
+			expectedURL: "https://example.com/?b=2&a=1",
+		},
+		{
+			name:        "Параметры отслеживания не удаляются по умолчанию",
+			cfg:         DefaultConfig(),
+			rawURL:      "https://example.com/?utm_source=x",
+			expectedURL: "https://example.com/?utm_source=x",
+		},
+		{
+			name:        "Пустая строка",
+			cfg:         DefaultConfig(),
+			rawURL:      "  ",
+			expectedErr: true,
+		},
+		{
+			name:        "Относительный путь",
+			cfg:         DefaultConfig(),
+			rawURL:      "/some/path",
+			expectedErr: true,
+		},
+		{
+			name:        "Схема javascript",
+			cfg:         DefaultConfig(),
+			rawURL:      "javascript:alert(1)",
+			expectedErr: true,
+		},
+		{
+			name:        "Схема не из списка разрешённых",
+			cfg:         DefaultConfig(),
+			rawURL:      "ftp://example.com/file",
+			expectedErr: true,
+		},
+		{
+			name:        "URL без хоста",
+			cfg:         DefaultConfig(),
+			rawURL:      "https:///path",
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			normalized, err := New(tt.cfg).Normalize(tt.rawURL)
+
+			if tt.expectedErr {
+				assert.ErrorIs(t, err, ErrInvalidURL)
+				assert.Empty(t, normalized)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedURL, normalized)
+			}
+		})
+	}
+}
+
+func TestNormalizeHost(t *testing.T) {
+	tests := []struct {
+		name         string
+		host         string
+		expectedHost string
+		expectedErr  bool
+	}{
+		{name: "Нижний регистр", host: "Example.COM", expectedHost: "example.com"},
+		{name: "Punycode", host: "пример.рф", expectedHost: "xn--e1afmkfd.xn--p1ai"},
+		{name: "IPv6 в скобках", host: "[2001:DB8::1]", expectedHost: "2001:db8::1"},
+		{name: "Пустой хост", host: " ", expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, err := NormalizeHost(tt.host)
+
+			if tt.expectedErr {
+				assert.ErrorIs(t, err, ErrInvalidURL)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedHost, host)
+			}
+		})
+	}
+}
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE urls ALTER COLUMN short_url TYPE VARCHAR(64);
+
+-- +goose Down
+ALTER TABLE urls ALTER COLUMN short_url TYPE VARCHAR(10);
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE urls ADD COLUMN expires_at TIMESTAMPTZ;
+CREATE INDEX urls_expires_at_idx ON urls (expires_at) WHERE expires_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX urls_expires_at_idx;
+ALTER TABLE urls DROP COLUMN expires_at;
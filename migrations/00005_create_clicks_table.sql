@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE clicks (
+                        id BIGSERIAL PRIMARY KEY,
+                        short_url VARCHAR(64) NOT NULL,
+                        clicked_at TIMESTAMPTZ NOT NULL,
+                        referrer TEXT NOT NULL DEFAULT '',
+                        user_agent TEXT NOT NULL DEFAULT '',
+                        ip_hash VARCHAR(64) NOT NULL
+);
+CREATE INDEX clicks_short_url_clicked_at_idx ON clicks (short_url, clicked_at);
+
+-- +goose Down
+DROP TABLE clicks;
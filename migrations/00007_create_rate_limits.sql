@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE rate_limits (
+                             key TEXT PRIMARY KEY,
+                             tokens DOUBLE PRECISION NOT NULL,
+                             updated_at TIMESTAMPTZ NOT NULL
+);
+
+-- +goose Down
+DROP TABLE rate_limits;
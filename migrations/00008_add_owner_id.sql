@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE urls ADD COLUMN owner_id TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE urls DROP COLUMN owner_id;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE api_keys (
+                          id VARCHAR(32) PRIMARY KEY,
+                          key_hash CHAR(64) NOT NULL UNIQUE,
+                          owner_id TEXT NOT NULL,
+                          name TEXT NOT NULL DEFAULT '',
+                          created_at TIMESTAMPTZ NOT NULL,
+                          revoked_at TIMESTAMPTZ
+);
+
+-- +goose Down
+DROP TABLE api_keys;
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE INDEX urls_owner_created_idx ON urls (owner_id, created_at, short_url);
+CREATE INDEX urls_owner_host_idx ON urls (owner_id, (trim(both '[]' from substring(original_url from '^[^:]+://(?:[^@/?#]*@)?(\[[^]]*\]|[^/?#:]*)'))));
+-- Роли без прав на создание расширений нужно, чтобы pg_trgm заранее создал администратор, см. README
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX urls_original_url_trgm_idx ON urls USING gin (original_url gin_trgm_ops);
+
+-- +goose Down
+DROP INDEX urls_original_url_trgm_idx;
+DROP INDEX urls_owner_host_idx;
+DROP INDEX urls_owner_created_idx;
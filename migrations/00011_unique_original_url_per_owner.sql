@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE urls DROP CONSTRAINT urls_original_url_key;
+ALTER TABLE urls ADD CONSTRAINT urls_owner_original_url_key UNIQUE (owner_id, original_url);
+
+-- +goose Down
+ALTER TABLE urls DROP CONSTRAINT urls_owner_original_url_key;
+ALTER TABLE urls ADD CONSTRAINT urls_original_url_key UNIQUE (original_url);
@@ -9,6 +9,7 @@ package proto
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -21,12 +22,63 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Порядок ссылок в списке по времени создания
+type ListOrder int32
+
+const (
+	ListOrder_LIST_ORDER_NEWEST_FIRST ListOrder = 0 // Сначала новые (по умолчанию)
+	ListOrder_LIST_ORDER_OLDEST_FIRST ListOrder = 1 // Сначала старые
+)
+
+// Enum value maps for ListOrder.
+var (
+	ListOrder_name = map[int32]string{
+		0: "LIST_ORDER_NEWEST_FIRST",
+		1: "LIST_ORDER_OLDEST_FIRST",
+	}
+	ListOrder_value = map[string]int32{
+		"LIST_ORDER_NEWEST_FIRST": 0,
+		"LIST_ORDER_OLDEST_FIRST": 1,
+	}
+)
+
+func (x ListOrder) Enum() *ListOrder {
+	p := new(ListOrder)
+	*p = x
+	return p
+}
+
+func (x ListOrder) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ListOrder) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_urlshortener_proto_enumTypes[0].Descriptor()
+}
+
+func (ListOrder) Type() protoreflect.EnumType {
+	return &file_proto_urlshortener_proto_enumTypes[0]
+}
+
+func (x ListOrder) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ListOrder.Descriptor instead.
+func (ListOrder) EnumDescriptor() ([]byte, []int) {
+	return file_proto_urlshortener_proto_rawDescGZIP(), []int{0}
+}
+
 // Запрос для сокращения URL
 type CreateURLRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	OriginalUrl   string                 `protobuf:"bytes,1,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	OriginalUrl    string                 `protobuf:"bytes,1,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
+	CustomAlias    string                 `protobuf:"bytes,2,opt,name=custom_alias,json=customAlias,proto3" json:"custom_alias,omitempty"`           // Пользовательский алиас вместо случайного кода (необязательно)
+	TtlSeconds     int64                  `protobuf:"varint,3,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`             // Время жизни ссылки в секундах (необязательно)
+	ExpiresAt      *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`                 // Абсолютное время истечения ссылки (необязательно)
+	RedirectStatus int32                  `protobuf:"varint,5,opt,name=redirect_status,json=redirectStatus,proto3" json:"redirect_status,omitempty"` // HTTP-статус редиректа: 301, 302, 307 или 308 (необязательно)
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *CreateURLRequest) Reset() {
@@ -66,11 +118,42 @@ func (x *CreateURLRequest) GetOriginalUrl() string {
 	return ""
 }
 
+func (x *CreateURLRequest) GetCustomAlias() string {
+	if x != nil {
+		return x.CustomAlias
+	}
+	return ""
+}
+
+func (x *CreateURLRequest) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *CreateURLRequest) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *CreateURLRequest) GetRedirectStatus() int32 {
+	if x != nil {
+		return x.RedirectStatus
+	}
+	return 0
+}
+
 // Ответ с коротким URL
 type CreateURLResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ShortUrl      string                 `protobuf:"bytes,1,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
-	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"` // Поле для ошибок, если они есть
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`                                // Поле для ошибок, если они есть
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`       // Время создания ссылки
+	OriginalUrl   string                 `protobuf:"bytes,4,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"` // Оригинальный URL после нормализации
+	Existing      bool                   `protobuf:"varint,5,opt,name=existing,proto3" json:"existing,omitempty"`                         // Оригинальный URL был сокращён раньше, возвращена существующая ссылка
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -119,6 +202,27 @@ func (x *CreateURLResponse) GetError() string {
 	return ""
 }
 
+func (x *CreateURLResponse) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *CreateURLResponse) GetOriginalUrl() string {
+	if x != nil {
+		return x.OriginalUrl
+	}
+	return ""
+}
+
+func (x *CreateURLResponse) GetExisting() bool {
+	if x != nil {
+		return x.Existing
+	}
+	return false
+}
+
 // Запрос для получения оригинального URL
 type GetURLRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -166,11 +270,12 @@ func (x *GetURLRequest) GetShortUrl() string {
 
 // Ответ с оригинальным URL
 type GetURLResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	OriginalUrl   string                 `protobuf:"bytes,1,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
-	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"` // Поле для ошибок, если они есть
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	OriginalUrl    string                 `protobuf:"bytes,1,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
+	Error          string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`                                          // Поле для ошибок, если они есть
+	RedirectStatus int32                  `protobuf:"varint,3,opt,name=redirect_status,json=redirectStatus,proto3" json:"redirect_status,omitempty"` // HTTP-статус редиректа ссылки, 0 — статус по умолчанию
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *GetURLResponse) Reset() {
@@ -217,24 +322,1270 @@ func (x *GetURLResponse) GetError() string {
 	return ""
 }
 
-var File_proto_urlshortener_proto protoreflect.FileDescriptor
+func (x *GetURLResponse) GetRedirectStatus() int32 {
+	if x != nil {
+		return x.RedirectStatus
+	}
+	return 0
+}
 
-const file_proto_urlshortener_proto_rawDesc = "" +
-	"\n" +
-	"\x18proto/urlshortener.proto\x12\x05proto\"5\n" +
-	"\x10CreateURLRequest\x12!\n" +
-	"\foriginal_url\x18\x01 \x01(\tR\voriginalUrl\"F\n" +
-	"\x11CreateURLResponse\x12\x1b\n" +
-	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\x12\x14\n" +
-	"\x05error\x18\x02 \x01(\tR\x05error\",\n" +
-	"\rGetURLRequest\x12\x1b\n" +
-	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\"I\n" +
-	"\x0eGetURLResponse\x12!\n" +
-	"\foriginal_url\x18\x01 \x01(\tR\voriginalUrl\x12\x14\n" +
-	"\x05error\x18\x02 \x01(\tR\x05error2\x89\x01\n" +
-	"\fURLShortener\x12@\n" +
-	"\tCreateURL\x12\x17.proto.CreateURLRequest\x1a\x18.proto.CreateURLResponse\"\x00\x127\n" +
-	"\x06GetURL\x12\x14.proto.GetURLRequest\x1a\x15.proto.GetURLResponse\"\x00B\tZ\a./protob\x06proto3"
+// Запрос сведений о короткой ссылке
+type GetURLInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ShortUrl      string                 `protobuf:"bytes,1,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetURLInfoRequest) Reset() {
+	*x = GetURLInfoRequest{}
+	mi := &file_proto_urlshortener_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetURLInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetURLInfoRequest) ProtoMessage() {}
+
+func (x *GetURLInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_urlshortener_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetURLInfoRequest.ProtoReflect.Descriptor instead.
+func (*GetURLInfoRequest) Descriptor() ([]byte, []int) {
+	return file_proto_urlshortener_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetURLInfoRequest) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+// Сведения о короткой ссылке
+type GetURLInfoResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ShortUrl       string                 `protobuf:"bytes,1,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	OriginalUrl    string                 `protobuf:"bytes,2,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
+	CreatedAt      *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	ExpiresAt      *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`                 // Не задано для бессрочной ссылки
+	RedirectStatus int32                  `protobuf:"varint,5,opt,name=redirect_status,json=redirectStatus,proto3" json:"redirect_status,omitempty"` // HTTP-статус редиректа ссылки, 0 — статус по умолчанию
+	Error          string                 `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`                                          // Поле для ошибок, если они есть
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetURLInfoResponse) Reset() {
+	*x = GetURLInfoResponse{}
+	mi := &file_proto_urlshortener_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetURLInfoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetURLInfoResponse) ProtoMessage() {}
+
+func (x *GetURLInfoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_urlshortener_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetURLInfoResponse.ProtoReflect.Descriptor instead.
+func (*GetURLInfoResponse) Descriptor() ([]byte, []int) {
+	return file_proto_urlshortener_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetURLInfoResponse) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+func (x *GetURLInfoResponse) GetOriginalUrl() string {
+	if x != nil {
+		return x.OriginalUrl
+	}
+	return ""
+}
+
+func (x *GetURLInfoResponse) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *GetURLInfoResponse) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *GetURLInfoResponse) GetRedirectStatus() int32 {
+	if x != nil {
+		return x.RedirectStatus
+	}
+	return 0
+}
+
+func (x *GetURLInfoResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Запрос статистики переходов по короткой ссылке
+type GetStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ShortUrl      string                 `protobuf:"bytes,1,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	BucketSeconds int64                  `protobuf:"varint,2,opt,name=bucket_seconds,json=bucketSeconds,proto3" json:"bucket_seconds,omitempty"` // Размер интервала группировки в секундах, по умолчанию сутки
+	From          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`                                         // Начало периода (необязательно)
+	To            *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=to,proto3" json:"to,omitempty"`                                             // Конец периода, не включительно (необязательно)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsRequest) Reset() {
+	*x = GetStatsRequest{}
+	mi := &file_proto_urlshortener_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsRequest) ProtoMessage() {}
+
+func (x *GetStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_urlshortener_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetStatsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_urlshortener_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetStatsRequest) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+func (x *GetStatsRequest) GetBucketSeconds() int64 {
+	if x != nil {
+		return x.BucketSeconds
+	}
+	return 0
+}
+
+func (x *GetStatsRequest) GetFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *GetStatsRequest) GetTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+// Количество переходов за интервал
+type ClickBucket struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	Clicks        int64                  `protobuf:"varint,2,opt,name=clicks,proto3" json:"clicks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClickBucket) Reset() {
+	*x = ClickBucket{}
+	mi := &file_proto_urlshortener_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClickBucket) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClickBucket) ProtoMessage() {}
+
+func (x *ClickBucket) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_urlshortener_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClickBucket.ProtoReflect.Descriptor instead.
+func (*ClickBucket) Descriptor() ([]byte, []int) {
+	return file_proto_urlshortener_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ClickBucket) GetStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *ClickBucket) GetClicks() int64 {
+	if x != nil {
+		return x.Clicks
+	}
+	return 0
+}
+
+// Статистика переходов по короткой ссылке
+type GetStatsResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	TotalClicks    int64                  `protobuf:"varint,1,opt,name=total_clicks,json=totalClicks,proto3" json:"total_clicks,omitempty"`
+	UniqueVisitors int64                  `protobuf:"varint,2,opt,name=unique_visitors,json=uniqueVisitors,proto3" json:"unique_visitors,omitempty"`
+	Buckets        []*ClickBucket         `protobuf:"bytes,3,rep,name=buckets,proto3" json:"buckets,omitempty"`
+	Error          string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"` // Поле для ошибок, если они есть
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GetStatsResponse) Reset() {
+	*x = GetStatsResponse{}
+	mi := &file_proto_urlshortener_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsResponse) ProtoMessage() {}
+
+func (x *GetStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_urlshortener_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetStatsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_urlshortener_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetStatsResponse) GetTotalClicks() int64 {
+	if x != nil {
+		return x.TotalClicks
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetUniqueVisitors() int64 {
+	if x != nil {
+		return x.UniqueVisitors
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetBuckets() []*ClickBucket {
+	if x != nil {
+		return x.Buckets
+	}
+	return nil
+}
+
+func (x *GetStatsResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Запрос на удаление короткой ссылки
+type DeleteURLRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ShortUrl      string                 `protobuf:"bytes,1,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteURLRequest) Reset() {
+	*x = DeleteURLRequest{}
+	mi := &file_proto_urlshortener_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteURLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteURLRequest) ProtoMessage() {}
+
+func (x *DeleteURLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_urlshortener_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteURLRequest.ProtoReflect.Descriptor instead.
+func (*DeleteURLRequest) Descriptor() ([]byte, []int) {
+	return file_proto_urlshortener_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteURLRequest) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+// Ответ на удаление короткой ссылки
+type DeleteURLResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Error         string                 `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"` // Поле для ошибок, если они есть
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteURLResponse) Reset() {
+	*x = DeleteURLResponse{}
+	mi := &file_proto_urlshortener_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteURLResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteURLResponse) ProtoMessage() {}
+
+func (x *DeleteURLResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_urlshortener_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteURLResponse.ProtoReflect.Descriptor instead.
+func (*DeleteURLResponse) Descriptor() ([]byte, []int) {
+	return file_proto_urlshortener_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DeleteURLResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Запрос на изменение оригинального URL короткой ссылки
+type UpdateURLRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ShortUrl      string                 `protobuf:"bytes,1,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	OriginalUrl   string                 `protobuf:"bytes,2,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"` // Новый оригинальный URL
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateURLRequest) Reset() {
+	*x = UpdateURLRequest{}
+	mi := &file_proto_urlshortener_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateURLRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateURLRequest) ProtoMessage() {}
+
+func (x *UpdateURLRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_urlshortener_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateURLRequest.ProtoReflect.Descriptor instead.
+func (*UpdateURLRequest) Descriptor() ([]byte, []int) {
+	return file_proto_urlshortener_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *UpdateURLRequest) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+func (x *UpdateURLRequest) GetOriginalUrl() string {
+	if x != nil {
+		return x.OriginalUrl
+	}
+	return ""
+}
+
+// Ответ на изменение короткой ссылки
+type UpdateURLResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Error         string                 `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"` // Поле для ошибок, если они есть
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateURLResponse) Reset() {
+	*x = UpdateURLResponse{}
+	mi := &file_proto_urlshortener_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateURLResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateURLResponse) ProtoMessage() {}
+
+func (x *UpdateURLResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_urlshortener_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateURLResponse.ProtoReflect.Descriptor instead.
+func (*UpdateURLResponse) Descriptor() ([]byte, []int) {
+	return file_proto_urlshortener_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *UpdateURLResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Ошибка обработки одного элемента пакета
+type ItemError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          int32                  `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`    // gRPC-код ошибки
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"` // Причина, как в google.rpc.ErrorInfo
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ItemError) Reset() {
+	*x = ItemError{}
+	mi := &file_proto_urlshortener_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ItemError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ItemError) ProtoMessage() {}
+
+func (x *ItemError) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_urlshortener_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ItemError.ProtoReflect.Descriptor instead.
+func (*ItemError) Descriptor() ([]byte, []int) {
+	return file_proto_urlshortener_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ItemError) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *ItemError) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ItemError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// Запрос на сокращение пакета URL
+type BatchCreateURLsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Urls          []*CreateURLRequest    `protobuf:"bytes,1,rep,name=urls,proto3" json:"urls,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchCreateURLsRequest) Reset() {
+	*x = BatchCreateURLsRequest{}
+	mi := &file_proto_urlshortener_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchCreateURLsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCreateURLsRequest) ProtoMessage() {}
+
+func (x *BatchCreateURLsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_urlshortener_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCreateURLsRequest.ProtoReflect.Descriptor instead.
+func (*BatchCreateURLsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_urlshortener_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *BatchCreateURLsRequest) GetUrls() []*CreateURLRequest {
+	if x != nil {
+		return x.Urls
+	}
+	return nil
+}
+
+// Результат сокращения одного URL пакета
+type BatchCreateURLResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ShortUrl      string                 `protobuf:"bytes,1,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	OriginalUrl   string                 `protobuf:"bytes,2,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"` // Оригинальный URL после нормализации
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Error         *ItemError             `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"` // Заполнено, если URL не сокращён
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchCreateURLResult) Reset() {
+	*x = BatchCreateURLResult{}
+	mi := &file_proto_urlshortener_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchCreateURLResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCreateURLResult) ProtoMessage() {}
+
+func (x *BatchCreateURLResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_urlshortener_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCreateURLResult.ProtoReflect.Descriptor instead.
+func (*BatchCreateURLResult) Descriptor() ([]byte, []int) {
+	return file_proto_urlshortener_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *BatchCreateURLResult) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+func (x *BatchCreateURLResult) GetOriginalUrl() string {
+	if x != nil {
+		return x.OriginalUrl
+	}
+	return ""
+}
+
+func (x *BatchCreateURLResult) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *BatchCreateURLResult) GetError() *ItemError {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+// Ответ на сокращение пакета URL, результаты в порядке запроса
+type BatchCreateURLsResponse struct {
+	state         protoimpl.MessageState  `protogen:"open.v1"`
+	Results       []*BatchCreateURLResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	Error         string                  `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"` // Поле для ошибок, если они есть
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchCreateURLsResponse) Reset() {
+	*x = BatchCreateURLsResponse{}
+	mi := &file_proto_urlshortener_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchCreateURLsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchCreateURLsResponse) ProtoMessage() {}
+
+func (x *BatchCreateURLsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_urlshortener_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchCreateURLsResponse.ProtoReflect.Descriptor instead.
+func (*BatchCreateURLsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_urlshortener_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *BatchCreateURLsResponse) GetResults() []*BatchCreateURLResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *BatchCreateURLsResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Запрос оригинальных URL для пакета коротких идентификаторов
+type BatchGetURLsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ShortUrls     []string               `protobuf:"bytes,1,rep,name=short_urls,json=shortUrls,proto3" json:"short_urls,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetURLsRequest) Reset() {
+	*x = BatchGetURLsRequest{}
+	mi := &file_proto_urlshortener_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetURLsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetURLsRequest) ProtoMessage() {}
+
+func (x *BatchGetURLsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_urlshortener_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetURLsRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetURLsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_urlshortener_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *BatchGetURLsRequest) GetShortUrls() []string {
+	if x != nil {
+		return x.ShortUrls
+	}
+	return nil
+}
+
+// Результат получения одной ссылки пакета
+type BatchGetURLResult struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ShortUrl       string                 `protobuf:"bytes,1,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	OriginalUrl    string                 `protobuf:"bytes,2,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
+	RedirectStatus int32                  `protobuf:"varint,3,opt,name=redirect_status,json=redirectStatus,proto3" json:"redirect_status,omitempty"`
+	Error          *ItemError             `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"` // Заполнено, если ссылка не найдена или истекла
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *BatchGetURLResult) Reset() {
+	*x = BatchGetURLResult{}
+	mi := &file_proto_urlshortener_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetURLResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetURLResult) ProtoMessage() {}
+
+func (x *BatchGetURLResult) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_urlshortener_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetURLResult.ProtoReflect.Descriptor instead.
+func (*BatchGetURLResult) Descriptor() ([]byte, []int) {
+	return file_proto_urlshortener_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *BatchGetURLResult) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+func (x *BatchGetURLResult) GetOriginalUrl() string {
+	if x != nil {
+		return x.OriginalUrl
+	}
+	return ""
+}
+
+func (x *BatchGetURLResult) GetRedirectStatus() int32 {
+	if x != nil {
+		return x.RedirectStatus
+	}
+	return 0
+}
+
+func (x *BatchGetURLResult) GetError() *ItemError {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+// Ответ с оригинальными URL, результаты в порядке запроса
+type BatchGetURLsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*BatchGetURLResult   `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"` // Поле для ошибок, если они есть
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetURLsResponse) Reset() {
+	*x = BatchGetURLsResponse{}
+	mi := &file_proto_urlshortener_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetURLsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetURLsResponse) ProtoMessage() {}
+
+func (x *BatchGetURLsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_urlshortener_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetURLsResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetURLsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_urlshortener_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *BatchGetURLsResponse) GetResults() []*BatchGetURLResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *BatchGetURLsResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// Запрос списка ссылок владельца ключа API. Истёкшие ссылки в список не входят
+type ListURLsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PageSize      int32                  `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`         // Число ссылок на странице, по умолчанию 50, не больше 1000
+	PageToken     string                 `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`       // next_page_token предыдущей страницы (необязательно)
+	CreatedFrom   *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_from,json=createdFrom,proto3" json:"created_from,omitempty"` // Созданные не раньше этого момента (необязательно)
+	CreatedTo     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_to,json=createdTo,proto3" json:"created_to,omitempty"`       // Созданные раньше этого момента (необязательно)
+	Domain        string                 `protobuf:"bytes,5,opt,name=domain,proto3" json:"domain,omitempty"`                              // Хост оригинального URL без порта (необязательно)
+	Contains      string                 `protobuf:"bytes,6,opt,name=contains,proto3" json:"contains,omitempty"`                          // Подстрока оригинального URL без учёта регистра (необязательно)
+	Order         ListOrder              `protobuf:"varint,7,opt,name=order,proto3,enum=proto.ListOrder" json:"order,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListURLsRequest) Reset() {
+	*x = ListURLsRequest{}
+	mi := &file_proto_urlshortener_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListURLsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListURLsRequest) ProtoMessage() {}
+
+func (x *ListURLsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_urlshortener_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListURLsRequest.ProtoReflect.Descriptor instead.
+func (*ListURLsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_urlshortener_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ListURLsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListURLsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListURLsRequest) GetCreatedFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedFrom
+	}
+	return nil
+}
+
+func (x *ListURLsRequest) GetCreatedTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedTo
+	}
+	return nil
+}
+
+func (x *ListURLsRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *ListURLsRequest) GetContains() string {
+	if x != nil {
+		return x.Contains
+	}
+	return ""
+}
+
+func (x *ListURLsRequest) GetOrder() ListOrder {
+	if x != nil {
+		return x.Order
+	}
+	return ListOrder_LIST_ORDER_NEWEST_FIRST
+}
+
+// Сведения о ссылке в списке
+type ListedURL struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	ShortUrl       string                 `protobuf:"bytes,1,opt,name=short_url,json=shortUrl,proto3" json:"short_url,omitempty"`
+	OriginalUrl    string                 `protobuf:"bytes,2,opt,name=original_url,json=originalUrl,proto3" json:"original_url,omitempty"`
+	CreatedAt      *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	ExpiresAt      *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`                 // Не задано для бессрочной ссылки
+	RedirectStatus int32                  `protobuf:"varint,5,opt,name=redirect_status,json=redirectStatus,proto3" json:"redirect_status,omitempty"` // HTTP-статус редиректа ссылки, 0 — статус по умолчанию
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ListedURL) Reset() {
+	*x = ListedURL{}
+	mi := &file_proto_urlshortener_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListedURL) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListedURL) ProtoMessage() {}
+
+func (x *ListedURL) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_urlshortener_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListedURL.ProtoReflect.Descriptor instead.
+func (*ListedURL) Descriptor() ([]byte, []int) {
+	return file_proto_urlshortener_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ListedURL) GetShortUrl() string {
+	if x != nil {
+		return x.ShortUrl
+	}
+	return ""
+}
+
+func (x *ListedURL) GetOriginalUrl() string {
+	if x != nil {
+		return x.OriginalUrl
+	}
+	return ""
+}
+
+func (x *ListedURL) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *ListedURL) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *ListedURL) GetRedirectStatus() int32 {
+	if x != nil {
+		return x.RedirectStatus
+	}
+	return 0
+}
+
+// Страница списка ссылок
+type ListURLsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Urls          []*ListedURL           `protobuf:"bytes,1,rep,name=urls,proto3" json:"urls,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"` // Пусто на последней странице
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`                                        // Поле для ошибок, если они есть
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListURLsResponse) Reset() {
+	*x = ListURLsResponse{}
+	mi := &file_proto_urlshortener_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListURLsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListURLsResponse) ProtoMessage() {}
+
+func (x *ListURLsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_urlshortener_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListURLsResponse.ProtoReflect.Descriptor instead.
+func (*ListURLsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_urlshortener_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ListURLsResponse) GetUrls() []*ListedURL {
+	if x != nil {
+		return x.Urls
+	}
+	return nil
+}
+
+func (x *ListURLsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+func (x *ListURLsResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_proto_urlshortener_proto protoreflect.FileDescriptor
+
+const file_proto_urlshortener_proto_rawDesc = "" +
+	"\n" +
+	"\x18proto/urlshortener.proto\x12\x05proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xdd\x01\n" +
+	"\x10CreateURLRequest\x12!\n" +
+	"\foriginal_url\x18\x01 \x01(\tR\voriginalUrl\x12!\n" +
+	"\fcustom_alias\x18\x02 \x01(\tR\vcustomAlias\x12\x1f\n" +
+	"\vttl_seconds\x18\x03 \x01(\x03R\n" +
+	"ttlSeconds\x129\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12'\n" +
+	"\x0fredirect_status\x18\x05 \x01(\x05R\x0eredirectStatus\"\xc0\x01\n" +
+	"\x11CreateURLResponse\x12\x1b\n" +
+	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x129\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12!\n" +
+	"\foriginal_url\x18\x04 \x01(\tR\voriginalUrl\x12\x1a\n" +
+	"\bexisting\x18\x05 \x01(\bR\bexisting\",\n" +
+	"\rGetURLRequest\x12\x1b\n" +
+	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\"r\n" +
+	"\x0eGetURLResponse\x12!\n" +
+	"\foriginal_url\x18\x01 \x01(\tR\voriginalUrl\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12'\n" +
+	"\x0fredirect_status\x18\x03 \x01(\x05R\x0eredirectStatus\"0\n" +
+	"\x11GetURLInfoRequest\x12\x1b\n" +
+	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\"\x89\x02\n" +
+	"\x12GetURLInfoResponse\x12\x1b\n" +
+	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\x12!\n" +
+	"\foriginal_url\x18\x02 \x01(\tR\voriginalUrl\x129\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12'\n" +
+	"\x0fredirect_status\x18\x05 \x01(\x05R\x0eredirectStatus\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\tR\x05error\"\xb1\x01\n" +
+	"\x0fGetStatsRequest\x12\x1b\n" +
+	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\x12%\n" +
+	"\x0ebucket_seconds\x18\x02 \x01(\x03R\rbucketSeconds\x12.\n" +
+	"\x04from\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04from\x12*\n" +
+	"\x02to\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x02to\"W\n" +
+	"\vClickBucket\x120\n" +
+	"\x05start\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05start\x12\x16\n" +
+	"\x06clicks\x18\x02 \x01(\x03R\x06clicks\"\xa2\x01\n" +
+	"\x10GetStatsResponse\x12!\n" +
+	"\ftotal_clicks\x18\x01 \x01(\x03R\vtotalClicks\x12'\n" +
+	"\x0funique_visitors\x18\x02 \x01(\x03R\x0euniqueVisitors\x12,\n" +
+	"\abuckets\x18\x03 \x03(\v2\x12.proto.ClickBucketR\abuckets\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\"/\n" +
+	"\x10DeleteURLRequest\x12\x1b\n" +
+	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\")\n" +
+	"\x11DeleteURLResponse\x12\x14\n" +
+	"\x05error\x18\x01 \x01(\tR\x05error\"R\n" +
+	"\x10UpdateURLRequest\x12\x1b\n" +
+	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\x12!\n" +
+	"\foriginal_url\x18\x02 \x01(\tR\voriginalUrl\")\n" +
+	"\x11UpdateURLResponse\x12\x14\n" +
+	"\x05error\x18\x01 \x01(\tR\x05error\"Q\n" +
+	"\tItemError\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"E\n" +
+	"\x16BatchCreateURLsRequest\x12+\n" +
+	"\x04urls\x18\x01 \x03(\v2\x17.proto.CreateURLRequestR\x04urls\"\xb9\x01\n" +
+	"\x14BatchCreateURLResult\x12\x1b\n" +
+	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\x12!\n" +
+	"\foriginal_url\x18\x02 \x01(\tR\voriginalUrl\x129\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12&\n" +
+	"\x05error\x18\x04 \x01(\v2\x10.proto.ItemErrorR\x05error\"f\n" +
+	"\x17BatchCreateURLsResponse\x125\n" +
+	"\aresults\x18\x01 \x03(\v2\x1b.proto.BatchCreateURLResultR\aresults\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"4\n" +
+	"\x13BatchGetURLsRequest\x12\x1d\n" +
+	"\n" +
+	"short_urls\x18\x01 \x03(\tR\tshortUrls\"\xa4\x01\n" +
+	"\x11BatchGetURLResult\x12\x1b\n" +
+	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\x12!\n" +
+	"\foriginal_url\x18\x02 \x01(\tR\voriginalUrl\x12'\n" +
+	"\x0fredirect_status\x18\x03 \x01(\x05R\x0eredirectStatus\x12&\n" +
+	"\x05error\x18\x04 \x01(\v2\x10.proto.ItemErrorR\x05error\"`\n" +
+	"\x14BatchGetURLsResponse\x122\n" +
+	"\aresults\x18\x01 \x03(\v2\x18.proto.BatchGetURLResultR\aresults\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"\xa3\x02\n" +
+	"\x0fListURLsRequest\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\x12=\n" +
+	"\fcreated_from\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\vcreatedFrom\x129\n" +
+	"\n" +
+	"created_to\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedTo\x12\x16\n" +
+	"\x06domain\x18\x05 \x01(\tR\x06domain\x12\x1a\n" +
+	"\bcontains\x18\x06 \x01(\tR\bcontains\x12&\n" +
+	"\x05order\x18\a \x01(\x0e2\x10.proto.ListOrderR\x05order\"\xea\x01\n" +
+	"\tListedURL\x12\x1b\n" +
+	"\tshort_url\x18\x01 \x01(\tR\bshortUrl\x12!\n" +
+	"\foriginal_url\x18\x02 \x01(\tR\voriginalUrl\x129\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"expires_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12'\n" +
+	"\x0fredirect_status\x18\x05 \x01(\x05R\x0eredirectStatus\"v\n" +
+	"\x10ListURLsResponse\x12$\n" +
+	"\x04urls\x18\x01 \x03(\v2\x10.proto.ListedURLR\x04urls\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error*E\n" +
+	"\tListOrder\x12\x1b\n" +
+	"\x17LIST_ORDER_NEWEST_FIRST\x10\x00\x12\x1b\n" +
+	"\x17LIST_ORDER_OLDEST_FIRST\x10\x012\xc2\x05\n" +
+	"\fURLShortener\x12@\n" +
+	"\tCreateURL\x12\x17.proto.CreateURLRequest\x1a\x18.proto.CreateURLResponse\"\x00\x127\n" +
+	"\x06GetURL\x12\x14.proto.GetURLRequest\x1a\x15.proto.GetURLResponse\"\x00\x12C\n" +
+	"\n" +
+	"GetURLInfo\x12\x18.proto.GetURLInfoRequest\x1a\x19.proto.GetURLInfoResponse\"\x00\x12=\n" +
+	"\bGetStats\x12\x16.proto.GetStatsRequest\x1a\x17.proto.GetStatsResponse\"\x00\x12@\n" +
+	"\tDeleteURL\x12\x17.proto.DeleteURLRequest\x1a\x18.proto.DeleteURLResponse\"\x00\x12@\n" +
+	"\tUpdateURL\x12\x17.proto.UpdateURLRequest\x1a\x18.proto.UpdateURLResponse\"\x00\x12R\n" +
+	"\x0fBatchCreateURLs\x12\x1d.proto.BatchCreateURLsRequest\x1a\x1e.proto.BatchCreateURLsResponse\"\x00\x12Q\n" +
+	"\x10StreamCreateURLs\x12\x17.proto.CreateURLRequest\x1a\x1e.proto.BatchCreateURLsResponse\"\x00(\x010\x01\x12I\n" +
+	"\fBatchGetURLs\x12\x1a.proto.BatchGetURLsRequest\x1a\x1b.proto.BatchGetURLsResponse\"\x00\x12=\n" +
+	"\bListURLs\x12\x16.proto.ListURLsRequest\x1a\x17.proto.ListURLsResponse\"\x00B\tZ\a./protob\x06proto3"
 
 var (
 	file_proto_urlshortener_proto_rawDescOnce sync.Once
@@ -248,23 +1599,81 @@ func file_proto_urlshortener_proto_rawDescGZIP() []byte {
 	return file_proto_urlshortener_proto_rawDescData
 }
 
-var file_proto_urlshortener_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_proto_urlshortener_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_urlshortener_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
 var file_proto_urlshortener_proto_goTypes = []any{
-	(*CreateURLRequest)(nil),  // 0: proto.CreateURLRequest
-	(*CreateURLResponse)(nil), // 1: proto.CreateURLResponse
-	(*GetURLRequest)(nil),     // 2: proto.GetURLRequest
-	(*GetURLResponse)(nil),    // 3: proto.GetURLResponse
+	(ListOrder)(0),                  // 0: proto.ListOrder
+	(*CreateURLRequest)(nil),        // 1: proto.CreateURLRequest
+	(*CreateURLResponse)(nil),       // 2: proto.CreateURLResponse
+	(*GetURLRequest)(nil),           // 3: proto.GetURLRequest
+	(*GetURLResponse)(nil),          // 4: proto.GetURLResponse
+	(*GetURLInfoRequest)(nil),       // 5: proto.GetURLInfoRequest
+	(*GetURLInfoResponse)(nil),      // 6: proto.GetURLInfoResponse
+	(*GetStatsRequest)(nil),         // 7: proto.GetStatsRequest
+	(*ClickBucket)(nil),             // 8: proto.ClickBucket
+	(*GetStatsResponse)(nil),        // 9: proto.GetStatsResponse
+	(*DeleteURLRequest)(nil),        // 10: proto.DeleteURLRequest
+	(*DeleteURLResponse)(nil),       // 11: proto.DeleteURLResponse
+	(*UpdateURLRequest)(nil),        // 12: proto.UpdateURLRequest
+	(*UpdateURLResponse)(nil),       // 13: proto.UpdateURLResponse
+	(*ItemError)(nil),               // 14: proto.ItemError
+	(*BatchCreateURLsRequest)(nil),  // 15: proto.BatchCreateURLsRequest
+	(*BatchCreateURLResult)(nil),    // 16: proto.BatchCreateURLResult
+	(*BatchCreateURLsResponse)(nil), // 17: proto.BatchCreateURLsResponse
+	(*BatchGetURLsRequest)(nil),     // 18: proto.BatchGetURLsRequest
+	(*BatchGetURLResult)(nil),       // 19: proto.BatchGetURLResult
+	(*BatchGetURLsResponse)(nil),    // 20: proto.BatchGetURLsResponse
+	(*ListURLsRequest)(nil),         // 21: proto.ListURLsRequest
+	(*ListedURL)(nil),               // 22: proto.ListedURL
+	(*ListURLsResponse)(nil),        // 23: proto.ListURLsResponse
+	(*timestamppb.Timestamp)(nil),   // 24: google.protobuf.Timestamp
 }
 var file_proto_urlshortener_proto_depIdxs = []int32{
-	0, // 0: proto.URLShortener.CreateURL:input_type -> proto.CreateURLRequest
-	2, // 1: proto.URLShortener.GetURL:input_type -> proto.GetURLRequest
-	1, // 2: proto.URLShortener.CreateURL:output_type -> proto.CreateURLResponse
-	3, // 3: proto.URLShortener.GetURL:output_type -> proto.GetURLResponse
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	24, // 0: proto.CreateURLRequest.expires_at:type_name -> google.protobuf.Timestamp
+	24, // 1: proto.CreateURLResponse.created_at:type_name -> google.protobuf.Timestamp
+	24, // 2: proto.GetURLInfoResponse.created_at:type_name -> google.protobuf.Timestamp
+	24, // 3: proto.GetURLInfoResponse.expires_at:type_name -> google.protobuf.Timestamp
+	24, // 4: proto.GetStatsRequest.from:type_name -> google.protobuf.Timestamp
+	24, // 5: proto.GetStatsRequest.to:type_name -> google.protobuf.Timestamp
+	24, // 6: proto.ClickBucket.start:type_name -> google.protobuf.Timestamp
+	8,  // 7: proto.GetStatsResponse.buckets:type_name -> proto.ClickBucket
+	1,  // 8: proto.BatchCreateURLsRequest.urls:type_name -> proto.CreateURLRequest
+	24, // 9: proto.BatchCreateURLResult.created_at:type_name -> google.protobuf.Timestamp
+	14, // 10: proto.BatchCreateURLResult.error:type_name -> proto.ItemError
+	16, // 11: proto.BatchCreateURLsResponse.results:type_name -> proto.BatchCreateURLResult
+	14, // 12: proto.BatchGetURLResult.error:type_name -> proto.ItemError
+	19, // 13: proto.BatchGetURLsResponse.results:type_name -> proto.BatchGetURLResult
+	24, // 14: proto.ListURLsRequest.created_from:type_name -> google.protobuf.Timestamp
+	24, // 15: proto.ListURLsRequest.created_to:type_name -> google.protobuf.Timestamp
+	0,  // 16: proto.ListURLsRequest.order:type_name -> proto.ListOrder
+	24, // 17: proto.ListedURL.created_at:type_name -> google.protobuf.Timestamp
+	24, // 18: proto.ListedURL.expires_at:type_name -> google.protobuf.Timestamp
+	22, // 19: proto.ListURLsResponse.urls:type_name -> proto.ListedURL
+	1,  // 20: proto.URLShortener.CreateURL:input_type -> proto.CreateURLRequest
+	3,  // 21: proto.URLShortener.GetURL:input_type -> proto.GetURLRequest
+	5,  // 22: proto.URLShortener.GetURLInfo:input_type -> proto.GetURLInfoRequest
+	7,  // 23: proto.URLShortener.GetStats:input_type -> proto.GetStatsRequest
+	10, // 24: proto.URLShortener.DeleteURL:input_type -> proto.DeleteURLRequest
+	12, // 25: proto.URLShortener.UpdateURL:input_type -> proto.UpdateURLRequest
+	15, // 26: proto.URLShortener.BatchCreateURLs:input_type -> proto.BatchCreateURLsRequest
+	1,  // 27: proto.URLShortener.StreamCreateURLs:input_type -> proto.CreateURLRequest
+	18, // 28: proto.URLShortener.BatchGetURLs:input_type -> proto.BatchGetURLsRequest
+	21, // 29: proto.URLShortener.ListURLs:input_type -> proto.ListURLsRequest
+	2,  // 30: proto.URLShortener.CreateURL:output_type -> proto.CreateURLResponse
+	4,  // 31: proto.URLShortener.GetURL:output_type -> proto.GetURLResponse
+	6,  // 32: proto.URLShortener.GetURLInfo:output_type -> proto.GetURLInfoResponse
+	9,  // 33: proto.URLShortener.GetStats:output_type -> proto.GetStatsResponse
+	11, // 34: proto.URLShortener.DeleteURL:output_type -> proto.DeleteURLResponse
+	13, // 35: proto.URLShortener.UpdateURL:output_type -> proto.UpdateURLResponse
+	17, // 36: proto.URLShortener.BatchCreateURLs:output_type -> proto.BatchCreateURLsResponse
+	17, // 37: proto.URLShortener.StreamCreateURLs:output_type -> proto.BatchCreateURLsResponse
+	20, // 38: proto.URLShortener.BatchGetURLs:output_type -> proto.BatchGetURLsResponse
+	23, // 39: proto.URLShortener.ListURLs:output_type -> proto.ListURLsResponse
+	30, // [30:40] is the sub-list for method output_type
+	20, // [20:30] is the sub-list for method input_type
+	20, // [20:20] is the sub-list for extension type_name
+	20, // [20:20] is the sub-list for extension extendee
+	0,  // [0:20] is the sub-list for field type_name
 }
 
 func init() { file_proto_urlshortener_proto_init() }
@@ -277,13 +1686,14 @@ func file_proto_urlshortener_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_urlshortener_proto_rawDesc), len(file_proto_urlshortener_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   4,
+			NumEnums:      1,
+			NumMessages:   23,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_proto_urlshortener_proto_goTypes,
 		DependencyIndexes: file_proto_urlshortener_proto_depIdxs,
+		EnumInfos:         file_proto_urlshortener_proto_enumTypes,
 		MessageInfos:      file_proto_urlshortener_proto_msgTypes,
 	}.Build()
 	File_proto_urlshortener_proto = out.File
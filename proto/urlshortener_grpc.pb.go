@@ -19,8 +19,16 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	URLShortener_CreateURL_FullMethodName = "/proto.URLShortener/CreateURL"
-	URLShortener_GetURL_FullMethodName    = "/proto.URLShortener/GetURL"
+	URLShortener_CreateURL_FullMethodName        = "/proto.URLShortener/CreateURL"
+	URLShortener_GetURL_FullMethodName           = "/proto.URLShortener/GetURL"
+	URLShortener_GetURLInfo_FullMethodName       = "/proto.URLShortener/GetURLInfo"
+	URLShortener_GetStats_FullMethodName         = "/proto.URLShortener/GetStats"
+	URLShortener_DeleteURL_FullMethodName        = "/proto.URLShortener/DeleteURL"
+	URLShortener_UpdateURL_FullMethodName        = "/proto.URLShortener/UpdateURL"
+	URLShortener_BatchCreateURLs_FullMethodName  = "/proto.URLShortener/BatchCreateURLs"
+	URLShortener_StreamCreateURLs_FullMethodName = "/proto.URLShortener/StreamCreateURLs"
+	URLShortener_BatchGetURLs_FullMethodName     = "/proto.URLShortener/BatchGetURLs"
+	URLShortener_ListURLs_FullMethodName         = "/proto.URLShortener/ListURLs"
 )
 
 // URLShortenerClient is the client API for URLShortener service.
@@ -33,6 +41,23 @@ type URLShortenerClient interface {
 	CreateURL(ctx context.Context, in *CreateURLRequest, opts ...grpc.CallOption) (*CreateURLResponse, error)
 	// Получить оригинальный URL по короткому идентификатору
 	GetURL(ctx context.Context, in *GetURLRequest, opts ...grpc.CallOption) (*GetURLResponse, error)
+	// Получить сведения о короткой ссылке без учёта перехода в статистике
+	GetURLInfo(ctx context.Context, in *GetURLInfoRequest, opts ...grpc.CallOption) (*GetURLInfoResponse, error)
+	// Получить статистику переходов по короткому идентификатору
+	GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error)
+	// Удалить короткую ссылку
+	DeleteURL(ctx context.Context, in *DeleteURLRequest, opts ...grpc.CallOption) (*DeleteURLResponse, error)
+	// Изменить оригинальный URL короткой ссылки
+	UpdateURL(ctx context.Context, in *UpdateURLRequest, opts ...grpc.CallOption) (*UpdateURLResponse, error)
+	// Сократить пакет URL; ошибки возвращаются для каждого URL отдельно
+	BatchCreateURLs(ctx context.Context, in *BatchCreateURLsRequest, opts ...grpc.CallOption) (*BatchCreateURLsResponse, error)
+	// Сократить поток URL для очень больших пакетов. URL сохраняются частями, результаты каждой части
+	// возвращаются отдельным сообщением в порядке отправки
+	StreamCreateURLs(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[CreateURLRequest, BatchCreateURLsResponse], error)
+	// Получить оригинальные URL для пакета коротких идентификаторов без учёта переходов в статистике
+	BatchGetURLs(ctx context.Context, in *BatchGetURLsRequest, opts ...grpc.CallOption) (*BatchGetURLsResponse, error)
+	// Получить ссылки владельца ключа API постранично с фильтрами
+	ListURLs(ctx context.Context, in *ListURLsRequest, opts ...grpc.CallOption) (*ListURLsResponse, error)
 }
 
 type uRLShortenerClient struct {
@@ -63,6 +88,89 @@ func (c *uRLShortenerClient) GetURL(ctx context.Context, in *GetURLRequest, opts
 	return out, nil
 }
 
+func (c *uRLShortenerClient) GetURLInfo(ctx context.Context, in *GetURLInfoRequest, opts ...grpc.CallOption) (*GetURLInfoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetURLInfoResponse)
+	err := c.cc.Invoke(ctx, URLShortener_GetURLInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerClient) GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetStatsResponse)
+	err := c.cc.Invoke(ctx, URLShortener_GetStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerClient) DeleteURL(ctx context.Context, in *DeleteURLRequest, opts ...grpc.CallOption) (*DeleteURLResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteURLResponse)
+	err := c.cc.Invoke(ctx, URLShortener_DeleteURL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerClient) UpdateURL(ctx context.Context, in *UpdateURLRequest, opts ...grpc.CallOption) (*UpdateURLResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateURLResponse)
+	err := c.cc.Invoke(ctx, URLShortener_UpdateURL_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerClient) BatchCreateURLs(ctx context.Context, in *BatchCreateURLsRequest, opts ...grpc.CallOption) (*BatchCreateURLsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchCreateURLsResponse)
+	err := c.cc.Invoke(ctx, URLShortener_BatchCreateURLs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerClient) StreamCreateURLs(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[CreateURLRequest, BatchCreateURLsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &URLShortener_ServiceDesc.Streams[0], URLShortener_StreamCreateURLs_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[CreateURLRequest, BatchCreateURLsResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type URLShortener_StreamCreateURLsClient = grpc.BidiStreamingClient[CreateURLRequest, BatchCreateURLsResponse]
+
+func (c *uRLShortenerClient) BatchGetURLs(ctx context.Context, in *BatchGetURLsRequest, opts ...grpc.CallOption) (*BatchGetURLsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchGetURLsResponse)
+	err := c.cc.Invoke(ctx, URLShortener_BatchGetURLs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uRLShortenerClient) ListURLs(ctx context.Context, in *ListURLsRequest, opts ...grpc.CallOption) (*ListURLsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListURLsResponse)
+	err := c.cc.Invoke(ctx, URLShortener_ListURLs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // URLShortenerServer is the server API for URLShortener service.
 // All implementations must embed UnimplementedURLShortenerServer
 // for forward compatibility.
@@ -73,6 +181,23 @@ type URLShortenerServer interface {
 	CreateURL(context.Context, *CreateURLRequest) (*CreateURLResponse, error)
 	// Получить оригинальный URL по короткому идентификатору
 	GetURL(context.Context, *GetURLRequest) (*GetURLResponse, error)
+	// Получить сведения о короткой ссылке без учёта перехода в статистике
+	GetURLInfo(context.Context, *GetURLInfoRequest) (*GetURLInfoResponse, error)
+	// Получить статистику переходов по короткому идентификатору
+	GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error)
+	// Удалить короткую ссылку
+	DeleteURL(context.Context, *DeleteURLRequest) (*DeleteURLResponse, error)
+	// Изменить оригинальный URL короткой ссылки
+	UpdateURL(context.Context, *UpdateURLRequest) (*UpdateURLResponse, error)
+	// Сократить пакет URL; ошибки возвращаются для каждого URL отдельно
+	BatchCreateURLs(context.Context, *BatchCreateURLsRequest) (*BatchCreateURLsResponse, error)
+	// Сократить поток URL для очень больших пакетов. URL сохраняются частями, результаты каждой части
+	// возвращаются отдельным сообщением в порядке отправки
+	StreamCreateURLs(grpc.BidiStreamingServer[CreateURLRequest, BatchCreateURLsResponse]) error
+	// Получить оригинальные URL для пакета коротких идентификаторов без учёта переходов в статистике
+	BatchGetURLs(context.Context, *BatchGetURLsRequest) (*BatchGetURLsResponse, error)
+	// Получить ссылки владельца ключа API постранично с фильтрами
+	ListURLs(context.Context, *ListURLsRequest) (*ListURLsResponse, error)
 	mustEmbedUnimplementedURLShortenerServer()
 }
 
@@ -89,6 +214,30 @@ func (UnimplementedURLShortenerServer) CreateURL(context.Context, *CreateURLRequ
 func (UnimplementedURLShortenerServer) GetURL(context.Context, *GetURLRequest) (*GetURLResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetURL not implemented")
 }
+func (UnimplementedURLShortenerServer) GetURLInfo(context.Context, *GetURLInfoRequest) (*GetURLInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetURLInfo not implemented")
+}
+func (UnimplementedURLShortenerServer) GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
+}
+func (UnimplementedURLShortenerServer) DeleteURL(context.Context, *DeleteURLRequest) (*DeleteURLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteURL not implemented")
+}
+func (UnimplementedURLShortenerServer) UpdateURL(context.Context, *UpdateURLRequest) (*UpdateURLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateURL not implemented")
+}
+func (UnimplementedURLShortenerServer) BatchCreateURLs(context.Context, *BatchCreateURLsRequest) (*BatchCreateURLsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchCreateURLs not implemented")
+}
+func (UnimplementedURLShortenerServer) StreamCreateURLs(grpc.BidiStreamingServer[CreateURLRequest, BatchCreateURLsResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamCreateURLs not implemented")
+}
+func (UnimplementedURLShortenerServer) BatchGetURLs(context.Context, *BatchGetURLsRequest) (*BatchGetURLsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchGetURLs not implemented")
+}
+func (UnimplementedURLShortenerServer) ListURLs(context.Context, *ListURLsRequest) (*ListURLsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListURLs not implemented")
+}
 func (UnimplementedURLShortenerServer) mustEmbedUnimplementedURLShortenerServer() {}
 func (UnimplementedURLShortenerServer) testEmbeddedByValue()                      {}
 
@@ -146,6 +295,139 @@ func _URLShortener_GetURL_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _URLShortener_GetURLInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetURLInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServer).GetURLInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortener_GetURLInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServer).GetURLInfo(ctx, req.(*GetURLInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortener_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortener_GetStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServer).GetStats(ctx, req.(*GetStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortener_DeleteURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServer).DeleteURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortener_DeleteURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServer).DeleteURL(ctx, req.(*DeleteURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortener_UpdateURL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateURLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServer).UpdateURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortener_UpdateURL_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServer).UpdateURL(ctx, req.(*UpdateURLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortener_BatchCreateURLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchCreateURLsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServer).BatchCreateURLs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortener_BatchCreateURLs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServer).BatchCreateURLs(ctx, req.(*BatchCreateURLsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortener_StreamCreateURLs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(URLShortenerServer).StreamCreateURLs(&grpc.GenericServerStream[CreateURLRequest, BatchCreateURLsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type URLShortener_StreamCreateURLsServer = grpc.BidiStreamingServer[CreateURLRequest, BatchCreateURLsResponse]
+
+func _URLShortener_BatchGetURLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetURLsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServer).BatchGetURLs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortener_BatchGetURLs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServer).BatchGetURLs(ctx, req.(*BatchGetURLsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _URLShortener_ListURLs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListURLsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(URLShortenerServer).ListURLs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: URLShortener_ListURLs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(URLShortenerServer).ListURLs(ctx, req.(*ListURLsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // URLShortener_ServiceDesc is the grpc.ServiceDesc for URLShortener service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -161,7 +443,42 @@ var URLShortener_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetURL",
 			Handler:    _URLShortener_GetURL_Handler,
 		},
+		{
+			MethodName: "GetURLInfo",
+			Handler:    _URLShortener_GetURLInfo_Handler,
+		},
+		{
+			MethodName: "GetStats",
+			Handler:    _URLShortener_GetStats_Handler,
+		},
+		{
+			MethodName: "DeleteURL",
+			Handler:    _URLShortener_DeleteURL_Handler,
+		},
+		{
+			MethodName: "UpdateURL",
+			Handler:    _URLShortener_UpdateURL_Handler,
+		},
+		{
+			MethodName: "BatchCreateURLs",
+			Handler:    _URLShortener_BatchCreateURLs_Handler,
+		},
+		{
+			MethodName: "BatchGetURLs",
+			Handler:    _URLShortener_BatchGetURLs_Handler,
+		},
+		{
+			MethodName: "ListURLs",
+			Handler:    _URLShortener_ListURLs_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamCreateURLs",
+			Handler:       _URLShortener_StreamCreateURLs_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/urlshortener.proto",
 }